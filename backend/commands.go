@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"eamagineweather-backend/internal/config"
+	"eamagineweather-backend/internal/database"
+	"eamagineweather-backend/internal/regionimport"
+	"eamagineweather-backend/internal/repository/sqlite"
+	"eamagineweather-backend/internal/service"
+)
+
+const commandUsage = `commands:
+  import   import the region CSV into the database
+  diff     compare the region CSV with the database
+  validate check the region CSV without a database
+  probe    check which villages BMKG has forecasts for
+  alias    point a retired region code at its successor
+`
+
+// runCommand runs a maintenance command and returns the exit code
+func runCommand(cfg *config.Config, name string, args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch name {
+	case "import":
+		return importRegions(ctx, cfg, args)
+	case "diff":
+		return diffRegions(ctx, cfg, args)
+	case "alias":
+		return aliasRegion(ctx, cfg, args)
+	case "validate":
+		return validateRegions(args)
+	case "probe":
+		return probeRegions(ctx, cfg, args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, commandUsage)
+		return 2
+	}
+}
+
+func importRegions(ctx context.Context, cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	input := flags.String("input", "indonesian_regions.csv", "region CSV of code,name rows")
+	batchSize := flags.Int("batch-size", 500, "rows upserted per transaction")
+	checkpoint := flags.String("checkpoint", "region-import.checkpoint", "checkpoint file for resuming; empty disables it")
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing")
+	deactivate := flags.Bool("deactivate-missing", true, "deactivate regions missing from the CSV and alias moved codes; turn off for partial files")
+	flags.Parse(args)
+
+	opts := regionimport.Options{
+		BatchSize:         *batchSize,
+		Checkpoint:        *checkpoint,
+		DryRun:            *dryRun,
+		DeactivateMissing: *deactivate,
+	}
+	if sqlite.IsURL(cfg.DatabaseURL) {
+		return importRegionsSQLite(ctx, cfg, *input, opts)
+	}
+
+	db, err := database.NewConnection(cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return 1
+	}
+	defer db.Disconnect()
+
+	report, err := regionimport.New(db.Client, opts).Import(ctx, *input)
+	if report != nil {
+		printImportReport(report, *dryRun)
+	}
+	if err != nil {
+		log.Printf("Import failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// importRegionsSQLite loads the whole CSV in one transaction. Batches and
+// checkpoints are not needed at SQLite sizes, and moved codes are not
+// aliased.
+func importRegionsSQLite(ctx context.Context, cfg *config.Config, input string, opts regionimport.Options) int {
+	regions, report, err := regionimport.Load(input)
+	if err != nil {
+		log.Printf("Import failed: %v", err)
+		return 1
+	}
+
+	db, err := sqlite.Open(cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("Failed to open database: %v", err)
+		return 1
+	}
+	defer db.Close()
+
+	err = db.ImportRegions(ctx, regions, opts, report)
+	printImportReport(report, opts.DryRun)
+	if err != nil {
+		log.Printf("Import failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+func diffRegions(ctx context.Context, cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	input := flags.String("input", "indonesian_regions.csv", "region CSV of code,name rows")
+	flags.Parse(args)
+
+	if sqlite.IsURL(cfg.DatabaseURL) {
+		log.Printf("diff needs Postgres; with SQLite use import -dry-run")
+		return 1
+	}
+
+	db, err := database.NewConnection(cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return 1
+	}
+	defer db.Disconnect()
+
+	diff, invalid, err := regionimport.New(db.Client, regionimport.Options{}).Diff(ctx, *input)
+	if err != nil {
+		log.Printf("Diff failed: %v", err)
+		return 1
+	}
+	printInvalid(invalid)
+	printDiff(diff, true)
+	return 0
+}
+
+// validateRegions checks a region CSV on its own and exits 1 if any row
+// would be left out of an import
+func validateRegions(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	input := flags.String("input", "indonesian_regions.csv", "region CSV of code,name rows")
+	flags.Parse(args)
+
+	file, err := os.Open(*input)
+	if err != nil {
+		log.Printf("Failed to open %s: %v", *input, err)
+		return 1
+	}
+	defer file.Close()
+
+	rows, unreadable, err := regionimport.ReadCSV(file)
+	if err != nil {
+		log.Printf("Failed to read %s: %v", *input, err)
+		return 1
+	}
+	printInvalid(unreadable)
+	problems := regionimport.Validate(rows, nil)
+	printProblems(problems)
+
+	errors := len(unreadable) + regionimport.Errors(problems)
+	fmt.Printf("rows: %d\nerrors: %d\nwarnings: %d\n", len(rows)+len(unreadable), errors, len(problems)-regionimport.Errors(problems))
+	if errors > 0 {
+		return 1
+	}
+	return 0
+}
+
+// probeRegions runs one BMKG availability probe of the villages that are
+// due, like the periodic probe
+func probeRegions(ctx context.Context, cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("probe", flag.ExitOnError)
+	batchSize := flags.Int("batch-size", cfg.BMKGProbeBatchSize, "villages probed")
+	flags.Parse(args)
+
+	storage, err := database.Open(cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return 1
+	}
+	defer storage.Close()
+
+	redisClient := database.NewRedisClient(cfg.RedisURL)
+	if redisClient != nil {
+		defer redisClient.Close()
+	}
+
+	policy := probePolicy(cfg)
+	policy.BatchSize = *batchSize
+	bmkgService := service.NewBMKGService(cfg.BMKGAPIBaseURL, cfg.BMKGAPIRateLimit, redisClient)
+	probeService := service.NewProbeService(storage.Regions, bmkgService, policy)
+
+	report, err := probeService.Run(ctx)
+	if report != nil {
+		fmt.Printf("probed: %d\navailable: %d\nunavailable: %d\nfailed: %d\n",
+			report.Probed, report.Available, report.Unavailable, report.Failed)
+	}
+	if err != nil {
+		log.Printf("Probe failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+func probePolicy(cfg *config.Config) service.ProbePolicy {
+	return service.ProbePolicy{
+		Interval:         cfg.BMKGProbeInterval,
+		BatchSize:        cfg.BMKGProbeBatchSize,
+		RetryUnavailable: cfg.BMKGProbeRetryUnavailable,
+		RecheckAvailable: cfg.BMKGProbeRecheckAvailable,
+	}
+}
+
+func aliasRegion(ctx context.Context, cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("alias", flag.ExitOnError)
+	reason := flags.String("reason", "manual", "why the code was retired")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: alias [-reason text] <old code> <new code>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	storage, err := database.Open(cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return 1
+	}
+	defer storage.Close()
+
+	oldCode, newCode := flags.Arg(0), flags.Arg(1)
+	if _, err := storage.RegionAliases.Upsert(ctx, oldCode, newCode, *reason); err != nil {
+		log.Printf("Failed to save alias: %v", err)
+		return 1
+	}
+	fmt.Printf("%s -> %s\n", oldCode, newCode)
+	return 0
+}
+
+func printImportReport(report *regionimport.Report, dryRun bool) {
+	printInvalid(report.Invalid)
+	printProblems(report.Warnings)
+	if report.Diff != nil {
+		printDiff(report.Diff, false)
+	}
+	if dryRun {
+		fmt.Println("Dry run, nothing was written")
+	}
+	if report.Resumed {
+		fmt.Println("Resumed from checkpoint; counts include the earlier run")
+	}
+	fmt.Printf("inserted: %d\nupdated: %d\nunchanged: %d\ninvalid: %d\nwarnings: %d\ndeactivated: %d\naliased: %d\nfavorites moved: %d\n",
+		report.Inserted, report.Updated, report.Unchanged, len(report.Invalid), len(report.Warnings),
+		report.Deactivated, report.Aliased, report.FavoritesMoved)
+}
+
+func printInvalid(invalid []regionimport.Invalid) {
+	for _, row := range invalid {
+		fmt.Printf("line %d: %s %s\n", row.Line, row.Code, row.Reason)
+	}
+}
+
+func printProblems(problems []regionimport.Problem) {
+	for _, problem := range problems {
+		fmt.Printf("line %d: %s %s %s\n", problem.Line, problem.Severity, problem.Code, problem.Message)
+	}
+}
+
+// printDiff lists changes one per line: + added, - removed, ~ renamed,
+// > moved. Added regions are only counted unless listAdded is set, since a
+// first import adds every region.
+func printDiff(diff *regionimport.Diff, listAdded bool) {
+	if listAdded {
+		for _, region := range diff.Added {
+			fmt.Printf("+ %s %s\n", region.Code, region.Name)
+		}
+	}
+	for _, region := range diff.Removed {
+		fmt.Printf("- %s %s\n", region.Code, region.Name)
+	}
+	for _, rename := range diff.Renamed {
+		fmt.Printf("~ %s %s -> %s\n", rename.Code, rename.OldName, rename.NewName)
+	}
+	for _, move := range diff.Moved {
+		fmt.Printf("> %s -> %s %s\n", move.OldCode, move.NewCode, move.Name)
+	}
+	fmt.Printf("added: %d, removed: %d, renamed: %d, moved: %d\n",
+		len(diff.Added), len(diff.Removed), len(diff.Renamed), len(diff.Moved))
+}
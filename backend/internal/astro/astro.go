@@ -0,0 +1,136 @@
+// Package astro computes solar events and the lunar phase offline. Solar
+// times follow the NOAA sunrise equation and are accurate to about a minute
+// at Indonesian latitudes, which is enough for display purposes.
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	julianUnixEpoch = 2440587.5 // Julian date of 1970-01-01T00:00:00Z
+	julian2000      = 2451545.0 // Julian date of 2000-01-01T12:00:00Z
+	obliquity       = 23.4397   // Earth's axial tilt in degrees
+
+	sunriseAltitude = -0.833 // Refraction plus solar disc radius
+	civilAltitude   = -6.0
+
+	synodicMonth = 29.530588853 // Days between new moons
+	newMoon2000  = 2451550.1    // Julian date of the new moon of 2000-01-06
+)
+
+// SolarDay holds solar events for one local calendar day. Times are zero
+// when the event does not occur, which cannot happen in Indonesia but can
+// at polar latitudes.
+type SolarDay struct {
+	Sunrise   time.Time
+	Sunset    time.Time
+	SolarNoon time.Time
+	CivilDawn time.Time
+	CivilDusk time.Time
+	DayLength time.Duration
+}
+
+// Sun computes solar events for the calendar day of date in loc
+func Sun(date time.Time, latitude, longitude float64, loc *time.Location) SolarDay {
+	local := date.In(loc)
+	noon := time.Date(local.Year(), local.Month(), local.Day(), 12, 0, 0, 0, loc)
+
+	n := math.Round(toJulian(noon) - julian2000 + 0.0008)
+	meanNoon := n - longitude/360
+
+	anomaly := normalizeDegrees(357.5291 + 0.98560028*meanNoon)
+	m := radians(anomaly)
+	center := 1.9148*math.Sin(m) + 0.0200*math.Sin(2*m) + 0.0003*math.Sin(3*m)
+	eclipticLongitude := radians(normalizeDegrees(anomaly + center + 180 + 102.9372))
+
+	transit := julian2000 + meanNoon + 0.0053*math.Sin(m) - 0.0069*math.Sin(2*eclipticLongitude)
+	declination := math.Asin(math.Sin(eclipticLongitude) * math.Sin(radians(obliquity)))
+
+	day := SolarDay{SolarNoon: fromJulian(transit, loc)}
+
+	if ha, ok := hourAngle(sunriseAltitude, latitude, declination); ok {
+		day.Sunrise = fromJulian(transit-ha/360, loc)
+		day.Sunset = fromJulian(transit+ha/360, loc)
+		day.DayLength = day.Sunset.Sub(day.Sunrise)
+	}
+	if ha, ok := hourAngle(civilAltitude, latitude, declination); ok {
+		day.CivilDawn = fromJulian(transit-ha/360, loc)
+		day.CivilDusk = fromJulian(transit+ha/360, loc)
+	}
+
+	return day
+}
+
+// hourAngle returns the hour angle in degrees at which the sun crosses the
+// given altitude, or false if it never does that day.
+func hourAngle(altitude, latitude, declination float64) (float64, bool) {
+	phi := radians(latitude)
+	cos := (math.Sin(radians(altitude)) - math.Sin(phi)*math.Sin(declination)) /
+		(math.Cos(phi) * math.Cos(declination))
+	if cos < -1 || cos > 1 {
+		return 0, false
+	}
+	return degrees(math.Acos(cos)), true
+}
+
+// MoonPhase describes the lunar phase at an instant
+type MoonPhase struct {
+	Phase        float64 // 0 = new, 0.25 = first quarter, 0.5 = full, 0.75 = last quarter
+	Illumination float64 // Illuminated fraction of the disc, 0–1
+	Age          float64 // Days since the last new moon
+	Name         string  // Indonesian
+	NameEn       string  // English
+}
+
+var moonPhaseNames = [8][2]string{
+	{"Bulan Baru", "New Moon"},
+	{"Sabit Awal", "Waxing Crescent"},
+	{"Kuartal Pertama", "First Quarter"},
+	{"Cembung Awal", "Waxing Gibbous"},
+	{"Purnama", "Full Moon"},
+	{"Cembung Akhir", "Waning Gibbous"},
+	{"Kuartal Akhir", "Last Quarter"},
+	{"Sabit Akhir", "Waning Crescent"},
+}
+
+// Moon returns the lunar phase at t using the mean synodic month, which is
+// within about a day of the true phase.
+func Moon(t time.Time) MoonPhase {
+	age := math.Mod(toJulian(t)-newMoon2000, synodicMonth)
+	if age < 0 {
+		age += synodicMonth
+	}
+	phase := age / synodicMonth
+	names := moonPhaseNames[int(math.Floor(phase*8+0.5))%8]
+
+	return MoonPhase{
+		Phase:        phase,
+		Illumination: (1 - math.Cos(2*math.Pi*phase)) / 2,
+		Age:          age,
+		Name:         names[0],
+		NameEn:       names[1],
+	}
+}
+
+func toJulian(t time.Time) float64 {
+	return float64(t.Unix())/86400 + julianUnixEpoch
+}
+
+func fromJulian(jd float64, loc *time.Location) time.Time {
+	seconds := (jd - julianUnixEpoch) * 86400
+	return time.Unix(0, int64(seconds*1e9)).Round(time.Second).In(loc)
+}
+
+func normalizeDegrees(d float64) float64 {
+	d = math.Mod(d, 360)
+	if d < 0 {
+		d += 360
+	}
+	return d
+}
+
+func radians(d float64) float64 { return d * math.Pi / 180 }
+
+func degrees(r float64) float64 { return r * 180 / math.Pi }
@@ -0,0 +1,97 @@
+// Package cap encodes alerts as Common Alerting Protocol 1.2 documents
+// (OASIS CAP-V1.2), the format emergency systems ingest. Struct fields are
+// declared in the order the CAP schema requires.
+package cap
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Namespace of CAP 1.2 documents
+const Namespace = "urn:oasis:names:tc:emergency:cap:1.2"
+
+// Values of the enumerated CAP elements we use
+const (
+	StatusActual = "Actual"
+	MsgTypeAlert = "Alert"
+	ScopePublic  = "Public"
+	CategoryMet  = "Met"
+
+	UrgencyImmediate = "Immediate"
+	UrgencyExpected  = "Expected"
+	UrgencyFuture    = "Future"
+
+	SeverityExtreme  = "Extreme"
+	SeveritySevere   = "Severe"
+	SeverityModerate = "Moderate"
+	SeverityMinor    = "Minor"
+	SeverityUnknown  = "Unknown"
+
+	CertaintyLikely = "Likely"
+)
+
+// Time is a CAP dateTime. CAP requires an explicit offset and forbids the
+// "Z" designator, so UTC is written as +00:00.
+type Time time.Time
+
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(time.Time(t).Format("2006-01-02T15:04:05-07:00")), nil
+}
+
+// Alert is the root <alert> element
+type Alert struct {
+	XMLName    xml.Name `xml:"urn:oasis:names:tc:emergency:cap:1.2 alert"`
+	Identifier string   `xml:"identifier"`
+	Sender     string   `xml:"sender"`
+	Sent       Time     `xml:"sent"`
+	Status     string   `xml:"status"`
+	MsgType    string   `xml:"msgType"`
+	Scope      string   `xml:"scope"`
+	Info       []Info   `xml:"info"`
+}
+
+// Info is one <info> block
+type Info struct {
+	Language    string   `xml:"language,omitempty"`
+	Category    []string `xml:"category"`
+	Event       string   `xml:"event"`
+	Urgency     string   `xml:"urgency"`
+	Severity    string   `xml:"severity"`
+	Certainty   string   `xml:"certainty"`
+	Effective   *Time    `xml:"effective,omitempty"`
+	Onset       *Time    `xml:"onset,omitempty"`
+	Expires     *Time    `xml:"expires,omitempty"`
+	SenderName  string   `xml:"senderName,omitempty"`
+	Headline    string   `xml:"headline,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	Web         string   `xml:"web,omitempty"`
+	Area        []Area   `xml:"area"`
+}
+
+// Area is an <area> block
+type Area struct {
+	AreaDesc string  `xml:"areaDesc"`
+	Geocode  []Value `xml:"geocode"`
+}
+
+// Value is a named value such as a <geocode>
+type Value struct {
+	ValueName string `xml:"valueName"`
+	Value     string `xml:"value"`
+}
+
+// Write encodes the alert as a standalone XML document
+func (a Alert) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(a); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,242 @@
+package cap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// schemaPath is the OASIS CAP 1.2 schema, vendored so the tests run offline
+const schemaPath = "testdata/CAP-v1.2.xsd"
+
+func sampleAlert() Alert {
+	wib := time.FixedZone("WIB", 7*60*60)
+	sent := time.Date(2026, 1, 15, 9, 30, 0, 0, wib)
+	onset := Time(sent.Add(time.Hour))
+	expires := Time(sent.Add(6 * time.Hour))
+	return Alert{
+		Identifier: "eamagineweather-alert-42",
+		Sender:     "alerts@eamagineweather.id",
+		Sent:       Time(sent),
+		Status:     StatusActual,
+		MsgType:    MsgTypeAlert,
+		Scope:      ScopePublic,
+		Info: []Info{{
+			Language:    "id-ID",
+			Category:    []string{CategoryMet},
+			Event:       "Hujan Lebat",
+			Urgency:     UrgencyExpected,
+			Severity:    SeveritySevere,
+			Certainty:   CertaintyLikely,
+			Effective:   &onset,
+			Onset:       &onset,
+			Expires:     &expires,
+			SenderName:  "EamagineWeather",
+			Headline:    "Hujan lebat di Kota Bandung",
+			Description: "Hujan lebat disertai angin kencang",
+			Web:         "https://eamagineweather.id/alerts/42",
+			Area: []Area{{
+				AreaDesc: "Kota Bandung, Jawa Barat",
+				Geocode:  []Value{{ValueName: "KEMENDAGRI", Value: "32.73"}},
+			}},
+		}},
+	}
+}
+
+func encode(t *testing.T, alert Alert) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := alert.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// node is any XML element, for walking both the schema and documents
+type node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []node     `xml:",any"`
+}
+
+func (n node) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// schemaElement is an element declared in the schema with the sequence of
+// its children, empty for simple elements
+type schemaElement struct {
+	name      string
+	required  bool
+	repeating bool
+	children  []schemaElement
+}
+
+func loadSchema(t *testing.T) schemaElement {
+	t.Helper()
+	raw, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root node
+	if err := xml.Unmarshal(raw, &root); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range root.Nodes {
+		if n.XMLName.Local == "element" && n.attr("name") == "alert" {
+			return declaration(n)
+		}
+	}
+	t.Fatal("schema declares no alert element")
+	return schemaElement{}
+}
+
+func declaration(n node) schemaElement {
+	name := n.attr("name")
+	if ref := n.attr("ref"); ref != "" {
+		name = strings.TrimPrefix(ref, "cap:")
+	}
+	e := schemaElement{
+		name:      name,
+		required:  n.attr("minOccurs") != "0",
+		repeating: n.attr("maxOccurs") == "unbounded",
+	}
+	for _, complexType := range n.Nodes {
+		if complexType.XMLName.Local != "complexType" {
+			continue
+		}
+		for _, sequence := range complexType.Nodes {
+			for _, child := range sequence.Nodes {
+				// The trailing <any> admits xmldsig signatures
+				if child.XMLName.Local == "element" {
+					e.children = append(e.children, declaration(child))
+				}
+			}
+		}
+	}
+	return e
+}
+
+// conform reports every child of doc that the schema sequence does not
+// allow where it appears, and every required child that is missing
+func conform(t *testing.T, path string, doc node, schema schemaElement) {
+	t.Helper()
+	if doc.XMLName.Space != Namespace {
+		t.Errorf("%s: namespace %q, want %q", path, doc.XMLName.Space, Namespace)
+	}
+	seen := make([]bool, len(schema.children))
+	next := 0
+	for _, child := range doc.Nodes {
+		name := child.XMLName.Local
+		i := next
+		for i < len(schema.children) && schema.children[i].name != name {
+			i++
+		}
+		if i == len(schema.children) {
+			t.Errorf("%s: <%s> is not allowed here; expected one of %v", path, name, remaining(schema.children[next:]))
+			continue
+		}
+		seen[i] = true
+		next = i
+		if !schema.children[i].repeating {
+			next++
+		}
+		conform(t, path+"/"+name, child, schema.children[i])
+	}
+	for i, declared := range schema.children {
+		if declared.required && !seen[i] {
+			t.Errorf("%s: required <%s> is missing", path, declared.name)
+		}
+	}
+}
+
+func remaining(elements []schemaElement) []string {
+	names := make([]string, len(elements))
+	for i, e := range elements {
+		names[i] = e.name
+	}
+	return names
+}
+
+func TestSchemaRequiredElements(t *testing.T) {
+	schema := loadSchema(t)
+	var required []string
+	for _, e := range schema.children {
+		if e.required {
+			required = append(required, e.name)
+		}
+	}
+	want := []string{"identifier", "sender", "sent", "status", "msgType", "scope"}
+	if !reflect.DeepEqual(required, want) {
+		t.Fatalf("schema requires %v in <alert>, want %v", required, want)
+	}
+}
+
+func TestAlertFollowsSchema(t *testing.T) {
+	schema := loadSchema(t)
+	var doc node
+	if err := xml.Unmarshal(encode(t, sampleAlert()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.XMLName.Local != "alert" {
+		t.Fatalf("root element <%s>, want <alert>", doc.XMLName.Local)
+	}
+	conform(t, "alert", doc, schema)
+
+	var order []string
+	for _, child := range doc.Nodes {
+		order = append(order, child.XMLName.Local)
+	}
+	want := []string{"identifier", "sender", "sent", "status", "msgType", "scope", "info"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("alert children %v, want %v", order, want)
+	}
+}
+
+func TestAlertAreaDesc(t *testing.T) {
+	var doc struct {
+		AreaDesc []string `xml:"info>area>areaDesc"`
+	}
+	if err := xml.Unmarshal(encode(t, sampleAlert()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Kota Bandung, Jawa Barat"}; !reflect.DeepEqual(doc.AreaDesc, want) {
+		t.Errorf("info/area/areaDesc = %v, want %v", doc.AreaDesc, want)
+	}
+}
+
+func TestTimeHasExplicitOffset(t *testing.T) {
+	text, _ := Time(time.Date(2026, 1, 15, 2, 30, 0, 0, time.UTC)).MarshalText()
+	if got, want := string(text), "2026-01-15T02:30:00+00:00"; got != want {
+		t.Errorf("Time = %s, want %s", got, want)
+	}
+}
+
+// TestAlertValidatesWithXmllint runs full XSD validation, including the
+// enumerations and the dateTime pattern, where libxml2's xmllint is
+// installed
+func TestAlertValidatesWithXmllint(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not installed")
+	}
+	document := filepath.Join(t.TempDir(), "alert.xml")
+	if err := os.WriteFile(document, encode(t, sampleAlert()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(xmllint, "--noout", "--schema", schemaPath, document).CombinedOutput()
+	if err != nil {
+		t.Errorf("xmllint: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,237 @@
+// Package condition maps BMKG's free-text weather descriptions onto a fixed
+// set of conditions with stable numeric codes, so clients can switch on a
+// code instead of string-matching "Cerah Berawan" or "Hujan Petir".
+package condition
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Code identifies a condition. Values are part of the public API and must
+// never be renumbered; add new conditions with new codes instead.
+type Code int
+
+const (
+	Unknown            Code = 0
+	Clear              Code = 100
+	MostlyClear        Code = 101
+	PartlyCloudy       Code = 102
+	Overcast           Code = 103
+	Haze               Code = 200
+	Smoke              Code = 201
+	Fog                Code = 202
+	Drizzle            Code = 300
+	LightRain          Code = 301
+	Rain               Code = 302
+	HeavyRain          Code = 303
+	VeryHeavyRain      Code = 304
+	Showers            Code = 310
+	Thunderstorm       Code = 400
+	SevereThunderstorm Code = 401
+)
+
+// Info describes a condition
+type Info struct {
+	Code      Code   `json:"code"`
+	Key       string `json:"key"`      // Stable machine name, e.g. "light_rain"
+	Name      string `json:"name"`     // Indonesian
+	NameEn    string `json:"name_en"`  // English
+	Severity  int    `json:"severity"` // Higher is worse; use to pick the dominant condition
+	WMO       int    `json:"wmo_code"` // Nearest WMO 4677 present-weather code; BMKG itself publishes its own weather codes
+	IconDay   string `json:"icon_day"`
+	IconNight string `json:"icon_night"`
+}
+
+var conditions = map[Code]Info{
+	Unknown:            {Unknown, "unknown", "Tidak Diketahui", "Unknown", 0, -1, "unknown", "unknown"},
+	Clear:              {Clear, "clear", "Cerah", "Clear", 1, 0, "clear-day", "clear-night"},
+	MostlyClear:        {MostlyClear, "mostly_clear", "Cerah Berawan", "Mostly Clear", 2, 1, "mostly-clear-day", "mostly-clear-night"},
+	PartlyCloudy:       {PartlyCloudy, "partly_cloudy", "Berawan", "Partly Cloudy", 3, 2, "partly-cloudy-day", "partly-cloudy-night"},
+	Overcast:           {Overcast, "overcast", "Berawan Tebal", "Overcast", 4, 3, "overcast", "overcast"},
+	Haze:               {Haze, "haze", "Udara Kabur", "Haze", 5, 5, "haze-day", "haze-night"},
+	Smoke:              {Smoke, "smoke", "Asap", "Smoke", 6, 4, "smoke", "smoke"},
+	Fog:                {Fog, "fog", "Kabut", "Fog", 7, 45, "fog-day", "fog-night"},
+	Drizzle:            {Drizzle, "drizzle", "Gerimis", "Drizzle", 8, 51, "drizzle", "drizzle"},
+	LightRain:          {LightRain, "light_rain", "Hujan Ringan", "Light Rain", 9, 61, "light-rain", "light-rain"},
+	Showers:            {Showers, "showers", "Hujan Lokal", "Isolated Showers", 10, 80, "showers-day", "showers-night"},
+	Rain:               {Rain, "rain", "Hujan Sedang", "Moderate Rain", 11, 63, "rain", "rain"},
+	HeavyRain:          {HeavyRain, "heavy_rain", "Hujan Lebat", "Heavy Rain", 12, 65, "heavy-rain", "heavy-rain"},
+	VeryHeavyRain:      {VeryHeavyRain, "very_heavy_rain", "Hujan Sangat Lebat", "Very Heavy Rain", 13, 65, "heavy-rain", "heavy-rain"},
+	Thunderstorm:       {Thunderstorm, "thunderstorm", "Hujan Petir", "Thunderstorm", 14, 95, "thunderstorm-day", "thunderstorm-night"},
+	SevereThunderstorm: {SevereThunderstorm, "severe_thunderstorm", "Hujan Badai Petir", "Severe Thunderstorm", 15, 97, "severe-thunderstorm", "severe-thunderstorm"},
+}
+
+// Every spelling BMKG has used in weather_desc and weather_desc_en, after
+// normalize. Both languages share one table because BMKG occasionally
+// swaps the two fields.
+var descriptions = map[string]Code{
+	"cerah":                  Clear,
+	"clear":                  Clear,
+	"clear skies":            Clear,
+	"sunny":                  Clear,
+	"cerah berawan":          MostlyClear,
+	"mostly clear":           MostlyClear,
+	"mostly sunny":           MostlyClear,
+	"partly cloudy":          PartlyCloudy,
+	"berawan":                PartlyCloudy,
+	"cloudy":                 PartlyCloudy,
+	"mostly cloudy":          PartlyCloudy,
+	"berawan tebal":          Overcast,
+	"overcast":               Overcast,
+	"overcast clouds":        Overcast,
+	"udara kabur":            Haze,
+	"kabur":                  Haze,
+	"haze":                   Haze,
+	"hazy":                   Haze,
+	"asap":                   Smoke,
+	"kabut asap":             Smoke,
+	"smoke":                  Smoke,
+	"kabut":                  Fog,
+	"fog":                    Fog,
+	"mist":                   Fog,
+	"gerimis":                Drizzle,
+	"drizzle":                Drizzle,
+	"hujan ringan":           LightRain,
+	"light rain":             LightRain,
+	"hujan lokal":            Showers,
+	"hujan lokal ringan":     Showers,
+	"isolated shower":        Showers,
+	"isolated showers":       Showers,
+	"local rain":             Showers,
+	"shower":                 Showers,
+	"showers":                Showers,
+	"hujan":                  Rain,
+	"hujan sedang":           Rain,
+	"rain":                   Rain,
+	"moderate rain":          Rain,
+	"hujan lebat":            HeavyRain,
+	"heavy rain":             HeavyRain,
+	"hujan sangat lebat":     VeryHeavyRain,
+	"hujan ekstrem":          VeryHeavyRain,
+	"very heavy rain":        VeryHeavyRain,
+	"extreme rain":           VeryHeavyRain,
+	"hujan petir":            Thunderstorm,
+	"petir":                  Thunderstorm,
+	"thunderstorm":           Thunderstorm,
+	"thunderstorms":          Thunderstorm,
+	"hujan disertai petir":   Thunderstorm,
+	"hujan badai":            SevereThunderstorm,
+	"hujan badai petir":      SevereThunderstorm,
+	"badai petir":            SevereThunderstorm,
+	"severe thunderstorm":    SevereThunderstorm,
+	"severe thunderstorms":   SevereThunderstorm,
+	"heavy thunderstorm":     SevereThunderstorm,
+	"thunderstorm with hail": SevereThunderstorm,
+}
+
+// maxUnknown bounds the counter so a misbehaving upstream cannot grow it
+// without limit; further new descriptions are only logged
+const maxUnknown = 256
+
+var (
+	unknownMu     sync.Mutex
+	unknownCounts = map[string]int64{}
+)
+
+// Lookup returns the description of a code, or Unknown's for codes that do
+// not exist
+func Lookup(code Code) Info {
+	if info, ok := conditions[code]; ok {
+		return info
+	}
+	return conditions[Unknown]
+}
+
+// All returns every condition ordered by severity
+func All() []Info {
+	all := make([]Info, 0, len(conditions))
+	for _, info := range conditions {
+		all = append(all, info)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Severity < all[j].Severity })
+	return all
+}
+
+// Classify maps a BMKG description pair to a condition. The Indonesian text
+// is tried first. Descriptions that match neither are logged the first time
+// they are seen and counted, see UnknownDescriptions.
+func Classify(desc, descEn string) Code {
+	if code, ok := descriptions[normalize(desc)]; ok {
+		return code
+	}
+	if code, ok := descriptions[normalize(descEn)]; ok {
+		return code
+	}
+	if desc == "" && descEn == "" {
+		return Unknown
+	}
+
+	key := desc + " / " + descEn
+	unknownMu.Lock()
+	count, seen := unknownCounts[key]
+	if seen || len(unknownCounts) < maxUnknown {
+		unknownCounts[key] = count + 1
+	}
+	unknownMu.Unlock()
+	if !seen {
+		log.Printf("Unknown weather description %q / %q", desc, descEn)
+	}
+	return Unknown
+}
+
+// UnknownDescription is a description pair Classify could not map
+type UnknownDescription struct {
+	Description string `json:"description"`
+	Count       int64  `json:"count"`
+}
+
+// UnknownDescriptions returns the unmapped descriptions seen since start,
+// most frequent first
+func UnknownDescriptions() []UnknownDescription {
+	unknownMu.Lock()
+	defer unknownMu.Unlock()
+
+	result := make([]UnknownDescription, 0, len(unknownCounts))
+	for desc, count := range unknownCounts {
+		result = append(result, UnknownDescription{Description: desc, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Description < result[j].Description
+	})
+	return result
+}
+
+// Icon returns the icon key for a code at day or night
+func Icon(code Code, night bool) string {
+	info := Lookup(code)
+	if night {
+		return info.IconNight
+	}
+	return info.IconDay
+}
+
+// IsWet reports whether a condition brings rain, drizzle or thunderstorms
+func IsWet(code Code) bool {
+	return code >= Drizzle
+}
+
+// Worst returns the most severe of the given codes, or Unknown if there are none
+func Worst(codes ...Code) Code {
+	worst := Unknown
+	for _, code := range codes {
+		if Lookup(code).Severity > Lookup(worst).Severity {
+			worst = code
+		}
+	}
+	return worst
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -3,7 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -20,6 +22,44 @@ type Config struct {
 	SupabaseURL      string
 	SupabaseAnonKey  string
 	SupabaseServiceKey string
+
+	WeatherBatchMaxRegions int // Most region codes accepted by the batch current-weather endpoint
+
+	// Where /regions endpoints read from: "database", or "embedded" for the
+	// dataset compiled in with the embedregions build tag. Without
+	// DATABASE_URL only the embedded regions are served.
+	RegionSource string
+
+	// gRPC API for internal services
+	GRPCPort       string // Empty disables the gRPC server
+	GRPCReflection bool   // Register server reflection, for grpcurl and similar tools
+
+	// MQTT publishing of weather and alerts
+	MQTTBrokerURL   string // Empty disables publishing
+	MQTTUsername    string
+	MQTTPassword    string
+	MQTTClientID    string
+	MQTTTopicPrefix string // Root of every published topic
+
+	// GraphQL query limits
+	GraphQLMaxDepth      int // Deepest field nesting allowed
+	GraphQLMaxComplexity int // Highest estimated query cost allowed
+
+	// Periodic BMKG sync
+	WeatherSyncInterval   time.Duration // Time between sync runs
+	WeatherSyncMaxRegions int           // Villages fetched per run
+
+	// BMKG availability probe
+	BMKGProbeInterval         time.Duration // Time between probe runs; 0 disables
+	BMKGProbeBatchSize        int           // Villages probed per run
+	BMKGProbeRetryUnavailable time.Duration // Re-probe villages BMKG had no forecast for after this
+	BMKGProbeRecheckAvailable time.Duration // Re-probe villages BMKG had a forecast for after this
+
+	// Weather data retention
+	WeatherRawRetentionDays   int           // Keep raw 3-hourly points this long before compacting
+	WeatherHardRetentionDays  int           // Delete raw points older than this
+	WeatherRetentionBatchSize int           // Rows deleted per statement
+	WeatherRetentionInterval  time.Duration // How often the retention job runs
 }
 
 func Load() *Config {
@@ -29,16 +69,49 @@ func Load() *Config {
 	}
 
 	config := &Config{
-		DatabaseURL:      getEnv("DATABASE_URL", "postgresql://localhost:5432/eamagine_weather?sslmode=disable"),
+		DatabaseURL:      getEnv("DATABASE_URL", ""),
 		RedisURL:         getEnv("REDIS_URL", "redis://localhost:6379"),
 		Port:             getEnv("PORT", "8080"),
 		GinMode:          getEnv("GIN_MODE", "debug"),
 		BMKGAPIBaseURL:   getEnv("BMKG_API_BASE_URL", "https://api.bmkg.go.id/publik"),
-		BMKGAPIRateLimit: 60,
+		BMKGAPIRateLimit: getEnvInt("BMKG_API_RATE_LIMIT", 60),
 		JWTSecret:        getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
 		SupabaseURL:      getEnv("SUPABASE_URL", ""),
 		SupabaseAnonKey:  getEnv("SUPABASE_ANON_KEY", ""),
 		SupabaseServiceKey: getEnv("SUPABASE_SERVICE_ROLE_KEY", ""),
+
+		WeatherBatchMaxRegions: getEnvInt("WEATHER_BATCH_MAX_REGIONS", 50),
+
+		RegionSource: getEnv("REGION_SOURCE", "database"),
+
+		GRPCPort:       getEnv("GRPC_PORT", "9090"),
+		GRPCReflection: getEnvBool("GRPC_REFLECTION", false),
+
+		MQTTBrokerURL:   getEnv("MQTT_BROKER_URL", ""),
+		MQTTUsername:    getEnv("MQTT_USERNAME", ""),
+		MQTTPassword:    getEnv("MQTT_PASSWORD", ""),
+		MQTTClientID:    getEnv("MQTT_CLIENT_ID", "eamagineweather-backend"),
+		MQTTTopicPrefix: getEnv("MQTT_TOPIC_PREFIX", "eamagine"),
+
+		GraphQLMaxDepth:      getEnvInt("GRAPHQL_MAX_DEPTH", 8),
+		GraphQLMaxComplexity: getEnvInt("GRAPHQL_MAX_COMPLEXITY", 1000),
+
+		WeatherSyncInterval:   getEnvDuration("WEATHER_SYNC_INTERVAL", 3*time.Hour),
+		WeatherSyncMaxRegions: getEnvInt("WEATHER_SYNC_MAX_REGIONS", 500),
+
+		BMKGProbeInterval:         getEnvDuration("BMKG_PROBE_INTERVAL", time.Hour),
+		BMKGProbeBatchSize:        getEnvInt("BMKG_PROBE_BATCH_SIZE", 200),
+		BMKGProbeRetryUnavailable: getEnvDuration("BMKG_PROBE_RETRY_UNAVAILABLE", 30*24*time.Hour),
+		BMKGProbeRecheckAvailable: getEnvDuration("BMKG_PROBE_RECHECK_AVAILABLE", 90*24*time.Hour),
+
+		WeatherRawRetentionDays:   getEnvInt("WEATHER_RAW_RETENTION_DAYS", 7),
+		WeatherHardRetentionDays:  getEnvInt("WEATHER_HARD_RETENTION_DAYS", 30),
+		WeatherRetentionBatchSize: getEnvInt("WEATHER_RETENTION_BATCH_SIZE", 5000),
+		WeatherRetentionInterval:  getEnvDuration("WEATHER_RETENTION_INTERVAL", 24*time.Hour),
+	}
+
+	if config.DatabaseURL == "" {
+		config.RegionSource = "embedded"
 	}
 
 	// Parse allowed origins
@@ -57,4 +130,34 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+		log.Printf("Invalid integer for %s, using default %d", key, defaultValue)
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+		log.Printf("Invalid duration for %s, using default %s", key, defaultValue)
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+		log.Printf("Invalid boolean for %s, using default %t", key, defaultValue)
+	}
+	return defaultValue
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/regionstore"
+	"eamagineweather-backend/internal/repository"
+	"eamagineweather-backend/internal/repository/sqlite"
+)
+
+// Storage is the storage backend DATABASE_URL selects: SQLite for sqlite:
+// and file: URLs, Postgres through Prisma otherwise
+type Storage struct {
+	Regions       repository.RegionRepository
+	Weather       repository.WeatherRepository
+	Users         repository.UserRepository
+	SyncRuns      repository.SyncRunRepository
+	Alerts        repository.AlertRepository
+	RegionAliases repository.RegionAliasRepository
+
+	// Exactly one of these is set
+	Prisma *db.PrismaClient
+	SQLite *sqlite.DB
+}
+
+// Open connects to the backend databaseURL names
+func Open(databaseURL string) (*Storage, error) {
+	if sqlite.IsURL(databaseURL) {
+		database, err := sqlite.Open(databaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return &Storage{
+			Regions:       database.Regions(),
+			Weather:       database.Weather(),
+			Users:         database.Users(),
+			SyncRuns:      database.SyncRuns(),
+			Alerts:        database.Alerts(),
+			RegionAliases: database.RegionAliases(),
+			SQLite:        database,
+		}, nil
+	}
+
+	connection, err := NewConnection(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Storage{
+		Regions:       repository.NewRegionRepository(connection.Client),
+		Weather:       repository.NewWeatherRepository(connection.Client),
+		Users:         repository.NewUserRepository(connection.Client),
+		SyncRuns:      repository.NewSyncRunRepository(connection.Client),
+		Alerts:        repository.NewAlertRepository(connection.Client),
+		RegionAliases: repository.NewRegionAliasRepository(connection.Client),
+		Prisma:        connection.Client,
+	}, nil
+}
+
+// RegionStore returns the store reading the regions table of the backend
+func (s *Storage) RegionStore() regionstore.Store {
+	if s.SQLite != nil {
+		return s.SQLite.RegionStore()
+	}
+	return regionstore.NewPrisma(s.Prisma)
+}
+
+// Close disconnects from the backend
+func (s *Storage) Close() {
+	if s.SQLite != nil {
+		s.SQLite.Close()
+		return
+	}
+	(&Database{Client: s.Prisma}).Disconnect()
+}
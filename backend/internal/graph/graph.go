@@ -0,0 +1,148 @@
+// Package graph serves a GraphQL API over regions, their weather and
+// alerts, and user favorites, so a client can fetch a region with its
+// ancestors, weather and alerts in one round trip.
+//
+// Resolvers never query per object: they hand keys to per-request loaders
+// that fetch each level of a query in one call, and queries are checked
+// against depth and complexity limits before they run.
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/repository"
+	"eamagineweather-backend/internal/service"
+	"eamagineweather-backend/internal/units"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request is a GraphQL request as sent over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type Server struct {
+	regionRepo     repository.RegionRepository
+	userRepo       repository.UserRepository
+	alertService   *service.AlertService
+	weatherService *service.WeatherService
+	limits         Limits
+	schema         graphql.Schema
+}
+
+func NewServer(
+	regionRepo repository.RegionRepository,
+	userRepo repository.UserRepository,
+	alertService *service.AlertService,
+	weatherService *service.WeatherService,
+	limits Limits,
+) (*Server, error) {
+	s := &Server{
+		regionRepo:     regionRepo,
+		userRepo:       userRepo,
+		alertService:   alertService,
+		weatherService: weatherService,
+		limits:         limits,
+	}
+
+	schema, err := s.buildSchema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build GraphQL schema: %w", err)
+	}
+	s.schema = schema
+	return s, nil
+}
+
+// Execute parses, validates, limits and runs one request. Weather values
+// are converted to prefs; userID is empty for anonymous requests.
+func (s *Server) Execute(ctx context.Context, req Request, prefs units.Preferences, userID string) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	validation := graphql.ValidateDocument(&s.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	if err := checkLimits(doc, req.OperationName, req.Variables, s.limits); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(ctx, stateKey{}, s.newState(prefs, userID)),
+	})
+}
+
+type stateKey struct{}
+
+// state is everything resolvers share within one request
+type state struct {
+	prefs  units.Preferences
+	userID string
+
+	regions   *loader[string, *db.RegionModel]
+	forecasts *loader[string, []models.ForecastWeather]
+	alerts    *loader[string, []models.WeatherAlert]
+
+	// known holds every region resolved so far, so the forecast loader can
+	// batch BMKG fetches without looking regions up again
+	known map[string]*db.RegionModel
+}
+
+func (s *Server) newState(prefs units.Preferences, userID string) *state {
+	st := &state{
+		prefs:  prefs,
+		userID: userID,
+		known:  make(map[string]*db.RegionModel),
+	}
+
+	st.regions = newLoader(func(ctx context.Context, codes []string) (map[string]*db.RegionModel, error) {
+		regions, err := s.regionRepo.FindByCodes(ctx, codes)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string]*db.RegionModel, len(regions))
+		for i := range regions {
+			result[regions[i].Code] = &regions[i]
+		}
+		return result, nil
+	})
+
+	st.forecasts = newLoader(func(ctx context.Context, codes []string) (map[string][]models.ForecastWeather, error) {
+		regions := make([]*db.RegionModel, 0, len(codes))
+		for _, code := range codes {
+			regions = append(regions, st.known[code])
+		}
+		result := s.weatherService.GetForecastBatch(ctx, regions)
+		for code, forecast := range result {
+			service.LocalizeWeather(&models.WeatherResponse{Forecast: forecast}, st.prefs)
+			result[code] = forecast
+		}
+		return result, nil
+	})
+
+	st.alerts = newLoader(s.alertService.GetActiveAlertsBatch)
+
+	return st
+}
+
+func stateFrom(ctx context.Context) *state {
+	return ctx.Value(stateKey{}).(*state)
+}
@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound how much work one query may ask for
+type Limits struct {
+	MaxDepth      int // Deepest field nesting, counting the top-level fields as 1
+	MaxComplexity int // Highest estimated cost; see fieldCosts and listSizes
+}
+
+// fieldCosts is the extra cost of fields that reach BMKG rather than only
+// our database. Every other field costs 1.
+var fieldCosts = map[string]int{
+	"currentWeather": 5,
+	"forecast":       5,
+}
+
+// listSizes estimates how many items a list field returns. The cost of its
+// selection is multiplied by that many. Fields whose size depends on an
+// argument are handled in listSize.
+var listSizes = map[string]int{
+	"ancestors": 4,
+	"alerts":    10,
+	"favorites": 20,
+}
+
+// analysis walks one operation to measure its depth and cost
+type analysis struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// checkLimits rejects an operation that is nested too deeply or costs too
+// much. Introspection fields are not counted, so tools can load the schema.
+func checkLimits(doc *ast.Document, operationName string, variables map[string]interface{}, limits Limits) error {
+	a := analysis{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+	}
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			a.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operation = d
+			}
+		}
+	}
+	if operation == nil {
+		return nil // The executor reports the missing operation
+	}
+
+	depth, cost := a.selectionSet(operation.SelectionSet, operation.VariableDefinitions)
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, limits.MaxDepth)
+	}
+	if limits.MaxComplexity > 0 && cost > limits.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", cost, limits.MaxComplexity)
+	}
+	return nil
+}
+
+// selectionSet returns the depth and cost of a selection set, expanding
+// fragments. Validation has already rejected fragment cycles.
+func (a analysis) selectionSet(set *ast.SelectionSet, definitions []*ast.VariableDefinition) (depth, cost int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, selection := range set.Selections {
+		var d, c int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			d, c = a.selectionSet(s.SelectionSet, definitions)
+			d++
+			c = fieldCost(s.Name.Value) + c*a.listSize(s, definitions)
+		case *ast.InlineFragment:
+			d, c = a.selectionSet(s.SelectionSet, definitions)
+		case *ast.FragmentSpread:
+			if fragment, ok := a.fragments[s.Name.Value]; ok {
+				d, c = a.selectionSet(fragment.SelectionSet, definitions)
+			}
+		}
+		if d > depth {
+			depth = d
+		}
+		cost += c
+	}
+	return depth, cost
+}
+
+func fieldCost(name string) int {
+	if cost, ok := fieldCosts[name]; ok {
+		return cost
+	}
+	return 1
+}
+
+// listSize estimates how many items a field returns, 1 for single objects
+func (a analysis) listSize(field *ast.Field, definitions []*ast.VariableDefinition) int {
+	switch field.Name.Value {
+	case "regions":
+		if n := a.argListLen(field, "codes", definitions); n > 0 {
+			return n
+		}
+		return 1
+	case "forecast":
+		return a.argInt(field, "hours", definitions, defaultForecastHours)/3 + 1
+	}
+	if size, ok := listSizes[field.Name.Value]; ok {
+		return size
+	}
+	return 1
+}
+
+// argValue resolves an argument, following variables to their values or
+// defaults
+func (a analysis) argValue(field *ast.Field, name string, definitions []*ast.VariableDefinition) (interface{}, bool) {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != name {
+			continue
+		}
+		variable, ok := arg.Value.(*ast.Variable)
+		if !ok {
+			return arg.Value, true
+		}
+		if value, ok := a.variables[variable.Name.Value]; ok {
+			return value, true
+		}
+		for _, definition := range definitions {
+			if definition.Variable.Name.Value == variable.Name.Value && definition.DefaultValue != nil {
+				return definition.DefaultValue, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (a analysis) argInt(field *ast.Field, name string, definitions []*ast.VariableDefinition, fallback int) int {
+	value, _ := a.argValue(field, name, definitions)
+	switch v := value.(type) {
+	case *ast.IntValue:
+		if n, err := strconv.Atoi(v.Value); err == nil {
+			return n
+		}
+	case float64: // Variables decoded from JSON
+		return int(v)
+	case int:
+		return v
+	}
+	return fallback
+}
+
+func (a analysis) argListLen(field *ast.Field, name string, definitions []*ast.VariableDefinition) int {
+	value, _ := a.argValue(field, name, definitions)
+	switch v := value.(type) {
+	case *ast.ListValue:
+		return len(v.Values)
+	case []interface{}:
+		return len(v)
+	}
+	return 0
+}
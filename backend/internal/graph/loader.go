@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"context"
+	"sync"
+)
+
+// loader batches the keys requested while one level of a query resolves.
+// Load only records the key and returns a thunk; the executor calls thunks
+// after the whole level has resolved, and the first one fetches every
+// pending key in a single call. Results are cached for the request.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	results map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		queued:  make(map[K]bool),
+		results: make(map[K]V),
+		errs:    make(map[K]error),
+	}
+}
+
+// Load returns a thunk resolving to the value for key, or to nil when the
+// fetch found none
+func (l *loader[K, V]) Load(ctx context.Context, key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		value, ok, err := l.get(ctx, key)
+		if err != nil || !ok {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// get flushes pending keys if needed and returns the value for key
+func (l *loader[K, V]) get(ctx context.Context, key K) (V, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) > 0 {
+		keys := l.pending
+		l.pending = nil
+		values, err := l.fetch(ctx, keys)
+		for _, k := range keys {
+			if err != nil {
+				l.errs[k] = err
+			} else if value, ok := values[k]; ok {
+				l.results[k] = value
+			}
+		}
+	}
+
+	value, ok := l.results[key]
+	return value, ok, l.errs[key]
+}
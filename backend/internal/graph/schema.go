@@ -0,0 +1,371 @@
+package graph
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/regiontz"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	defaultForecastHours = 24
+	maxForecastHours     = 72
+	maxRegionCodes       = 50
+)
+
+var errSignInRequired = errors.New("sign in to list favorites")
+
+func (s *Server) buildSchema() (graphql.Schema, error) {
+	weatherType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "WeatherData",
+		Description: "One 3-hourly BMKG forecast point, in the requested units",
+		Fields: graphql.Fields{
+			"datetime":      &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.DateTime })},
+			"localDatetime": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.LocalDateTime.Format("2006-01-02T15:04:05") })},
+			"temperature":   &graphql.Field{Type: graphql.NewNonNull(graphql.Float), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.Temperature })},
+			"feelsLike":     &graphql.Field{Type: graphql.NewNonNull(graphql.Float), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.FeelsLike })},
+			"humidity":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.Humidity })},
+			"description":   &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.Description })},
+			"conditionCode": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.ConditionCode })},
+			"condition":     &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.Condition })},
+			"icon":          &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.Icon })},
+			"windSpeed":     &graphql.Field{Type: graphql.NewNonNull(graphql.Float), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.WindSpeed })},
+			"windDirection": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.WindDirection })},
+			"cloudCover":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.CloudCover })},
+			"visibility":    &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.Visibility })},
+			"precipitation": &graphql.Field{Type: graphql.NewNonNull(graphql.Float), Resolve: weatherField(func(w models.ForecastWeather) interface{} { return w.Precipitation })},
+		},
+	})
+
+	regionType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Region",
+		Description: "A province, regency, district or village by its Kemendagri code",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: regionField(func(r *db.RegionModel) interface{} { return r.ID })},
+			"code":  &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: regionField(func(r *db.RegionModel) interface{} { return r.Code })},
+			"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: regionField(func(r *db.RegionModel) interface{} { return r.Name })},
+			"level": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: regionField(func(r *db.RegionModel) interface{} { return r.Level })},
+			"latitude": &graphql.Field{Type: graphql.Float, Resolve: regionField(func(r *db.RegionModel) interface{} {
+				if lat, ok := r.Latitude(); ok {
+					return lat
+				}
+				return nil
+			})},
+			"longitude": &graphql.Field{Type: graphql.Float, Resolve: regionField(func(r *db.RegionModel) interface{} {
+				if lon, ok := r.Longitude(); ok {
+					return lon
+				}
+				return nil
+			})},
+			"timezone": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: regionField(func(r *db.RegionModel) interface{} {
+				timezone, _ := r.Timezone()
+				return regiontz.Location(r.Code, timezone).String()
+			})},
+		},
+	})
+
+	alertType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "WeatherAlert",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: alertField(func(a models.WeatherAlert) interface{} { return a.ID })},
+			"title":       &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: alertField(func(a models.WeatherAlert) interface{} { return a.Title })},
+			"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: alertField(func(a models.WeatherAlert) interface{} { return a.Description })},
+			"severity":    &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: alertField(func(a models.WeatherAlert) interface{} { return a.Severity })},
+			"regionCode":  &graphql.Field{Type: graphql.String, Description: "Null for alerts covering the whole country", Resolve: alertField(func(a models.WeatherAlert) interface{} { return nullable(a.Region) })},
+			"startTime":   &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: alertField(func(a models.WeatherAlert) interface{} { return a.StartTime })},
+			"endTime": &graphql.Field{Type: graphql.DateTime, Resolve: alertField(func(a models.WeatherAlert) interface{} {
+				if a.EndTime == nil {
+					return nil
+				}
+				return *a.EndTime
+			})},
+			"region": &graphql.Field{
+				Type: regionType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					alert := p.Source.(models.WeatherAlert)
+					if alert.Region == "" {
+						return nil, nil
+					}
+					return s.loadRegion(p, alert.Region), nil
+				},
+			},
+		},
+	})
+
+	regionType.AddFieldConfig("parent", &graphql.Field{
+		Type: regionType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			code := p.Source.(*db.RegionModel).Code
+			dot := strings.LastIndex(code, ".")
+			if dot < 0 {
+				return nil, nil
+			}
+			return s.loadRegion(p, code[:dot]), nil
+		},
+	})
+	regionType.AddFieldConfig("ancestors", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(regionType))),
+		Description: "Containing regions, province first",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			code := p.Source.(*db.RegionModel).Code
+			var codes []string
+			for i, c := range code {
+				if c == '.' {
+					codes = append(codes, code[:i])
+				}
+			}
+			return s.loadRegions(p, codes), nil
+		},
+	})
+	regionType.AddFieldConfig("currentWeather", &graphql.Field{
+		Type:        weatherType,
+		Description: "The forecast point covering now; villages only",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			thunk := s.loadForecast(p)
+			return func() (interface{}, error) {
+				value, err := thunk()
+				if err != nil || value == nil {
+					return nil, err
+				}
+				forecast := value.([]models.ForecastWeather)
+				if len(forecast) == 0 {
+					return nil, nil
+				}
+				now := time.Now()
+				current := forecast[0]
+				for _, point := range forecast {
+					if point.DateTime.After(now) {
+						break
+					}
+					current = point
+				}
+				return current, nil
+			}, nil
+		},
+	})
+	regionType.AddFieldConfig("forecast", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(weatherType))),
+		Description: "Forecast points for the coming hours; empty for regions that are not villages",
+		Args: graphql.FieldConfigArgument{
+			"hours": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultForecastHours},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			hours, _ := p.Args["hours"].(int)
+			if hours < 1 || hours > maxForecastHours {
+				return nil, errors.New("hours must be between 1 and 72")
+			}
+			thunk := s.loadForecast(p)
+			return func() (interface{}, error) {
+				value, err := thunk()
+				if err != nil || value == nil {
+					return []interface{}{}, err
+				}
+				// Start from the 3-hour slot covering now
+				now := time.Now()
+				from, to := now.Add(-3*time.Hour), now.Add(time.Duration(hours)*time.Hour)
+				points := []interface{}{}
+				for _, point := range value.([]models.ForecastWeather) {
+					if point.DateTime.After(from) && !point.DateTime.After(to) {
+						points = append(points, point)
+					}
+				}
+				return points, nil
+			}, nil
+		},
+	})
+	regionType.AddFieldConfig("alerts", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(alertType))),
+		Description: "Active alerts for this region, a region containing or within it, or the whole country",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			thunk := stateFrom(p.Context).alerts.Load(p.Context, p.Source.(*db.RegionModel).Code)
+			return func() (interface{}, error) {
+				value, err := thunk()
+				if err != nil || value == nil {
+					return []models.WeatherAlert{}, err
+				}
+				return value, nil
+			}, nil
+		},
+	})
+
+	favoriteType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "UserFavorite",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: favoriteField(func(f db.UserFavoriteModel) interface{} { return f.ID })},
+			"order": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: favoriteField(func(f db.UserFavoriteModel) interface{} { return f.Order })},
+			"region": &graphql.Field{
+				Type: graphql.NewNonNull(regionType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					// Fetched with the favorites
+					region := p.Source.(db.UserFavoriteModel).Region()
+					if region != nil {
+						stateFrom(p.Context).known[region.Code] = region
+					}
+					return region, nil
+				},
+			},
+		},
+	})
+
+	unitsType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Units",
+		Description: "Units weather values are expressed in, chosen with the units, wind_unit and lang query parameters",
+		Fields: graphql.Fields{
+			"temperature":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"windSpeed":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"visibility":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"precipitation": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"lang":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"region": &graphql.Field{
+				Type: regionType,
+				Args: graphql.FieldConfigArgument{
+					"code": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return s.loadRegion(p, p.Args["code"].(string)), nil
+				},
+			},
+			"regions": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(regionType))),
+				Description: "Regions by code, in the order given; unknown codes are skipped",
+				Args: graphql.FieldConfigArgument{
+					"codes": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					args := p.Args["codes"].([]interface{})
+					if len(args) > maxRegionCodes {
+						return nil, errors.New("at most 50 codes can be requested at once")
+					}
+					codes := make([]string, len(args))
+					for i, code := range args {
+						codes[i] = code.(string)
+					}
+					return s.loadRegions(p, codes), nil
+				},
+			},
+			"alerts": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(alertType))),
+				Description: "Active alerts, optionally only those concerning a region",
+				Args: graphql.FieldConfigArgument{
+					"regionCode": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					regionCode, _ := p.Args["regionCode"].(string)
+					return s.alertService.GetActiveAlerts(p.Context, regionCode)
+				},
+			},
+			"favorites": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(favoriteType))),
+				Description: "The signed-in user's favorite regions",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					userID := stateFrom(p.Context).userID
+					if userID == "" {
+						return nil, errSignInRequired
+					}
+					return s.userRepo.FindFavorites(p.Context, userID)
+				},
+			},
+			"units": &graphql.Field{
+				Type: graphql.NewNonNull(unitsType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					prefs := stateFrom(p.Context).prefs
+					return map[string]interface{}{
+						"temperature":   prefs.TemperatureUnit(),
+						"windSpeed":     prefs.WindSpeedUnit(),
+						"visibility":    prefs.VisibilityUnit(),
+						"precipitation": prefs.PrecipitationUnit(),
+						"lang":          string(prefs.Lang),
+					}, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// loadRegion returns a thunk resolving a region by code and remembers it
+// for the forecast loader
+func (s *Server) loadRegion(p graphql.ResolveParams, code string) func() (interface{}, error) {
+	st := stateFrom(p.Context)
+	thunk := st.regions.Load(p.Context, code)
+	return func() (interface{}, error) {
+		value, err := thunk()
+		if region, ok := value.(*db.RegionModel); ok {
+			st.known[region.Code] = region
+		}
+		return value, err
+	}
+}
+
+// loadRegions resolves several regions in order, skipping unknown codes
+func (s *Server) loadRegions(p graphql.ResolveParams, codes []string) func() (interface{}, error) {
+	thunks := make([]func() (interface{}, error), len(codes))
+	for i, code := range codes {
+		thunks[i] = s.loadRegion(p, code)
+	}
+	return func() (interface{}, error) {
+		regions := []interface{}{}
+		for _, thunk := range thunks {
+			value, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				regions = append(regions, value)
+			}
+		}
+		return regions, nil
+	}
+}
+
+// loadForecast returns a thunk resolving the source region's forecast
+func (s *Server) loadForecast(p graphql.ResolveParams) func() (interface{}, error) {
+	region := p.Source.(*db.RegionModel)
+	st := stateFrom(p.Context)
+	st.known[region.Code] = region
+	return st.forecasts.Load(p.Context, region.Code)
+}
+
+// nullable maps an empty string to null
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func regionField(get func(*db.RegionModel) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(*db.RegionModel)), nil
+	}
+}
+
+func weatherField(get func(models.ForecastWeather) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(models.ForecastWeather)), nil
+	}
+}
+
+func alertField(get func(models.WeatherAlert) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(models.WeatherAlert)), nil
+	}
+}
+
+func favoriteField(get func(db.UserFavoriteModel) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(db.UserFavoriteModel)), nil
+	}
+}
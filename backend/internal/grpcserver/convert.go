@@ -0,0 +1,108 @@
+package grpcserver
+
+import (
+	"strings"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/models"
+	weatherv1 "eamagineweather-backend/internal/pb/weather/v1"
+	"eamagineweather-backend/internal/regiontz"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func currentResponse(weather *models.WeatherResponse) *weatherv1.GetCurrentWeatherResponse {
+	response := &weatherv1.GetCurrentWeatherResponse{Region: regionFromInfo(weather.Region)}
+
+	if c := weather.Current; c != nil {
+		response.Current = &weatherv1.Weather{
+			Time:          timestamppb.New(c.DateTime),
+			LocalTime:     c.LocalDateTime.Format("2006-01-02T15:04:05"),
+			Temperature:   c.Temperature,
+			FeelsLike:     c.FeelsLike,
+			Humidity:      int32(c.Humidity),
+			Description:   c.WeatherDesc,
+			DescriptionEn: c.WeatherDescEn,
+			ConditionCode: int32(c.ConditionCode),
+			Condition:     c.Condition,
+			Icon:          c.Icon,
+			WindSpeed:     c.WindSpeed,
+			WindDirection: c.WindDirection,
+			CloudCover:    int32(c.CloudCover),
+			Visibility:    c.Visibility,
+			Precipitation: c.Precipitation,
+		}
+	}
+
+	if r := weather.Regional; r != nil {
+		response.Regional = &weatherv1.RegionalWeather{
+			VillageCount:      int32(r.VillageCount),
+			MinTemperature:    r.MinTemperature,
+			MedianTemperature: r.MedianTemperature,
+			MaxTemperature:    r.MaxTemperature,
+			MaxWindSpeed:      r.MaxWindSpeed,
+			RainShare:         r.RainShare,
+			ConditionCode:     int32(r.ConditionCode),
+			Condition:         r.Condition,
+			ValidAt:           timestamppb.New(r.ValidAt),
+			SyncRunId:         r.SyncRunID,
+		}
+	}
+	return response
+}
+
+func forecastWeather(f models.ForecastWeather) *weatherv1.Weather {
+	return &weatherv1.Weather{
+		Time:          timestamppb.New(f.DateTime),
+		LocalTime:     f.LocalDateTime.Format("2006-01-02T15:04:05"),
+		Temperature:   f.Temperature,
+		FeelsLike:     f.FeelsLike,
+		Humidity:      int32(f.Humidity),
+		Description:   f.WeatherDesc,
+		DescriptionEn: f.WeatherDescEn,
+		ConditionCode: int32(f.ConditionCode),
+		Condition:     f.Condition,
+		Icon:          f.Icon,
+		WindSpeed:     f.WindSpeed,
+		WindDirection: f.WindDirection,
+		CloudCover:    int32(f.CloudCover),
+		Visibility:    f.Visibility,
+		Precipitation: f.Precipitation,
+	}
+}
+
+func regionFromInfo(info models.RegionInfo) *weatherv1.Region {
+	return &weatherv1.Region{
+		Code:      info.Code,
+		Name:      info.Name,
+		Level:     int32(strings.Count(info.Code, ".") + 1),
+		Province:  info.Province,
+		Regency:   info.Regency,
+		District:  info.District,
+		Village:   info.Village,
+		Latitude:  info.Latitude,
+		Longitude: info.Longitude,
+		Timezone:  info.Timezone,
+	}
+}
+
+func regionFromModel(region *db.RegionModel) *weatherv1.Region {
+	result := &weatherv1.Region{
+		Code:  region.Code,
+		Name:  region.Name,
+		Level: int32(region.Level),
+	}
+	result.Province, _ = region.ProvinceName()
+	result.Regency, _ = region.RegencyName()
+	result.District, _ = region.DistrictName()
+	result.Village, _ = region.VillageName()
+	if lat, ok := region.Latitude(); ok {
+		result.Latitude = &lat
+	}
+	if lon, ok := region.Longitude(); ok {
+		result.Longitude = &lon
+	}
+	timezone, _ := region.Timezone()
+	result.Timezone = regiontz.Location(region.Code, timezone).String()
+	return result
+}
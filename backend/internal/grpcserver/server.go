@@ -0,0 +1,193 @@
+// Package grpcserver serves the weather API over gRPC for internal
+// services, on its own port next to the gin JSON API. It is a thin layer
+// over WeatherService and the region repository.
+package grpcserver
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=eamagineweather-backend --go-grpc_out=../.. --go-grpc_opt=module=eamagineweather-backend weather/v1/weather.proto
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"strings"
+
+	"eamagineweather-backend/db"
+	weatherv1 "eamagineweather-backend/internal/pb/weather/v1"
+	"eamagineweather-backend/internal/repository"
+	"eamagineweather-backend/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	maxStreamRegions   = 50
+)
+
+type Server struct {
+	weatherv1.UnimplementedWeatherServiceServer
+
+	weatherService *service.WeatherService
+	regionRepo     repository.RegionRepository
+}
+
+func New(weatherService *service.WeatherService, regionRepo repository.RegionRepository) *Server {
+	return &Server{weatherService: weatherService, regionRepo: regionRepo}
+}
+
+// Serve listens on addr until ctx is done, then stops gracefully. The
+// standard health service is always registered; server reflection only
+// when enabled, so tools like grpcurl can list methods in development.
+func (s *Server) Serve(ctx context.Context, addr string, enableReflection bool) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer()
+	weatherv1.RegisterWeatherServiceServer(server, s)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(weatherv1.WeatherService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	if enableReflection {
+		reflection.Register(server)
+	}
+
+	go func() {
+		<-ctx.Done()
+		healthServer.Shutdown()
+		server.GracefulStop()
+	}()
+
+	log.Printf("gRPC server starting on %s", addr)
+	return server.Serve(listener)
+}
+
+func (s *Server) GetCurrentWeather(ctx context.Context, req *weatherv1.GetCurrentWeatherRequest) (*weatherv1.GetCurrentWeatherResponse, error) {
+	if req.RegionCode == "" {
+		return nil, status.Error(codes.InvalidArgument, "region_code is required")
+	}
+
+	weather, err := s.weatherService.GetCurrentWeather(ctx, req.RegionCode)
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+	return currentResponse(weather), nil
+}
+
+func (s *Server) GetForecast(ctx context.Context, req *weatherv1.GetForecastRequest) (*weatherv1.GetForecastResponse, error) {
+	if req.RegionCode == "" {
+		return nil, status.Error(codes.InvalidArgument, "region_code is required")
+	}
+
+	forecast, err := s.weatherService.GetWeatherForecast(ctx, req.RegionCode)
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+
+	response := &weatherv1.GetForecastResponse{Region: regionFromInfo(forecast.Region)}
+	for _, point := range forecast.Forecast {
+		response.Points = append(response.Points, forecastWeather(point))
+	}
+	for _, day := range forecast.Daily {
+		response.Daily = append(response.Daily, &weatherv1.DailyForecast{
+			Date:           day.Date,
+			MinTemperature: day.MinTemperature,
+			MaxTemperature: day.MaxTemperature,
+			ConditionCode:  int32(day.ConditionCode),
+			RainChance:     int32(day.RainChance),
+		})
+	}
+	return response, nil
+}
+
+func (s *Server) StreamUpdates(req *weatherv1.StreamUpdatesRequest, stream weatherv1.WeatherService_StreamUpdatesServer) error {
+	if len(req.RegionCodes) == 0 || len(req.RegionCodes) > maxStreamRegions {
+		return status.Errorf(codes.InvalidArgument, "region_codes must hold between 1 and %d codes", maxStreamRegions)
+	}
+
+	// Subscribe first so a run finishing during the first send is not missed
+	runs, unsubscribe := s.weatherService.SubscribeSyncRuns()
+	defer unsubscribe()
+
+	ctx := stream.Context()
+	for {
+		results := s.weatherService.GetCurrentWeatherBatch(ctx, req.RegionCodes)
+		for _, code := range req.RegionCodes {
+			update := &weatherv1.WeatherUpdate{RegionCode: code}
+			if result := results[code]; result.Weather != nil {
+				update.Weather = currentResponse(result.Weather)
+			} else {
+				update.Error = result.Error
+			}
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-runs:
+		}
+	}
+}
+
+func (s *Server) SearchRegions(ctx context.Context, req *weatherv1.SearchRegionsRequest) (*weatherv1.SearchRegionsResponse, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+	limit := int(req.Limit)
+	if limit < 1 || limit > maxSearchLimit {
+		limit = defaultSearchLimit
+	}
+
+	regions, err := s.regionRepo.Search(ctx, req.Query, int(req.Level), limit)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &weatherv1.SearchRegionsResponse{}
+	for i := range regions {
+		response.Regions = append(response.Regions, regionFromModel(&regions[i]))
+	}
+	return response, nil
+}
+
+func (s *Server) GetRegion(ctx context.Context, req *weatherv1.GetRegionRequest) (*weatherv1.Region, error) {
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	region, err := s.regionRepo.FindByCode(ctx, req.Code)
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, status.Error(codes.NotFound, "region not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return regionFromModel(region), nil
+}
+
+// statusFromError maps service errors onto gRPC codes
+func statusFromError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrRegionNotFound), errors.Is(err, service.ErrNoRollupData):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
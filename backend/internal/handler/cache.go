@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/internal/middleware"
+	"eamagineweather-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// untilNextSync is the Cache-Control of responses built from synced or
+// BMKG data: fresh until the next sync run, and for at least a minute
+func untilNextSync(weatherService *service.WeatherService) string {
+	maxAge := int(time.Until(weatherService.NextSyncAt()).Seconds())
+	if maxAge < 60 {
+		maxAge = 60
+	}
+	return fmt.Sprintf("public, max-age=%d", maxAge)
+}
+
+// writeCachedJSON writes value as JSON with a strong ETag derived from the
+// body, for responses whose data has no cheaper version. A matching
+// If-None-Match gets 304 without a body. Clients that accept gzip get a
+// compressed body, whose ETag carries a -gzip suffix so caches never take
+// one encoding for the other. Bodies are localized, so they vary by
+// Accept-Language.
+func writeCachedJSON(c *gin.Context, value any, cacheControl string) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to encode response",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	tag := hex.EncodeToString(sum[:16])
+	gzipped := strings.Contains(c.GetHeader("Accept-Encoding"), "gzip")
+	etag := `"` + tag + `"`
+	if gzipped {
+		etag = `"` + tag + gzipETagSuffix + `"`
+	}
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", middleware.CacheControl(c, cacheControl))
+	c.Writer.Header().Add("Vary", "Accept-Encoding, Accept-Language")
+
+	if etagMatches(c.GetHeader("If-None-Match"), `"`+tag+`"`) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Header("Content-Type", gin.MIMEJSON+"; charset=utf-8")
+	if !gzipped {
+		c.Data(http.StatusOK, gin.MIMEJSON+"; charset=utf-8", body)
+		return
+	}
+
+	c.Header("Content-Encoding", "gzip")
+	c.Status(http.StatusOK)
+	writer := gzip.NewWriter(c.Writer)
+	writer.Write(body)
+	writer.Close()
+}
+
+// gzipETagSuffix marks the ETag of a gzip-encoded body
+const gzipETagSuffix = "-gzip"
+
+// etagMatches reports whether an If-None-Match header lists etag. Weak
+// validators match their strong form, as RFC 9110 requires for GET, and
+// the content-coding suffix is ignored; the 304 carries the ETag of the
+// encoding the request accepts.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if strings.HasSuffix(candidate, gzipETagSuffix+`"`) {
+			candidate = strings.TrimSuffix(candidate, gzipETagSuffix+`"`) + `"`
+		}
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Output formats for endpoints that can export tabular data
+const (
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+
+	mimeCSV    = "text/csv"
+	mimeNDJSON = "application/x-ndjson"
+)
+
+// negotiateExportFormat picks the response format from the format query
+// parameter, falling back to the Accept header. It returns false when the
+// query parameter names an unknown format.
+func negotiateExportFormat(c *gin.Context) (string, bool) {
+	if format := strings.ToLower(c.Query("format")); format != "" {
+		switch format {
+		case formatJSON, formatCSV, formatNDJSON:
+			return format, true
+		}
+		return "", false
+	}
+
+	switch c.NegotiateFormat(gin.MIMEJSON, mimeCSV, mimeNDJSON, "application/ndjson") {
+	case mimeCSV:
+		return formatCSV, true
+	case mimeNDJSON, "application/ndjson":
+		return formatNDJSON, true
+	default:
+		return formatJSON, true
+	}
+}
+
+// writeCSV streams a header row followed by records
+func writeCSV(c *gin.Context, filename string, header []string, records [][]string) {
+	c.Header("Content-Type", mimeCSV+"; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write(header)
+	writer.WriteAll(records)
+}
+
+// writeNDJSON streams one JSON document per line
+func writeNDJSON[T any](c *gin.Context, items []T) {
+	c.Header("Content-Type", mimeNDJSON)
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			return
+		}
+	}
+}
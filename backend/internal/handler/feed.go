@@ -0,0 +1,372 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/internal/cap"
+	"eamagineweather-backend/internal/condition"
+	"eamagineweather-backend/internal/ical"
+	"eamagineweather-backend/internal/middleware"
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/regiontz"
+	"eamagineweather-backend/internal/service"
+	"eamagineweather-backend/internal/syndication"
+	"eamagineweather-backend/internal/units"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// feedDomain qualifies the UIDs of feed entries
+	feedDomain = "eamagineweather"
+	// feedRefresh is how often calendar clients are asked to poll
+	feedRefresh = 3 * time.Hour
+)
+
+type FeedHandler struct {
+	weatherService *service.WeatherService
+	alertService   *service.AlertService
+}
+
+func NewFeedHandler(weatherService *service.WeatherService, alertService *service.AlertService) *FeedHandler {
+	return &FeedHandler{weatherService: weatherService, alertService: alertService}
+}
+
+// GET /feeds/:file where file is <regionCode>.ics. Publishes one all-day
+// event per forecast day and one timed event per active alert.
+func (h *FeedHandler) GetCalendar(c *gin.Context) {
+	regionCode, ok := strings.CutSuffix(c.Param("file"), ".ics")
+	if !ok || regionCode == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Calendar must be requested as <regionCode>.ics",
+		})
+		return
+	}
+
+	forecast, err := h.weatherService.GetWeatherForecast(c.Request.Context(), regionCode)
+	if errors.Is(err, service.ErrRegionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Region not found",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch weather forecast",
+			"details": err.Error(),
+		})
+		return
+	}
+	alerts, err := h.alertService.GetActiveAlerts(c.Request.Context(), regionCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch weather alerts",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	prefs := middleware.GetPreferences(c)
+	service.LocalizeWeather(forecast, prefs)
+
+	var body bytes.Buffer
+	if err := forecastCalendar(forecast, alerts, prefs, time.Now()).Write(&body); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to write calendar",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", middleware.CacheControl(c, untilNextSync(h.weatherService)))
+	c.Header("Content-Disposition", `inline; filename="`+regionCode+`.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body.Bytes())
+}
+
+// forecastCalendar builds the calendar of a localized forecast. Day UIDs
+// are derived from the region and date, and alert UIDs from the alert, so
+// clients update existing events on refresh instead of adding new ones.
+func forecastCalendar(forecast *models.WeatherResponse, alerts []models.WeatherAlert, prefs units.Preferences, now time.Time) ical.Calendar {
+	region := forecast.Region
+	name := region.Name
+	if name == "" {
+		name = region.Code
+	}
+	loc := regiontz.Location(region.Code, region.Timezone)
+	unit := prefs.TemperatureUnit()
+
+	calendar := ical.Calendar{
+		ProdID:  "-//EamagineWeather//Forecast Feed//ID",
+		Name:    prefs.Pick("Prakiraan Cuaca ", "Weather Forecast ") + name,
+		Refresh: feedRefresh,
+	}
+
+	// Group the 3-hourly points under the local day they fall on
+	points := make(map[string][]models.ForecastWeather)
+	for _, point := range forecast.Forecast {
+		date := point.DateTime.In(loc).Format("2006-01-02")
+		points[date] = append(points[date], point)
+	}
+
+	for _, day := range forecast.Daily {
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			continue
+		}
+		info := condition.Lookup(condition.Code(day.ConditionCode))
+
+		var description strings.Builder
+		for _, point := range points[day.Date] {
+			pointInfo := condition.Lookup(condition.Code(point.ConditionCode))
+			fmt.Fprintf(&description, "%s %s, %.0f%s, %d%%\n",
+				point.DateTime.In(loc).Format("15:04"),
+				prefs.Pick(pointInfo.Name, pointInfo.NameEn),
+				point.Temperature, unit, point.Humidity)
+		}
+		fmt.Fprintf(&description, "%s %d%%", prefs.Pick("Peluang hujan", "Chance of rain"), day.RainChance)
+
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:         fmt.Sprintf("%s-%s@%s", date.Format("20060102"), region.Code, feedDomain),
+			Stamp:       now,
+			Start:       date,
+			AllDay:      true,
+			Summary:     fmt.Sprintf("%s %.0f–%.0f%s", prefs.Pick(info.Name, info.NameEn), day.MinTemperature, day.MaxTemperature, unit),
+			Description: description.String(),
+			Location:    name,
+		})
+	}
+
+	for _, alert := range alerts {
+		event := ical.Event{
+			UID:          fmt.Sprintf("alert-%s@%s", alert.ID, feedDomain),
+			Stamp:        now,
+			LastModified: alert.UpdatedAt,
+			Start:        alert.StartTime,
+			Summary:      "⚠ " + alert.Title,
+			Description:  alert.Description,
+			Location:     name,
+			Categories:   []string{alert.Severity},
+		}
+		if alert.EndTime != nil {
+			event.End = *alert.EndTime
+		}
+		calendar.Events = append(calendar.Events, event)
+	}
+	return calendar
+}
+
+// feedRegionPattern matches the province and regency codes alert feeds can
+// be filtered by
+var feedRegionPattern = regexp.MustCompile(`^\d{2}(\.\d{2})?$`)
+
+// GET /feeds/alerts.atom?region=32
+func (h *FeedHandler) GetAlertsAtom(c *gin.Context) {
+	h.writeAlertFeed(c, "application/atom+xml", syndication.WriteAtom)
+}
+
+// GET /feeds/alerts.rss?region=32
+func (h *FeedHandler) GetAlertsRSS(c *gin.Context) {
+	h.writeAlertFeed(c, "application/rss+xml", syndication.WriteRSS)
+}
+
+func (h *FeedHandler) writeAlertFeed(c *gin.Context, contentType string, write func(w io.Writer, feed syndication.Feed) error) {
+	regionCode := c.Query("region")
+	if regionCode != "" && !feedRegionPattern.MatchString(regionCode) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "region must be a province or regency code",
+		})
+		return
+	}
+
+	alerts, err := h.alertService.GetActiveAlerts(c.Request.Context(), regionCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch weather alerts",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var body bytes.Buffer
+	if err := write(&body, alertFeed(requestBaseURL(c), c.Request.URL.RequestURI(), regionCode, alerts, time.Now())); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to write feed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", middleware.CacheControl(c, "public, max-age=300"))
+	c.Data(http.StatusOK, contentType+"; charset=utf-8", body.Bytes())
+}
+
+// GET /feeds/alerts/:file where file is <alertId>.cap
+func (h *FeedHandler) GetAlertCAP(c *gin.Context) {
+	id, ok := strings.CutSuffix(c.Param("file"), ".cap")
+	if !ok || id == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Alert must be requested as <alertId>.cap",
+		})
+		return
+	}
+
+	alert, err := h.alertService.GetAlert(c.Request.Context(), id)
+	if errors.Is(err, service.ErrAlertNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Alert not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch weather alert",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var body bytes.Buffer
+	if err := capAlert(*alert, time.Now()).Write(&body); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to write alert",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", middleware.CacheControl(c, "public, max-age=300"))
+	c.Data(http.StatusOK, "application/cap+xml; charset=utf-8", body.Bytes())
+}
+
+// alertFeed describes active alerts for Atom and RSS. Each item links to the
+// alert's CAP document.
+func alertFeed(baseURL, selfPath, regionCode string, alerts []models.WeatherAlert, now time.Time) syndication.Feed {
+	feed := syndication.Feed{
+		ID:          "urn:" + feedDomain + ":alerts",
+		Title:       "Peringatan Cuaca EamagineWeather",
+		Description: "Peringatan cuaca aktif",
+		Author:      "EamagineWeather",
+		SelfURL:     baseURL + selfPath,
+	}
+	if regionCode != "" {
+		feed.ID += ":" + regionCode
+		feed.Description += " untuk wilayah " + regionCode
+	}
+
+	for _, alert := range alerts {
+		if alert.UpdatedAt.After(feed.Updated) {
+			feed.Updated = alert.UpdatedAt
+		}
+
+		title := fmt.Sprintf("[%s] %s", alert.Severity, alert.Title)
+		if alert.Region != "" {
+			title += " – " + alertAreaDesc(alert)
+		}
+		feed.Items = append(feed.Items, syndication.Item{
+			ID:         "urn:" + feedDomain + ":alert:" + alert.ID,
+			Title:      title,
+			Summary:    alert.Description,
+			Link:       baseURL + "/feeds/alerts/" + alert.ID + ".cap",
+			LinkType:   "application/cap+xml",
+			Published:  alert.CreatedAt,
+			Updated:    alert.UpdatedAt,
+			Categories: []string{alert.Severity},
+		})
+	}
+	if feed.Updated.IsZero() {
+		feed.Updated = now
+	}
+	return feed
+}
+
+// capSeverities maps our severities onto CAP's
+var capSeverities = map[string]string{
+	"LOW":      cap.SeverityMinor,
+	"MEDIUM":   cap.SeverityModerate,
+	"HIGH":     cap.SeveritySevere,
+	"CRITICAL": cap.SeverityExtreme,
+}
+
+// capAlert describes one alert as a CAP 1.2 document. Times are written in
+// the alert region's zone.
+func capAlert(alert models.WeatherAlert, now time.Time) cap.Alert {
+	loc := regiontz.Location(alert.Region, "")
+	at := func(t time.Time) *cap.Time {
+		v := cap.Time(t.In(loc))
+		return &v
+	}
+
+	urgency := cap.UrgencyImmediate
+	if until := alert.StartTime.Sub(now); until > 24*time.Hour {
+		urgency = cap.UrgencyFuture
+	} else if until > 0 {
+		urgency = cap.UrgencyExpected
+	}
+
+	info := cap.Info{
+		Language:    "id-ID",
+		Category:    []string{cap.CategoryMet},
+		Event:       alert.Title,
+		Urgency:     urgency,
+		Severity:    capSeverities[alert.Severity],
+		Certainty:   cap.CertaintyLikely,
+		Effective:   at(alert.UpdatedAt),
+		Onset:       at(alert.StartTime),
+		SenderName:  "EamagineWeather",
+		Headline:    alert.Title,
+		Description: alert.Description,
+		Area:        []cap.Area{{AreaDesc: alertAreaDesc(alert)}},
+	}
+	if info.Severity == "" {
+		info.Severity = cap.SeverityUnknown
+	}
+	if alert.EndTime != nil {
+		info.Expires = at(*alert.EndTime)
+	}
+	if alert.Region != "" {
+		info.Area[0].Geocode = []cap.Value{{ValueName: "KEMENDAGRI", Value: alert.Region}}
+	}
+
+	return cap.Alert{
+		Identifier: feedDomain + ".alert." + alert.ID,
+		Sender:     feedDomain,
+		Sent:       *at(alert.UpdatedAt),
+		Status:     cap.StatusActual,
+		MsgType:    cap.MsgTypeAlert,
+		Scope:      cap.ScopePublic,
+		Info:       []cap.Info{info},
+	}
+}
+
+// alertAreaDesc names the area an alert covers
+func alertAreaDesc(alert models.WeatherAlert) string {
+	switch {
+	case alert.RegionName != "":
+		return alert.RegionName
+	case alert.Region != "":
+		return alert.Region
+	default:
+		return "Indonesia"
+	}
+}
+
+// requestBaseURL is the scheme and host the client used to reach us,
+// honouring a reverse proxy's X-Forwarded-Proto
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
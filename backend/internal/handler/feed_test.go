@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetCalendar(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.expect(t, get("/feeds/"+village+".ics"), http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "text/calendar; charset=utf-8" {
+		t.Errorf("calendar Content-Type %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.Contains(got, village+".ics") {
+		t.Errorf("calendar Content-Disposition %q", got)
+	}
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(body, "END:VCALENDAR\r\n") {
+		t.Fatalf("calendar is not an iCalendar document:\n%s", body)
+	}
+	if events := strings.Count(body, "BEGIN:VEVENT"); events < 3 {
+		t.Errorf("calendar has %d events, want the forecast days and the alert", events)
+	}
+	if !strings.Contains(body, "Hujan lebat") || strings.Contains(body, "Banjir rob") {
+		t.Error("calendar should list the Bandung alert and only that one")
+	}
+
+	s.expect(t, get("/feeds/"+village+".txt"), http.StatusNotFound)
+	s.expect(t, get("/feeds/"+missing+".ics"), http.StatusNotFound)
+}
+
+func TestGetAlertFeeds(t *testing.T) {
+	s := newTestServer(t)
+
+	var atom struct {
+		Entries []struct {
+			Title string `xml:"title"`
+		} `xml:"entry"`
+	}
+	rec := s.expect(t, get("/feeds/alerts.atom"), http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "application/atom+xml; charset=utf-8" {
+		t.Errorf("Atom Content-Type %q", got)
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &atom); err != nil {
+		t.Fatal(err)
+	}
+	if len(atom.Entries) != 2 {
+		t.Errorf("Atom feed has %d entries, want both alerts", len(atom.Entries))
+	}
+
+	var rss struct {
+		Items []struct {
+			Title string `xml:"title"`
+			Link  string `xml:"link"`
+		} `xml:"channel>item"`
+	}
+	rec = s.expect(t, get("/feeds/alerts.rss?region="+regency), http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "application/rss+xml; charset=utf-8" {
+		t.Errorf("RSS Content-Type %q", got)
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &rss); err != nil {
+		t.Fatal(err)
+	}
+	if len(rss.Items) != 1 || !strings.HasSuffix(rss.Items[0].Link, "/feeds/alerts/alert-bandung.cap") {
+		t.Errorf("RSS feed for %s = %+v, want the Bandung alert linking its CAP document", regency, rss.Items)
+	}
+
+	s.expect(t, get("/feeds/alerts.atom?region="+village), http.StatusBadRequest)
+	s.expect(t, get("/feeds/alerts.rss?region=jabar"), http.StatusBadRequest)
+}
+
+func TestGetAlertCAP(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.expect(t, get("/feeds/alerts/alert-bandung.cap"), http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "application/cap+xml; charset=utf-8" {
+		t.Errorf("CAP Content-Type %q", got)
+	}
+	var alert struct {
+		Identifier string `xml:"identifier"`
+		Headline   string `xml:"info>headline"`
+		Severity   string `xml:"info>severity"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &alert); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(alert.Identifier, "alert-bandung") || alert.Headline != "Hujan lebat" || alert.Severity != "Severe" {
+		t.Errorf("CAP alert = %+v", alert)
+	}
+
+	s.expect(t, get("/feeds/alerts/alert-missing.cap"), http.StatusNotFound)
+	s.expect(t, get("/feeds/alerts/alert-bandung.xml"), http.StatusNotFound)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"eamagineweather-backend/internal/graph"
+	"eamagineweather-backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GraphQLHandler struct {
+	server *graph.Server
+}
+
+func NewGraphQLHandler(server *graph.Server) *GraphQLHandler {
+	return &GraphQLHandler{server: server}
+}
+
+// POST /graphql with a JSON body {query, operationName, variables}, or
+// GET /graphql?query=...&variables=... for cacheable queries. Errors inside
+// a valid request are reported in the GraphQL errors array with status 200.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req graph.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "variables must be a JSON object",
+					"details": err.Error(),
+				})
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid GraphQL request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "query is required",
+		})
+		return
+	}
+
+	result := h.server.Execute(c.Request.Context(), req, middleware.GetPreferences(c), c.GetString(middleware.UserIDKey))
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type graphqlBody struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func graphqlPost(query string, variables map[string]any) request {
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	return request{method: http.MethodPost, path: "/graphql", body: string(body)}
+}
+
+func TestGraphQLQuery(t *testing.T) {
+	s := newTestServer(t)
+
+	query := `query($code: String!) {
+		region(code: $code) {
+			name
+			ancestors { code }
+			currentWeather { temperature description }
+			forecast(hours: 6) { temperature }
+			alerts { id }
+		}
+		units { temperature lang }
+	}`
+	var body graphqlBody
+	decode(t, s.expect(t, graphqlPost(query, map[string]any{"code": village}).with("Accept-Language", "en"), http.StatusOK), &body)
+	if len(body.Errors) != 0 {
+		t.Fatalf("errors %+v", body.Errors)
+	}
+	var data struct {
+		Region struct {
+			Name           string
+			Ancestors      []struct{ Code string }
+			CurrentWeather *struct {
+				Temperature float64
+				Description string
+			}
+			Forecast []struct{ Temperature float64 }
+			Alerts   []struct{ ID string }
+		}
+		Units struct{ Temperature, Lang string }
+	}
+	if err := json.Unmarshal(body.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	region := data.Region
+	if region.Name != "Sukarasa" || len(region.Ancestors) != 3 || region.Ancestors[0].Code != province {
+		t.Errorf("region %q with ancestors %v", region.Name, region.Ancestors)
+	}
+	if region.CurrentWeather == nil || region.CurrentWeather.Temperature != 24 || region.CurrentWeather.Description != "Light Rain" {
+		t.Errorf("current weather %+v", region.CurrentWeather)
+	}
+	// From the slot covering now
+	if len(region.Forecast) != 3 {
+		t.Errorf("6 hours of forecast has %d points, want 3", len(region.Forecast))
+	}
+	if len(region.Alerts) != 1 || region.Alerts[0].ID != "alert-bandung" {
+		t.Errorf("alerts %v", region.Alerts)
+	}
+	if data.Units.Temperature != "°C" || data.Units.Lang != "en" {
+		t.Errorf("units %+v", data.Units)
+	}
+}
+
+func TestGraphQLGet(t *testing.T) {
+	s := newTestServer(t)
+
+	query := url.Values{
+		"query":     {`query($codes: [String!]!) { regions(codes: $codes) { code } }`},
+		"variables": {`{"codes": ["` + regency + `", "99", "` + province + `"]}`},
+	}
+	var body graphqlBody
+	decode(t, s.expect(t, get("/graphql?"+query.Encode()), http.StatusOK), &body)
+	if got, want := string(body.Data), `{"regions":[{"code":"32.73"},{"code":"32"}]}`; got != want {
+		t.Errorf("data = %s, want %s", got, want)
+	}
+
+	s.expect(t, get("/graphql"), http.StatusBadRequest)
+	s.expect(t, get("/graphql?query={units{lang}}&variables=[1"), http.StatusBadRequest)
+	s.expect(t, request{method: http.MethodPost, path: "/graphql", body: `{"query": 1}`}, http.StatusBadRequest)
+}
+
+func TestGraphQLFavorites(t *testing.T) {
+	s := newTestServer(t)
+	query := `{ favorites { order region { code } } units { temperature } }`
+
+	var body graphqlBody
+	decode(t, s.expect(t, graphqlPost(query, nil), http.StatusOK), &body)
+	if len(body.Errors) == 0 {
+		t.Error("favorites without signing in reported no error")
+	}
+
+	body = graphqlBody{}
+	decode(t, s.expect(t, graphqlPost(query, nil).with("Authorization", bearer(testUserID)), http.StatusOK), &body)
+	if len(body.Errors) != 0 {
+		t.Fatalf("errors %+v", body.Errors)
+	}
+	if got, want := string(body.Data), `{"favorites":[{"order":1,"region":{"code":"`+village+`"}}],"units":{"temperature":"°F"}}`; got != want {
+		t.Errorf("data = %s, want %s", got, want)
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	s := newTestServer(t)
+	query := "{ region(code: \"" + village + "\") { " + strings.Repeat("parent { ", 9) + "code" + strings.Repeat(" }", 9) + " } }"
+
+	var body graphqlBody
+	decode(t, s.expect(t, graphqlPost(query, nil), http.StatusOK), &body)
+	if len(body.Errors) == 0 || string(body.Data) != "" && string(body.Data) != "null" {
+		t.Errorf("query nested past the depth limit returned %s with errors %+v", body.Data, body.Errors)
+	}
+}
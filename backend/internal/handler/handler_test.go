@@ -0,0 +1,376 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/graph"
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/regionstore"
+	"eamagineweather-backend/internal/repository/memory"
+	"eamagineweather-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testSecret   = "test-secret"
+	testUserID   = "user-ani"
+	testBatchMax = 3
+
+	province     = "32"
+	regency      = "32.73"
+	district     = "32.73.01"
+	village      = "32.73.01.1001"
+	otherVillage = "32.73.01.1002"
+	missing      = "32.73.01.1999" // A village code BMKG does not know
+	retired      = "32.73.01.1009" // Replaced by village
+	unlisted     = "32.74.01"      // A district not in our database that BMKG answers for
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	// NewRouter logs every request
+	gin.DefaultWriter = io.Discard
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// fakeBMKG serves 3-hourly forecasts from the current slot like the BMKG
+// API, for the villages it has temperatures for, and 404 for the rest
+type fakeBMKG struct {
+	mu           sync.Mutex
+	temperatures map[string]float64
+}
+
+func (f *fakeBMKG) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("adm4")
+	f.mu.Lock()
+	temperature, ok := f.temperatures[code]
+	f.mu.Unlock()
+	if r.URL.Path != "/prakiraan-cuaca" || !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	response := models.BMKGWeatherResponse{
+		Lokasi: &models.BMKGLocation{Latitude: -6.87, Longitude: 107.59, Timezone: "Asia/Jakarta"},
+	}
+	start := time.Now().UTC().Truncate(3 * time.Hour)
+	for i := 0; i < 16; i++ {
+		at := start.Add(time.Duration(3*i) * time.Hour)
+		response.Data = append(response.Data, models.BMKGWeatherData{
+			UTCDatetime:   at.Format("2006-01-02 15:04:05"),
+			LocalDatetime: at.Add(7 * time.Hour).Format("2006-01-02 15:04:05"),
+			Temperature:   temperature + float64(i%4),
+			Humidity:      80,
+			WeatherDesc:   "Hujan Ringan",
+			WeatherDescEn: "Light Rain",
+			WindSpeed:     9,
+			WindDirection: "W",
+			CloudCover:    90,
+			Visibility:    "> 10 km",
+			Precipitation: 1.2,
+			AnalysisDate:  start.Format("2006-01-02T15:04:05"),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func region(code, name string, level int, hasWeatherData bool) db.RegionModel {
+	r := db.InnerRegion{
+		ID:             "region-" + code,
+		Code:           code,
+		Name:           name,
+		Level:          level,
+		ProvinceCode:   ptr(province),
+		ProvinceName:   ptr("Jawa Barat"),
+		HasWeatherData: hasWeatherData,
+		IsActive:       true,
+	}
+	if level >= 2 {
+		r.RegencyCode, r.RegencyName = ptr(regency), ptr("Kota Bandung")
+	}
+	if level >= 3 {
+		r.DistrictCode, r.DistrictName = ptr(district), ptr("Sukasari")
+	}
+	if level == 4 {
+		r.VillageCode, r.VillageName = ptr(code), ptr(name)
+	}
+	return db.RegionModel{InnerRegion: r}
+}
+
+// storeRegion describes a seeded region for the region store
+func storeRegion(r db.RegionModel) regionstore.Region {
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return regionstore.Region{
+		ID:             r.ID,
+		Code:           r.Code,
+		Name:           r.InnerRegion.Name,
+		Level:          r.Level,
+		ProvinceCode:   value(r.InnerRegion.ProvinceCode),
+		ProvinceName:   value(r.InnerRegion.ProvinceName),
+		RegencyCode:    value(r.InnerRegion.RegencyCode),
+		RegencyName:    value(r.InnerRegion.RegencyName),
+		DistrictCode:   value(r.InnerRegion.DistrictCode),
+		DistrictName:   value(r.InnerRegion.DistrictName),
+		VillageName:    value(r.InnerRegion.VillageName),
+		HasWeatherData: r.HasWeatherData,
+	}
+}
+
+type testServer struct {
+	router  *gin.Engine
+	store   *memory.Store
+	weather *service.WeatherService
+}
+
+// newTestServer wires the handlers over a memory store and a fake BMKG API
+// with the routes and middleware main.go registers
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	store := memory.New()
+	regions := []db.RegionModel{
+		region(province, "Jawa Barat", 1, false),
+		region(regency, "Kota Bandung", 2, false),
+		region(district, "Sukasari", 3, false),
+		region(village, "Sukarasa", 4, true),
+		region(otherVillage, "Gegerkalong", 4, true),
+	}
+	store.AddRegions(regions...)
+	store.AddUsers(db.UserModel{InnerUser: db.InnerUser{
+		ID:       testUserID,
+		Email:    "ani@example.com",
+		Name:     ptr("Ani"),
+		Units:    "imperial",
+		Language: "en",
+	}})
+	store.AddFavorites(db.UserFavoriteModel{InnerUserFavorite: db.InnerUserFavorite{
+		ID: "favorite-1", UserID: testUserID, RegionID: "region-" + village, Order: 1,
+	}})
+	store.AddAlerts(
+		db.WeatherAlertModel{InnerWeatherAlert: db.InnerWeatherAlert{
+			ID:          "alert-bandung",
+			Title:       "Hujan lebat",
+			Description: "Hujan lebat di Kota Bandung",
+			Severity:    db.AlertSeverityHigh,
+			Region:      ptr(regency),
+			StartTime:   time.Now().Add(-time.Hour),
+			IsActive:    true,
+		}},
+		db.WeatherAlertModel{InnerWeatherAlert: db.InnerWeatherAlert{
+			ID:          "alert-jakarta",
+			Title:       "Banjir rob",
+			Description: "Banjir rob di pesisir Jakarta",
+			Severity:    db.AlertSeverityMedium,
+			Region:      ptr("31"),
+			StartTime:   time.Now().Add(-time.Hour),
+			IsActive:    true,
+		}},
+	)
+	if _, err := store.RegionAliases().Upsert(context.Background(), retired, village, "pemekaran"); err != nil {
+		t.Fatal(err)
+	}
+	storeRegions := make([]regionstore.Region, len(regions))
+	for i, r := range regions {
+		storeRegions[i] = storeRegion(r)
+	}
+
+	api := httptest.NewServer(&fakeBMKG{temperatures: map[string]float64{village: 24, otherVillage: 22, unlisted: 26}})
+	t.Cleanup(api.Close)
+
+	bmkgService := service.NewBMKGService(api.URL, 100, nil)
+	weatherService := service.NewWeatherService(store.Weather(), store.Regions(), store.SyncRuns(), bmkgService, nil, service.SyncPolicy{
+		Interval:   time.Hour,
+		MaxRegions: 10,
+	})
+	userService := service.NewUserService(store.Users())
+	alertService := service.NewAlertService(store.Alerts(), store.Regions())
+	aliasService := service.NewRegionAliasService(store.RegionAliases())
+
+	graphServer, err := graph.NewServer(store.Regions(), store.Users(), alertService, weatherService, graph.Limits{
+		MaxDepth:      8,
+		MaxComplexity: 1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter(nil, nil)
+	RegisterRoutes(router, Routes{
+		JWTSecret:    testSecret,
+		Preferences:  userService.GetPreferences,
+		ResolveAlias: aliasService.Resolve,
+		Weather:      NewWeatherHandler(weatherService, testBatchMax),
+		Users:        NewUserHandler(userService),
+		Regions:      NewSimpleRegionsHandler(regionstore.NewMemory(storeRegions), nil),
+		Feeds:        NewFeedHandler(weatherService, alertService),
+		GraphQL:      NewGraphQLHandler(graphServer),
+	})
+
+	return &testServer{router: router, store: store, weather: weatherService}
+}
+
+// request is a request to the test server; header values are set on it
+type request struct {
+	method string
+	path   string
+	body   string
+	header map[string]string
+}
+
+func get(path string) request { return request{method: http.MethodGet, path: path} }
+
+func (r request) with(name, value string) request {
+	header := map[string]string{name: value}
+	for k, v := range r.header {
+		header[k] = v
+	}
+	r.header = header
+	return r
+}
+
+func (s *testServer) do(t *testing.T, r request) *httptest.ResponseRecorder {
+	t.Helper()
+	var body io.Reader
+	if r.body != "" {
+		body = bytes.NewBufferString(r.body)
+	}
+	req := httptest.NewRequest(r.method, r.path, body)
+	if r.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range r.header {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect performs r and fails the test unless it answers status
+func (s *testServer) expect(t *testing.T, r request, status int) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := s.do(t, r)
+	if rec.Code != status {
+		t.Fatalf("%s %s: status %d, want %d\n%s", r.method, r.path, rec.Code, status, rec.Body)
+	}
+	return rec
+}
+
+// decode unmarshals a JSON response body into value
+func decode(t *testing.T, rec *httptest.ResponseRecorder, value any) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), value); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+}
+
+// sync stores the fake BMKG forecast of the given villages
+func (s *testServer) sync(t *testing.T, codes ...string) {
+	t.Helper()
+	for _, code := range codes {
+		s.expect(t, request{method: http.MethodPost, path: "/api/v1/weather/sync/" + code}, http.StatusOK)
+	}
+}
+
+// token signs an HS256 JWT for subject, expiring after ttl
+func token(subject, secret string, ttl time.Duration) string {
+	encode := func(v any) string {
+		raw, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	unsigned := encode(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." +
+		encode(map[string]any{"sub": subject, "exp": time.Now().Add(ttl).Unix()})
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func bearer(subject string) string {
+	return "Bearer " + token(subject, testSecret, time.Hour)
+}
+
+func TestHealth(t *testing.T) {
+	s := newTestServer(t)
+	var body struct {
+		Status  string `json:"status"`
+		Service string `json:"service"`
+	}
+	decode(t, s.expect(t, get("/health"), http.StatusOK), &body)
+	if body.Status != "ok" || body.Service != "eamagine-weather-api" {
+		t.Errorf("health = %+v", body)
+	}
+}
+
+func TestRegionsOnlyRoutes(t *testing.T) {
+	// The routes the server registers without a database
+	router := NewRouter(nil, gin.H{"regions": "embedded"})
+	RegisterRoutes(router, Routes{
+		Regions: NewSimpleRegionsHandler(regionstore.NewMemory([]regionstore.Region{
+			storeRegion(region(province, "Jawa Barat", 1, false)),
+		}), nil),
+	})
+	s := &testServer{router: router}
+
+	var health struct {
+		Status  string `json:"status"`
+		Regions string `json:"regions"`
+	}
+	decode(t, s.expect(t, get("/health"), http.StatusOK), &health)
+	if health.Status != "ok" || health.Regions != "embedded" {
+		t.Errorf("health = %+v", health)
+	}
+	s.expect(t, get("/api/v1/regions/provinces"), http.StatusOK)
+	s.expect(t, get("/api/v1/weather/current/"+village), http.StatusNotFound)
+	s.expect(t, get("/graphql?query={units{lang}}"), http.StatusNotFound)
+}
+
+func TestCORSPreflight(t *testing.T) {
+	s := newTestServer(t)
+	rec := s.do(t, request{method: http.MethodOptions, path: "/api/v1/users/preferences"}.
+		with("Origin", "https://eamagineweather.id").
+		with("Access-Control-Request-Method", "PUT").
+		with("Access-Control-Request-Headers", "Authorization"))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight status %d, want 204", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+}
+
+func TestRegionAliasRedirects(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.expect(t, get("/api/v1/weather/forecast/"+retired+"?lang=en"), http.StatusMovedPermanently)
+	if got, want := rec.Header().Get("Location"), "/api/v1/weather/forecast/"+village+"?lang=en"; got != want {
+		t.Errorf("Location = %q, want %q", got, want)
+	}
+	if got := rec.Header().Get("X-Region-Alias"); got != retired+" -> "+village {
+		t.Errorf("X-Region-Alias = %q", got)
+	}
+
+	// Other methods keep theirs
+	rec = s.expect(t, request{method: http.MethodPost, path: "/api/v1/weather/sync/" + retired}, http.StatusPermanentRedirect)
+	if got, want := rec.Header().Get("Location"), "/api/v1/weather/sync/"+village; got != want {
+		t.Errorf("Location = %q, want %q", got, want)
+	}
+}
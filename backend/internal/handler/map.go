@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"eamagineweather-backend/internal/middleware"
+	"eamagineweather-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/v1/map/layer/:regionCode?field=temperature|condition|rain|humidity|wind_speed
+func (h *WeatherHandler) GetMapLayer(c *gin.Context) {
+	regionCode := c.Param("regionCode")
+
+	field := c.DefaultQuery("field", service.MapFieldTemperature)
+	if !service.IsMapField(field) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "field must be one of temperature, condition, rain, humidity, wind_speed",
+		})
+		return
+	}
+
+	layer, err := h.weatherService.GetMapLayer(c.Request.Context(), regionCode, field)
+	if err != nil {
+		if errors.Is(err, service.ErrNotAggregate) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "regionCode must be a province, regency or district code",
+			})
+			return
+		}
+		if errors.Is(err, service.ErrRegionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Region not found",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch map layer",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	service.LocalizeMapLayer(layer, middleware.GetPreferences(c))
+	writeCachedJSON(c, gin.H{"data": layer}, "public, max-age=300")
+}
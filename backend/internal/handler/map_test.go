@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"eamagineweather-backend/internal/models"
+)
+
+func TestGetMapLayer(t *testing.T) {
+	s := newTestServer(t)
+	var body struct {
+		Data models.MapLayer `json:"data"`
+	}
+
+	decode(t, s.expect(t, get("/api/v1/map/layer/"+regency), http.StatusOK), &body)
+	if len(body.Data.Codes) != 0 {
+		t.Errorf("map layer before a sync has villages %v", body.Data.Codes)
+	}
+
+	s.sync(t, village, otherVillage)
+	// Looking up a forecast stores the coordinates BMKG reports
+	s.expect(t, get("/api/v1/weather/forecast/"+village), http.StatusOK)
+	decode(t, s.expect(t, get("/api/v1/map/layer/"+regency), http.StatusOK), &body)
+	layer := body.Data
+	if layer.Region != regency || layer.Field != "temperature" || len(layer.Codes) != 2 {
+		t.Fatalf("map layer %s/%s with villages %v", layer.Region, layer.Field, layer.Codes)
+	}
+	if len(layer.Values) != 2 || len(layer.Lats) != 2 || layer.Lats[0] == nil || layer.Lats[1] != nil {
+		t.Fatalf("map layer values %v, lats %v; want coordinates for %s only", layer.Values, layer.Lats, village)
+	}
+	for i, code := range layer.Codes {
+		want := map[string]float64{village: 24, otherVillage: 22}[code]
+		if layer.Values[i] != want {
+			t.Errorf("temperature of %s = %v, want %v", code, layer.Values[i], want)
+		}
+	}
+
+	decode(t, s.expect(t, get("/api/v1/map/layer/"+province+"?field=rain"), http.StatusOK), &body)
+	if len(body.Data.Values) != 2 || body.Data.Values[0] != 1 {
+		t.Errorf("rain layer %v, want 1 for the light rain", body.Data.Values)
+	}
+
+	s.expect(t, get("/api/v1/map/layer/"+regency+"?field=pressure"), http.StatusBadRequest)
+	s.expect(t, get("/api/v1/map/layer/"+village), http.StatusBadRequest)
+	s.expect(t, get("/api/v1/map/layer/99.99"), http.StatusNotFound)
+}
@@ -1,37 +1,127 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 
-	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/middleware"
+	"eamagineweather-backend/internal/regionstore"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
 type SimpleRegionsHandler struct {
-	db    *db.PrismaClient
+	store regionstore.Store
 	redis *redis.Client
+
+	versionMu     sync.Mutex
+	version       middleware.Version
+	versionLoaded time.Time
 }
 
-func NewSimpleRegionsHandler(database *db.PrismaClient, redisClient *redis.Client) *SimpleRegionsHandler {
+// NewSimpleRegionsHandler serves regions from store; redisClient may be nil
+func NewSimpleRegionsHandler(store regionstore.Store, redisClient *redis.Client) *SimpleRegionsHandler {
 	return &SimpleRegionsHandler{
-		db:    database,
+		store: store,
 		redis: redisClient,
 	}
 }
 
+// regionVersionTTL is how long a loaded dataset version is reused. Counting
+// the regions table on every request would cost more than the revalidation
+// saves; a change shows up in responses within this long.
+const regionVersionTTL = time.Minute
+
+// Version returns the version of the region dataset, for
+// middleware.Conditional
+func (h *SimpleRegionsHandler) Version(ctx context.Context) (middleware.Version, error) {
+	h.versionMu.Lock()
+	defer h.versionMu.Unlock()
+	if !h.versionLoaded.IsZero() && time.Since(h.versionLoaded) < regionVersionTTL {
+		return h.version, nil
+	}
+
+	version, err := h.store.Version(ctx)
+	if err != nil {
+		return middleware.Version{}, err
+	}
+	h.version = middleware.Version{Tag: version.Tag, Modified: version.Modified}
+	h.versionLoaded = time.Now()
+	return h.version, nil
+}
+
+// CacheControl lets region listings be cached for an hour; they change only
+// when regions are imported or probed
+func (h *SimpleRegionsHandler) CacheControl(c *gin.Context) string {
+	return "public, max-age=3600"
+}
+
+// maxRegionPageSize caps the limit parameter of the region listings
+const maxRegionPageSize = 50
+
+// regionPage reads the cursor and limit parameters of a region listing,
+// answering 400 for a limit out of range. defaultLimit applies without a
+// limit parameter; 0 lists every region.
+func regionPage(c *gin.Context, defaultLimit int) (regionstore.Page, bool) {
+	page := regionstore.Page{
+		Cursor: c.Query("cursor"),
+		Limit:  defaultLimit,
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxRegionPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "limit must be between 1 and 50",
+			})
+			return page, false
+		}
+		page.Limit = limit
+	}
+	return page, true
+}
+
+// regionListError answers a failed listing, 400 for a bad cursor
+func regionListError(c *gin.Context, err error, message string) {
+	if errors.Is(err, regionstore.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   message,
+		"details": err.Error(),
+	})
+}
+
+// nextCursor is the nextCursor of a listing response, null on the last page
+func nextCursor(list regionstore.List) interface{} {
+	if list.NextCursor == "" {
+		return nil
+	}
+	return list.NextCursor
+}
+
 // GET /api/regions/provinces - Get all provinces (with Redis caching)
 func (h *SimpleRegionsHandler) GetProvinces(c *gin.Context) {
 	cacheKey := "regions:provinces"
 	ctx := c.Request.Context()
 
+	page, ok := regionPage(c, 0)
+	if !ok {
+		return
+	}
+	// Only the full list is cached
+	cacheable := h.redis != nil && page == regionstore.Page{}
+
 	// Try to get from Redis cache first
-	if h.redis != nil {
+	if cacheable {
 		cached, err := h.redis.Get(ctx, cacheKey).Result()
 		if err == nil {
 			c.Header("X-Cache", "HIT")
@@ -41,22 +131,16 @@ func (h *SimpleRegionsHandler) GetProvinces(c *gin.Context) {
 		}
 	}
 
-	provinces, err := h.db.Region.FindMany(
-		db.Region.Level.Equals(1),
-		db.Region.IsActive.Equals(true),
-	).OrderBy(db.Region.Name.Order(db.SortOrderAsc)).Exec(ctx)
+	provinces, err := h.store.Provinces(ctx, page)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch provinces",
-			"details": err.Error(),
-		})
+		regionListError(c, err, "Failed to fetch provinces")
 		return
 	}
 
 	// Simple response structure
-	result := make([]map[string]interface{}, len(provinces))
-	for i, province := range provinces {
+	result := make([]map[string]interface{}, len(provinces.Regions))
+	for i, province := range provinces.Regions {
 		result[i] = map[string]interface{}{
 			"id":    province.ID,
 			"code":  province.Code,
@@ -66,12 +150,13 @@ func (h *SimpleRegionsHandler) GetProvinces(c *gin.Context) {
 	}
 
 	response := gin.H{
-		"data":  result,
-		"total": len(result),
+		"data":       result,
+		"total":      provinces.Total,
+		"nextCursor": nextCursor(provinces),
 	}
 
 	// Cache the result for 24 hours (provinces rarely change)
-	if h.redis != nil {
+	if cacheable {
 		if responseJson, err := json.Marshal(response); err == nil {
 			h.redis.Set(ctx, cacheKey, responseJson, 24*time.Hour)
 		}
@@ -84,23 +169,20 @@ func (h *SimpleRegionsHandler) GetProvinces(c *gin.Context) {
 // GET /api/regions/regencies/:provinceCode - Get regencies by province
 func (h *SimpleRegionsHandler) GetRegenciesByProvince(c *gin.Context) {
 	provinceCode := c.Param("provinceCode")
+	page, ok := regionPage(c, 0)
+	if !ok {
+		return
+	}
 
-	regencies, err := h.db.Region.FindMany(
-		db.Region.Level.Equals(2),
-		db.Region.ProvinceCode.Equals(provinceCode),
-		db.Region.IsActive.Equals(true),
-	).OrderBy(db.Region.Name.Order(db.SortOrderAsc)).Exec(c.Request.Context())
+	regencies, err := h.store.Regencies(c.Request.Context(), provinceCode, page)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch regencies",
-			"details": err.Error(),
-		})
+		regionListError(c, err, "Failed to fetch regencies")
 		return
 	}
 
-	result := make([]map[string]interface{}, len(regencies))
-	for i, regency := range regencies {
+	result := make([]map[string]interface{}, len(regencies.Regions))
+	for i, regency := range regencies.Regions {
 		result[i] = map[string]interface{}{
 			"id":           regency.ID,
 			"code":         regency.Code,
@@ -111,56 +193,30 @@ func (h *SimpleRegionsHandler) GetRegenciesByProvince(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":  result,
-		"total": len(result),
+		"data":       result,
+		"total":      regencies.Total,
+		"nextCursor": nextCursor(regencies),
 	})
 }
 
 // GET /api/regions/villages/:regencyCode - Get villages with weather data by regency
 func (h *SimpleRegionsHandler) GetVillagesByRegency(c *gin.Context) {
 	regencyCode := c.Param("regencyCode")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 50 {
-		limit = 20
+	page, ok := regionPage(c, 20)
+	if !ok {
+		return
 	}
 
-	offset := (page - 1) * limit
-
 	// Get villages with pagination
-	villages, err := h.db.Region.FindMany(
-		db.Region.Level.Equals(4),
-		db.Region.RegencyCode.Equals(regencyCode),
-		db.Region.HasWeatherData.Equals(true),
-		db.Region.IsActive.Equals(true),
-	).Skip(offset).
-		Take(limit).
-		OrderBy(db.Region.Name.Order(db.SortOrderAsc)).
-		Exec(c.Request.Context())
+	villages, err := h.store.Villages(c.Request.Context(), regencyCode, page)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch villages",
-			"details": err.Error(),
-		})
+		regionListError(c, err, "Failed to fetch villages")
 		return
 	}
 
-	// Get total count (simplified - in production you'd want to optimize this)
-	allVillages, _ := h.db.Region.FindMany(
-		db.Region.Level.Equals(4),
-		db.Region.RegencyCode.Equals(regencyCode),
-		db.Region.HasWeatherData.Equals(true),
-		db.Region.IsActive.Equals(true),
-	).Exec(c.Request.Context())
-	totalCount := len(allVillages)
-
-	result := make([]map[string]interface{}, len(villages))
-	for i, village := range villages {
+	result := make([]map[string]interface{}, len(villages.Regions))
+	for i, village := range villages.Regions {
 		result[i] = map[string]interface{}{
 			"id":             village.ID,
 			"code":           village.Code,
@@ -171,14 +227,11 @@ func (h *SimpleRegionsHandler) GetVillagesByRegency(c *gin.Context) {
 		}
 	}
 
-	totalPages := (totalCount + limit - 1) / limit
-
 	c.JSON(http.StatusOK, gin.H{
 		"data":       result,
-		"total":      totalCount,
-		"page":       page,
-		"pageSize":   limit,
-		"totalPages": totalPages,
+		"total":      villages.Total,
+		"pageSize":   page.Limit,
+		"nextCursor": nextCursor(villages),
 	})
 }
 
@@ -186,7 +239,6 @@ func (h *SimpleRegionsHandler) GetVillagesByRegency(c *gin.Context) {
 func (h *SimpleRegionsHandler) SearchRegions(c *gin.Context) {
 	query := c.Query("q")
 	level := c.Query("level")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
 	if query == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -195,44 +247,24 @@ func (h *SimpleRegionsHandler) SearchRegions(c *gin.Context) {
 		return
 	}
 
-	if limit < 1 || limit > 50 {
-		limit = 20
-	}
-
-	// Build search conditions - case-insensitive search across all levels
-	conditions := []db.RegionWhereParam{
-		db.Region.IsActive.Equals(true),
+	page, ok := regionPage(c, 20)
+	if !ok {
+		return
 	}
 
-	// Use case-insensitive search by normalizing to title case
-	queryTrimmed := strings.TrimSpace(query)
-	queryTitle := strings.Title(strings.ToLower(queryTrimmed))
-	
-	// Search using title case since most Indonesian region names follow title case
-	conditions = append(conditions, db.Region.Name.Contains(queryTitle))
-
 	// Only filter by level if explicitly specified
 	// This allows searching across all levels (provinces, regencies, districts, villages)
+	levelInt := 0
 	if level != "" {
-		if levelInt, err := strconv.Atoi(level); err == nil {
-			conditions = append(conditions, db.Region.Level.Equals(levelInt))
+		if parsed, err := strconv.Atoi(level); err == nil {
+			levelInt = parsed
 		}
 	}
 
-	regions, err := h.db.Region.FindMany(conditions...).
-		Take(limit).
-		OrderBy(
-			db.Region.HasWeatherData.Order(db.SortOrderDesc),
-			db.Region.Level.Order(db.SortOrderDesc),
-			db.Region.Name.Order(db.SortOrderAsc),
-		).
-		Exec(c.Request.Context())
+	regions, err := h.store.Search(c.Request.Context(), query, levelInt, page)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to search regions",
-			"details": err.Error(),
-		})
+		regionListError(c, err, "Failed to search regions")
 		return
 	}
 
@@ -250,23 +282,12 @@ func (h *SimpleRegionsHandler) SearchRegions(c *gin.Context) {
 		HasWeatherData bool   `json:"hasWeatherData"`
 	}
 
-	result := make([]SimpleRegion, len(regions))
-	for i, region := range regions {
-		// Get optional values using Prisma methods
-		var provinceName, regencyName, districtName, villageName string
-		
-		if pName, ok := region.ProvinceName(); ok {
-			provinceName = pName
-		}
-		if rName, ok := region.RegencyName(); ok {
-			regencyName = rName
-		}
-		if dName, ok := region.DistrictName(); ok {
-			districtName = dName
-		}
-		if vName, ok := region.VillageName(); ok {
-			villageName = vName
-		}
+	result := make([]SimpleRegion, len(regions.Regions))
+	for i, region := range regions.Regions {
+		provinceName := region.ProvinceName
+		regencyName := region.RegencyName
+		districtName := region.DistrictName
+		villageName := region.VillageName
 		
 		// Build hierarchical display name based on level
 		var displayName string
@@ -316,7 +337,8 @@ func (h *SimpleRegionsHandler) SearchRegions(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":  result,
-		"total": len(result),
+		"data":       result,
+		"total":      regions.Total,
+		"nextCursor": nextCursor(regions),
 	})
 }
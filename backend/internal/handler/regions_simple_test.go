@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+)
+
+type regionListBody struct {
+	Data []struct {
+		ID             string `json:"id"`
+		Code           string `json:"code"`
+		Name           string `json:"name"`
+		Level          int    `json:"level"`
+		DisplayName    string `json:"displayName"`
+		HasWeatherData bool   `json:"hasWeatherData"`
+	} `json:"data"`
+	Total      int     `json:"total"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+func (b regionListBody) codes() []string {
+	codes := make([]string, len(b.Data))
+	for i, r := range b.Data {
+		codes[i] = r.Code
+	}
+	return codes
+}
+
+func TestGetProvinces(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.expect(t, get("/api/v1/regions/provinces"), http.StatusOK)
+	var body regionListBody
+	decode(t, rec, &body)
+	if body.Total != 1 || len(body.Data) != 1 || body.Data[0].Name != "Jawa Barat" || body.NextCursor != nil {
+		t.Errorf("provinces = %+v", body)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("provinces Cache-Control %q", got)
+	}
+
+	// The dataset version answers revalidation
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("provinces have no ETag")
+	}
+	s.expect(t, get("/api/v1/regions/provinces").with("If-None-Match", etag), http.StatusNotModified)
+
+	s.expect(t, get("/api/v1/regions/provinces?limit=51"), http.StatusBadRequest)
+	s.expect(t, get("/api/v1/regions/provinces?cursor=nonsense"), http.StatusBadRequest)
+}
+
+func TestGetRegenciesByProvince(t *testing.T) {
+	s := newTestServer(t)
+	var body regionListBody
+	decode(t, s.expect(t, get("/api/v1/regions/regencies/"+province), http.StatusOK), &body)
+	if got := body.codes(); len(got) != 1 || got[0] != regency {
+		t.Errorf("regencies of %s = %v", province, got)
+	}
+	decode(t, s.expect(t, get("/api/v1/regions/regencies/31"), http.StatusOK), &body)
+	if body.Total != 0 || len(body.Data) != 0 {
+		t.Errorf("regencies of an unknown province = %+v", body)
+	}
+}
+
+func TestGetVillagesByRegency(t *testing.T) {
+	s := newTestServer(t)
+	path := "/api/v1/regions/villages/" + regency
+
+	var body regionListBody
+	decode(t, s.expect(t, get(path+"?limit=1"), http.StatusOK), &body)
+	if body.Total != 2 || len(body.Data) != 1 || body.NextCursor == nil {
+		t.Fatalf("first page of villages = %+v, want 1 of 2 and a cursor", body)
+	}
+	first := body.Data[0].Code
+
+	decode(t, s.expect(t, get(path+"?limit=1&cursor="+*body.NextCursor), http.StatusOK), &body)
+	if len(body.Data) != 1 || body.Data[0].Code == first || body.NextCursor != nil {
+		t.Errorf("second page of villages = %+v", body)
+	}
+	if !body.Data[0].HasWeatherData {
+		t.Error("villages list one without weather data")
+	}
+
+	s.expect(t, get(path+"?limit=0"), http.StatusBadRequest)
+}
+
+func TestSearchRegions(t *testing.T) {
+	s := newTestServer(t)
+
+	var body regionListBody
+	decode(t, s.expect(t, get("/api/v1/regions/search?q=suka"), http.StatusOK), &body)
+	if got := body.codes(); len(got) != 2 || got[0] != village || got[1] != district {
+		t.Fatalf("search for suka = %v, want the village with weather data first", got)
+	}
+	if want := "Sukarasa, Kec. Sukasari, Kota Bandung, Jawa Barat"; body.Data[0].DisplayName != want {
+		t.Errorf("display name %q, want %q", body.Data[0].DisplayName, want)
+	}
+
+	decode(t, s.expect(t, get("/api/v1/regions/search?q=suka&level=3"), http.StatusOK), &body)
+	if got := body.codes(); len(got) != 1 || got[0] != district {
+		t.Errorf("search for districts named suka = %v", got)
+	}
+
+	s.expect(t, get("/api/v1/regions/search"), http.StatusBadRequest)
+	s.expect(t, get("/api/v1/regions/search?q=suka&limit=100"), http.StatusBadRequest)
+}
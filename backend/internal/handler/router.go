@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"eamagineweather-backend/internal/middleware"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// NewRouter returns the router every serving mode starts from: logging,
+// recovery, the CORS policy and GET /health. Without allowed origins every
+// origin is allowed. health adds fields to the health answer, e.g. to name
+// the serving mode.
+func NewRouter(allowedOrigins []string, health gin.H) *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Logger())
+	router.Use(gin.Recovery())
+
+	corsConfig := cors.DefaultConfig()
+	if len(allowedOrigins) > 0 && allowedOrigins[0] != "" {
+		corsConfig.AllowOrigins = allowedOrigins
+	} else {
+		corsConfig.AllowAllOrigins = true
+	}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With", "If-None-Match", "If-Modified-Since"}
+	corsConfig.ExposeHeaders = []string{"ETag", "Last-Modified"}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	router.Use(cors.New(corsConfig))
+
+	router.GET("/health", func(c *gin.Context) {
+		body := gin.H{
+			"status":    "ok",
+			"timestamp": time.Now().Unix(),
+			"service":   "eamagine-weather-api",
+		}
+		for key, value := range health {
+			body[key] = value
+		}
+		c.JSON(http.StatusOK, body)
+	})
+
+	return router
+}
+
+// Routes are the handlers and middleware dependencies RegisterRoutes serves.
+// Nil handlers leave their routes out, so the regions-only mode registers
+// /api/v1/regions alone.
+type Routes struct {
+	JWTSecret    string
+	Preferences  middleware.PreferenceLookup
+	ResolveAlias middleware.AliasResolver
+
+	Weather *WeatherHandler
+	Users   *UserHandler
+	Regions *SimpleRegionsHandler
+	Feeds   *FeedHandler
+	GraphQL *GraphQLHandler
+}
+
+// RegisterRoutes adds the API routes to a router from NewRouter
+func RegisterRoutes(router *gin.Engine, routes Routes) {
+	// Public routes apply a signed-in user's preferences
+	optionalAuth := []gin.HandlerFunc{
+		middleware.OptionalAuth(routes.JWTSecret),
+		middleware.Preferences(routes.Preferences),
+	}
+
+	// GraphQL
+	if routes.GraphQL != nil {
+		graphqlRoutes := router.Group("/graphql")
+		graphqlRoutes.Use(optionalAuth...)
+		{
+			graphqlRoutes.GET("", routes.GraphQL.Query)
+			graphqlRoutes.POST("", routes.GraphQL.Query)
+		}
+	}
+
+	// Feeds for calendar apps and feed readers
+	if routes.Feeds != nil {
+		feeds := router.Group("/feeds")
+		feeds.Use(optionalAuth...)
+		{
+			feeds.GET("/:file", routes.Feeds.GetCalendar)
+			feeds.GET("/alerts.atom", routes.Feeds.GetAlertsAtom)
+			feeds.GET("/alerts.rss", routes.Feeds.GetAlertsRSS)
+			feeds.GET("/alerts/:file", routes.Feeds.GetAlertCAP)
+		}
+	}
+
+	// API routes
+	api := router.Group("/api/v1")
+	if routes.ResolveAlias != nil {
+		api.Use(middleware.RegionAliases(routes.ResolveAlias))
+	}
+
+	if weatherHandler := routes.Weather; weatherHandler != nil {
+		// Weather routes
+		weather := api.Group("/weather")
+		weather.Use(optionalAuth...)
+		{
+			weather.GET("/current/:regionCode", weatherHandler.GetCurrentWeather)
+			weather.POST("/current/batch", weatherHandler.GetCurrentWeatherBatch)
+			weather.GET("/forecast/:regionCode", weatherHandler.GetWeatherForecast)
+			weather.GET("/history/:regionCode",
+				middleware.Conditional(weatherHandler.Version, weatherHandler.CacheControl, "Accept", "Accept-Language"),
+				weatherHandler.GetWeatherHistory)
+			weather.GET("/compare", weatherHandler.CompareWeather)
+			weather.GET("/conditions", weatherHandler.GetConditions)
+			weather.GET("/search", weatherHandler.SearchRegions)
+			weather.POST("/sync/:regionCode", weatherHandler.SyncWeatherData)
+		}
+
+		// Map routes
+		mapRoutes := api.Group("/map")
+		mapRoutes.Use(optionalAuth...)
+		{
+			mapRoutes.GET("/layer/:regionCode", weatherHandler.GetMapLayer)
+		}
+
+		// Embeddable widgets
+		widgets := api.Group("/widget")
+		widgets.Use(optionalAuth...)
+		{
+			widgets.GET("/:file", weatherHandler.GetWidget)
+		}
+	}
+
+	// Region routes
+	if regionsHandler := routes.Regions; regionsHandler != nil {
+		regions := api.Group("/regions")
+		regions.Use(middleware.Conditional(regionsHandler.Version, regionsHandler.CacheControl))
+		{
+			regions.GET("/provinces", regionsHandler.GetProvinces)
+			regions.GET("/regencies/:provinceCode", regionsHandler.GetRegenciesByProvince)
+			regions.GET("/villages/:regencyCode", regionsHandler.GetVillagesByRegency)
+			regions.GET("/search", regionsHandler.SearchRegions)
+		}
+	}
+
+	// User routes (protected)
+	if userHandler := routes.Users; userHandler != nil {
+		users := api.Group("/users")
+		users.Use(middleware.AuthMiddleware(routes.JWTSecret))
+		{
+			users.GET("/profile", userHandler.GetProfile)
+			users.PUT("/profile", userHandler.UpdateProfile)
+			users.GET("/favorites", userHandler.GetFavorites)
+			users.POST("/favorites", userHandler.AddFavorite)
+			users.DELETE("/favorites/:regionId", userHandler.RemoveFavorite)
+			users.GET("/preferences", userHandler.GetPreferences)
+			users.PUT("/preferences", userHandler.UpdatePreferences)
+		}
+	}
+}
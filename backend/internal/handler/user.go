@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"eamagineweather-backend/internal/middleware"
 	"eamagineweather-backend/internal/service"
+	"eamagineweather-backend/internal/units"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,8 +19,7 @@ func NewUserHandler(userService *service.UserService) *UserHandler {
 }
 
 func (h *UserHandler) GetProfile(c *gin.Context) {
-	// TODO: Extract user ID from JWT token
-	userID := "dummy-user-id"
+	userID := c.GetString(middleware.UserIDKey)
 	
 	profile, err := h.userService.GetProfile(c.Request.Context(), userID)
 	if err != nil {
@@ -31,7 +33,7 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 }
 
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
-	userID := "dummy-user-id"
+	userID := c.GetString(middleware.UserIDKey)
 	
 	var updateData interface{}
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -55,7 +57,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 }
 
 func (h *UserHandler) GetFavorites(c *gin.Context) {
-	userID := "dummy-user-id"
+	userID := c.GetString(middleware.UserIDKey)
 	
 	favorites, err := h.userService.GetFavorites(c.Request.Context(), userID)
 	if err != nil {
@@ -69,7 +71,7 @@ func (h *UserHandler) GetFavorites(c *gin.Context) {
 }
 
 func (h *UserHandler) AddFavorite(c *gin.Context) {
-	userID := "dummy-user-id"
+	userID := c.GetString(middleware.UserIDKey)
 	
 	var request struct {
 		RegionID string `json:"regionId" binding:"required"`
@@ -96,7 +98,7 @@ func (h *UserHandler) AddFavorite(c *gin.Context) {
 }
 
 func (h *UserHandler) RemoveFavorite(c *gin.Context) {
-	userID := "dummy-user-id"
+	userID := c.GetString(middleware.UserIDKey)
 	regionID := c.Param("regionId")
 	
 	err := h.userService.RemoveFavorite(c.Request.Context(), userID, regionID)
@@ -110,4 +112,98 @@ func (h *UserHandler) RemoveFavorite(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Favorite removed successfully",
 	})
-}
\ No newline at end of file
+}
+
+type preferencesPayload struct {
+	Units    string `json:"units"`
+	WindUnit string `json:"wind_unit,omitempty"`
+	Lang     string `json:"lang"`
+}
+
+func (h *UserHandler) GetPreferences(c *gin.Context) {
+	userID := c.GetString(middleware.UserIDKey)
+
+	prefs, err := h.userService.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch preferences",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferencesPayload{
+		Units:    string(prefs.System),
+		WindUnit: string(prefs.Wind),
+		Lang:     string(prefs.Lang),
+	})
+}
+
+func (h *UserHandler) UpdatePreferences(c *gin.Context) {
+	userID := c.GetString(middleware.UserIDKey)
+
+	var request preferencesPayload
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request data",
+		})
+		return
+	}
+
+	prefs := units.Preferences{System: units.Metric, Lang: units.Indonesian}
+	if request.Units != "" {
+		system, ok := units.ParseSystem(request.Units)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "units must be one of metric, imperial, si",
+			})
+			return
+		}
+		prefs.System = system
+	}
+	if request.WindUnit != "" {
+		wind, ok := units.ParseWindUnit(request.WindUnit)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "wind_unit must be one of kmh, ms, kn, mph",
+			})
+			return
+		}
+		prefs.Wind = wind
+	}
+	if request.Lang != "" {
+		lang, ok := units.ParseLang(request.Lang)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "lang must be one of id, en",
+			})
+			return
+		}
+		prefs.Lang = lang
+	}
+
+	err := h.userService.UpdatePreferences(c.Request.Context(), userID, prefs)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update preferences",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Preferences updated successfully",
+	})
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUserRoutesRequireToken(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{"no header", ""},
+		{"not bearer", "Basic YW5pOnNlY3JldA=="},
+		{"wrong secret", "Bearer " + token(testUserID, "wrong", time.Hour)},
+		{"expired", "Bearer " + token(testUserID, testSecret, -time.Minute)},
+		{"no subject", "Bearer " + token("", testSecret, time.Hour)},
+	}
+	for _, tt := range tests {
+		r := get("/api/v1/users/preferences")
+		if tt.authorization != "" {
+			r = r.with("Authorization", tt.authorization)
+		}
+		if rec := s.do(t, r); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status %d, want 401", tt.name, rec.Code)
+		}
+	}
+}
+
+func TestPreferences(t *testing.T) {
+	s := newTestServer(t)
+	auth := bearer(testUserID)
+
+	var prefs preferencesPayload
+	decode(t, s.expect(t, get("/api/v1/users/preferences").with("Authorization", auth), http.StatusOK), &prefs)
+	if prefs.Units != "imperial" || prefs.WindUnit != "mph" || prefs.Lang != "en" {
+		t.Errorf("saved preferences = %+v", prefs)
+	}
+
+	update := func(body string) request {
+		return request{method: http.MethodPut, path: "/api/v1/users/preferences", body: body}.with("Authorization", auth)
+	}
+	s.expect(t, update(`{"units": "metric", "wind_unit": "kn", "lang": "id"}`), http.StatusOK)
+	decode(t, s.expect(t, get("/api/v1/users/preferences").with("Authorization", auth), http.StatusOK), &prefs)
+	if prefs.Units != "metric" || prefs.WindUnit != "kn" || prefs.Lang != "id" {
+		t.Errorf("updated preferences = %+v", prefs)
+	}
+
+	s.expect(t, update(`{"units": "furlongs"}`), http.StatusBadRequest)
+	s.expect(t, update(`{"wind_unit": "beaufort"}`), http.StatusBadRequest)
+	s.expect(t, update(`{"lang": "jv"}`), http.StatusBadRequest)
+	s.expect(t, update(`[`), http.StatusBadRequest)
+
+	// A valid token for a user we do not have
+	s.expect(t, get("/api/v1/users/preferences").with("Authorization", bearer("user-unknown")), http.StatusNotFound)
+	s.expect(t, request{method: http.MethodPut, path: "/api/v1/users/preferences", body: `{}`}.
+		with("Authorization", bearer("user-unknown")), http.StatusNotFound)
+}
+
+func TestProfileAndFavorites(t *testing.T) {
+	s := newTestServer(t)
+	auth := bearer(testUserID)
+	with := func(r request) request { return r.with("Authorization", auth) }
+
+	s.expect(t, with(get("/api/v1/users/profile")), http.StatusOK)
+	s.expect(t, with(request{method: http.MethodPut, path: "/api/v1/users/profile", body: `{"name": "Ani Rahma"}`}), http.StatusOK)
+	s.expect(t, with(request{method: http.MethodPut, path: "/api/v1/users/profile", body: `{`}), http.StatusBadRequest)
+
+	s.expect(t, with(get("/api/v1/users/favorites")), http.StatusOK)
+	s.expect(t, with(request{method: http.MethodPost, path: "/api/v1/users/favorites", body: `{"regionId": "region-` + otherVillage + `"}`}), http.StatusOK)
+	s.expect(t, with(request{method: http.MethodPost, path: "/api/v1/users/favorites", body: `{}`}), http.StatusBadRequest)
+	s.expect(t, with(request{method: http.MethodDelete, path: "/api/v1/users/favorites/region-" + village}), http.StatusOK)
+}
@@ -1,31 +1,59 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/internal/condition"
+	"eamagineweather-backend/internal/middleware"
+	"eamagineweather-backend/internal/models"
 	"eamagineweather-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 type WeatherHandler struct {
-	weatherService *service.WeatherService
+	weatherService  *service.WeatherService
+	maxBatchRegions int
+}
+
+func NewWeatherHandler(weatherService *service.WeatherService, maxBatchRegions int) *WeatherHandler {
+	return &WeatherHandler{weatherService: weatherService, maxBatchRegions: maxBatchRegions}
 }
 
-func NewWeatherHandler(weatherService *service.WeatherService) *WeatherHandler {
-	return &WeatherHandler{weatherService: weatherService}
+// Version returns the version of the synced weather data, for
+// middleware.Conditional
+func (h *WeatherHandler) Version(ctx context.Context) (middleware.Version, error) {
+	version, err := h.weatherService.DataVersion(ctx)
+	if err != nil {
+		return middleware.Version{}, err
+	}
+	return middleware.Version{Tag: version.Tag, Modified: version.Modified}, nil
+}
+
+// CacheControl lets responses built from synced data be cached until the
+// next sync run
+func (h *WeatherHandler) CacheControl(c *gin.Context) string {
+	return untilNextSync(h.weatherService)
 }
 
 func (h *WeatherHandler) GetCurrentWeather(c *gin.Context) {
 	regionCode := c.Param("regionCode")
 	
 	weather, err := h.weatherService.GetCurrentWeather(c.Request.Context(), regionCode)
+	if errors.Is(err, service.ErrRegionNotFound) || errors.Is(err, service.ErrNoRollupData) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "No weather data for region",
+			"details": err.Error(),
+		})
+		return
+	}
 	if err != nil {
-		// If region not found in database, try to fetch directly from BMKG API
-		if weather, bmkgErr := h.weatherService.FetchWeatherDirectFromBMKG(c.Request.Context(), regionCode); bmkgErr == nil && weather != nil {
-			c.JSON(http.StatusOK, weather)
-			return
-		}
-		
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch weather data",
 			"details": err.Error(),
@@ -33,17 +61,134 @@ func (h *WeatherHandler) GetCurrentWeather(c *gin.Context) {
 		return
 	}
 	
-	c.JSON(http.StatusOK, weather)
+	service.LocalizeWeather(weather, middleware.GetPreferences(c))
+	// Current conditions follow the clock, not only the data
+	writeCachedJSON(c, weather, "public, max-age=300")
+}
+
+// GetCurrentWeatherBatch returns current weather for up to maxBatchRegions
+// region codes. Every code gets an entry, either weather or an error.
+func (h *WeatherHandler) GetCurrentWeatherBatch(c *gin.Context) {
+	var request struct {
+		Codes []string `json:"codes" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	seen := make(map[string]bool, len(request.Codes))
+	codes := make([]string, 0, len(request.Codes))
+	for _, code := range request.Codes {
+		code = strings.TrimSpace(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+
+	if len(codes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "codes must contain at least one region code",
+		})
+		return
+	}
+	if len(codes) > h.maxBatchRegions {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("codes must contain at most %d region codes", h.maxBatchRegions),
+		})
+		return
+	}
+
+	results := h.weatherService.GetCurrentWeatherBatch(c.Request.Context(), codes)
+	prefs := middleware.GetPreferences(c)
+	for _, result := range results {
+		if result.Weather != nil {
+			service.LocalizeWeather(result.Weather, prefs)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
 }
 
 func (h *WeatherHandler) GetWeatherForecast(c *gin.Context) {
 	regionCode := c.Param("regionCode")
 	
-	// TODO: Implement forecast logic
-	c.JSON(http.StatusOK, gin.H{
-		"regionCode": regionCode,
-		"forecast": []interface{}{},
-	})
+	forecast, err := h.weatherService.GetWeatherForecast(c.Request.Context(), regionCode)
+	if errors.Is(err, service.ErrRegionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Region not found",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch weather forecast",
+			"details": err.Error(),
+		})
+		return
+	}
+	
+	service.LocalizeWeather(forecast, middleware.GetPreferences(c))
+	writeCachedJSON(c, gin.H{"data": forecast}, untilNextSync(h.weatherService))
+}
+
+// GetConditions lists the condition taxonomy behind condition_code, plus
+// the BMKG descriptions seen since start that are not mapped yet
+func (h *WeatherHandler) GetConditions(c *gin.Context) {
+	writeCachedJSON(c, gin.H{
+		"data":    condition.All(),
+		"unknown": condition.UnknownDescriptions(),
+	}, "public, max-age=3600")
+}
+
+// maxCompareRegions limits how many forecasts one comparison fetches
+const maxCompareRegions = 10
+
+// CompareWeather aligns the forecasts of 2 to maxCompareRegions regions. The
+// first code in codes is the baseline for deltas.
+func (h *WeatherHandler) CompareWeather(c *gin.Context) {
+	seen := make(map[string]bool)
+	var codes []string
+	for _, code := range strings.Split(c.Query("codes"), ",") {
+		code = strings.TrimSpace(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+
+	if len(codes) < 2 || len(codes) > maxCompareRegions {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("codes must list between 2 and %d region codes", maxCompareRegions),
+		})
+		return
+	}
+
+	comparison, err := h.weatherService.CompareRegions(c.Request.Context(), codes)
+	if errors.Is(err, service.ErrRegionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Region not found",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to compare weather",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	service.LocalizeComparison(comparison, middleware.GetPreferences(c))
+	writeCachedJSON(c, gin.H{"data": comparison}, untilNextSync(h.weatherService))
 }
 
 func (h *WeatherHandler) SearchRegions(c *gin.Context) {
@@ -59,16 +204,218 @@ func (h *WeatherHandler) SearchRegions(c *gin.Context) {
 func (h *WeatherHandler) SyncWeatherData(c *gin.Context) {
 	regionCode := c.Param("regionCode")
 	
-	err := h.weatherService.SyncWeatherData(c.Request.Context(), regionCode)
+	points, err := h.weatherService.SyncWeatherData(c.Request.Context(), regionCode)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to sync weather data",
-		})
+		switch {
+		case errors.Is(err, service.ErrRegionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Region not found",
+			})
+		case errors.Is(err, service.ErrNotVillage):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Only village (adm4) codes can be synced",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to sync weather data",
+				"details": err.Error(),
+			})
+		}
 		return
 	}
 	
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Weather data synced successfully",
 		"regionCode": regionCode,
+		"points": points,
 	})
-}
\ No newline at end of file
+}
+
+// GET /api/v1/weather/history/:regionCode?from=&to=&granularity=3h|daily|monthly
+func (h *WeatherHandler) GetWeatherHistory(c *gin.Context) {
+	regionCode := c.Param("regionCode")
+
+	format, ok := negotiateExportFormat(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be one of json, csv, ndjson",
+		})
+		return
+	}
+
+	granularity := c.DefaultQuery("granularity", service.GranularityThreeHourly)
+	var defaultSpan time.Duration
+	switch granularity {
+	case service.GranularityThreeHourly:
+		defaultSpan = 7 * 24 * time.Hour
+	case service.GranularityDaily:
+		defaultSpan = 30 * 24 * time.Hour
+	case service.GranularityMonthly:
+		defaultSpan = 365 * 24 * time.Hour
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "granularity must be one of 3h, daily, monthly",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit must be between 1 and 1000",
+		})
+		return
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour).AddDate(0, 0, 1)
+	if value := c.Query("to"); value != "" {
+		parsed, dateOnly, err := parseLocalTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "to must be a date (2006-01-02) or RFC 3339 timestamp",
+			})
+			return
+		}
+		to = parsed
+		if dateOnly {
+			to = to.AddDate(0, 0, 1) // Whole day is included
+		}
+	}
+
+	from := to.Add(-defaultSpan)
+	if value := c.Query("from"); value != "" {
+		parsed, _, err := parseLocalTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "from must be a date (2006-01-02) or RFC 3339 timestamp",
+			})
+			return
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from must be before to",
+		})
+		return
+	}
+
+	history, err := h.weatherService.GetHistory(c.Request.Context(), regionCode, service.HistoryQuery{
+		Granularity: granularity,
+		From:        from,
+		To:          to,
+		Cursor:      c.Query("cursor"),
+		Limit:       limit,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRegionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Region not found",
+			})
+		case errors.Is(err, service.ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid cursor",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to fetch weather history",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	service.LocalizeHistory(history, middleware.GetPreferences(c))
+
+	if history.NextCursor != "" {
+		c.Header("X-Next-Cursor", history.NextCursor)
+	}
+
+	switch format {
+	case formatCSV:
+		filename := regionCode + "-" + granularity + ".csv"
+		if granularity == service.GranularityThreeHourly {
+			writeCSV(c, filename, historyPointHeader, historyPointRecords(history.Points))
+		} else {
+			writeCSV(c, filename, historyStatsHeader, historyStatsRecords(history.Stats))
+		}
+	case formatNDJSON:
+		if granularity == service.GranularityThreeHourly {
+			writeNDJSON(c, history.Points)
+		} else {
+			writeNDJSON(c, history.Stats)
+		}
+	default:
+		c.JSON(http.StatusOK, history)
+	}
+}
+
+// parseLocalTime accepts a date or RFC 3339 timestamp and returns its wall
+// clock time labelled as UTC, matching how local datetimes are stored.
+func parseLocalTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC), false, nil
+}
+
+var historyPointHeader = []string{
+	"local_datetime", "utc_datetime", "temperature", "humidity", "weather_desc", "weather_desc_en",
+	"wind_speed", "wind_direction", "cloud_cover", "visibility", "precipitation", "condition_code",
+}
+
+func historyPointRecords(points []models.ForecastWeather) [][]string {
+	records := make([][]string, len(points))
+	for i, p := range points {
+		records[i] = []string{
+			p.LocalDateTime.Format("2006-01-02 15:04:05"),
+			p.DateTime.Format(time.RFC3339),
+			formatFloat(p.Temperature),
+			strconv.Itoa(p.Humidity),
+			p.WeatherDesc,
+			p.WeatherDescEn,
+			formatFloat(p.WindSpeed),
+			p.WindDirection,
+			strconv.Itoa(p.CloudCover),
+			p.Visibility,
+			formatFloat(p.Precipitation),
+			strconv.Itoa(p.ConditionCode),
+		}
+	}
+	return records
+}
+
+var historyStatsHeader = []string{
+	"period", "min_temperature", "max_temperature", "avg_temperature", "avg_humidity",
+	"max_wind_speed", "avg_cloud_cover", "weather_desc", "weather_desc_en", "condition_code", "sample_count",
+}
+
+func historyStatsRecords(stats []models.WeatherHistoryStats) [][]string {
+	records := make([][]string, len(stats))
+	for i, s := range stats {
+		records[i] = []string{
+			s.Period.Format("2006-01-02"),
+			formatFloat(s.MinTemperature),
+			formatFloat(s.MaxTemperature),
+			formatFloat(s.AvgTemperature),
+			formatFloat(s.AvgHumidity),
+			formatFloat(s.MaxWindSpeed),
+			formatFloat(s.AvgCloudCover),
+			s.WeatherDesc,
+			s.WeatherDescEn,
+			strconv.Itoa(s.ConditionCode),
+			strconv.Itoa(s.SampleCount),
+		}
+	}
+	return records
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"encoding/csv"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"eamagineweather-backend/internal/models"
+)
+
+type weatherBody struct {
+	Data models.WeatherResponse `json:"data"`
+}
+
+func TestGetCurrentWeather(t *testing.T) {
+	s := newTestServer(t)
+
+	var body models.WeatherResponse
+	decode(t, s.expect(t, get("/api/v1/weather/current/"+village), http.StatusOK), &body)
+	if body.Current == nil || body.Current.Temperature != 24 {
+		t.Fatalf("current = %+v, want 24°C", body.Current)
+	}
+	if body.Region.Code != village || body.Region.Name != "Sukarasa" {
+		t.Errorf("region = %+v", body.Region)
+	}
+	if body.Current.Description != "Hujan Ringan" {
+		t.Errorf("description %q, want the Indonesian one by default", body.Current.Description)
+	}
+
+	decode(t, s.expect(t, get("/api/v1/weather/current/"+village+"?units=imperial&lang=en"), http.StatusOK), &body)
+	if got := body.Current.Temperature; math.Abs(got-75.2) > 0.1 {
+		t.Errorf("imperial temperature %v, want 75.2", got)
+	}
+	if body.Units == nil || body.Units.Temperature != "°F" || body.Current.Description != "Light Rain" {
+		t.Errorf("imperial English response units %+v, description %q", body.Units, body.Current.Description)
+	}
+
+	s.expect(t, get("/api/v1/weather/current/"+village+"?units=furlongs"), http.StatusBadRequest)
+	s.expect(t, get("/api/v1/weather/current/"+missing), http.StatusNotFound)
+}
+
+func TestGetCurrentWeatherFromBMKG(t *testing.T) {
+	s := newTestServer(t)
+
+	// Regions missing from our database are fetched directly from BMKG
+	var body models.WeatherResponse
+	decode(t, s.expect(t, get("/api/v1/weather/current/"+unlisted), http.StatusOK), &body)
+	if body.Current == nil || body.Current.Temperature != 26 || body.Region.Code != unlisted {
+		t.Errorf("current weather of %s = %+v in %+v, want 26°C from BMKG", unlisted, body.Current, body.Region)
+	}
+}
+
+func TestGetCurrentWeatherSavedPreferences(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.expect(t, get("/api/v1/weather/current/"+village).with("Authorization", bearer(testUserID)), http.StatusOK)
+	var body models.WeatherResponse
+	decode(t, rec, &body)
+	if body.Units == nil || body.Units.Temperature != "°F" || body.Units.Lang != "en" {
+		t.Errorf("signed-in units %+v, want the saved imperial and English", body.Units)
+	}
+	if got := rec.Header().Get("Cache-Control"); !strings.HasPrefix(got, "private") {
+		t.Errorf("Cache-Control %q for a signed-in user, want private", got)
+	}
+
+	// An invalid token is ignored on public routes
+	rec = s.expect(t, get("/api/v1/weather/current/"+village).with("Authorization", "Bearer "+token(testUserID, "wrong", 0)), http.StatusOK)
+	body = models.WeatherResponse{}
+	decode(t, rec, &body)
+	if body.Units != nil && body.Units.Temperature != "°C" {
+		t.Errorf("units %+v for an invalid token, want the defaults", body.Units)
+	}
+}
+
+func TestVaryByAuthorization(t *testing.T) {
+	s := newTestServer(t)
+	s.sync(t, village)
+
+	// Shared caches must key every OptionalAuth route on the token, and
+	// keep the encoding and language a body was built for
+	for _, path := range []string{
+		"/api/v1/weather/current/" + village,
+		"/api/v1/weather/history/" + village,
+		"/api/v1/map/layer/" + regency,
+		"/feeds/" + village + ".ics",
+	} {
+		for _, r := range []request{get(path), get(path).with("Authorization", bearer(testUserID))} {
+			vary := strings.Join(s.expect(t, r, http.StatusOK).Header().Values("Vary"), ", ")
+			if !strings.Contains(vary, "Authorization") {
+				t.Errorf("%s (Authorization %q) varies by %q, want Authorization", path, r.header["Authorization"], vary)
+			}
+			if strings.HasPrefix(path, "/api/v1/weather/current/") && !strings.Contains(vary, "Accept-Language") {
+				t.Errorf("%s varies by %q, want Accept-Language", path, vary)
+			}
+		}
+	}
+}
+
+func TestGetCurrentWeatherRegional(t *testing.T) {
+	s := newTestServer(t)
+
+	// Nothing to summarise before a sync
+	s.expect(t, get("/api/v1/weather/current/"+regency), http.StatusNotFound)
+	s.expect(t, get("/api/v1/weather/current/99.99"), http.StatusNotFound)
+
+	s.sync(t, village, otherVillage)
+	var body models.WeatherResponse
+	decode(t, s.expect(t, get("/api/v1/weather/current/"+regency), http.StatusOK), &body)
+	regional := body.Regional
+	if regional == nil || regional.VillageCount != 2 {
+		t.Fatalf("regional = %+v, want a summary of 2 villages", regional)
+	}
+	if regional.MinTemperature != 22 || regional.MaxTemperature != 24 {
+		t.Errorf("regional temperatures %v–%v, want 22–24", regional.MinTemperature, regional.MaxTemperature)
+	}
+}
+
+func TestGetCurrentWeatherBatch(t *testing.T) {
+	s := newTestServer(t)
+	batch := func(body string) request {
+		return request{method: http.MethodPost, path: "/api/v1/weather/current/batch", body: body}
+	}
+
+	var body struct {
+		Data map[string]models.BatchWeatherResult `json:"data"`
+	}
+	decode(t, s.expect(t, batch(`{"codes": ["`+village+`", " `+village+` ", "`+missing+`"]}`), http.StatusOK), &body)
+	if len(body.Data) != 2 {
+		t.Fatalf("batch returned %d entries, want one per distinct code", len(body.Data))
+	}
+	if result := body.Data[village]; result.Weather == nil || result.Weather.Current.Temperature != 24 {
+		t.Errorf("batch %s = %+v", village, result)
+	}
+	if result := body.Data[missing]; result.Weather != nil || result.Error == "" {
+		t.Errorf("batch %s = %+v, want an error", missing, result)
+	}
+
+	s.expect(t, batch(`{"codes": []}`), http.StatusBadRequest)
+	s.expect(t, batch(`{"codes": [" "]}`), http.StatusBadRequest)
+	s.expect(t, batch(`{"codes": ["1", "2", "3", "4"]}`), http.StatusBadRequest)
+	s.expect(t, batch(`not json`), http.StatusBadRequest)
+}
+
+func TestGetWeatherForecast(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.expect(t, get("/api/v1/weather/forecast/"+village), http.StatusOK)
+	var body weatherBody
+	decode(t, rec, &body)
+	if len(body.Data.Forecast) != 16 || len(body.Data.Daily) == 0 {
+		t.Errorf("forecast has %d points and %d days, want 16 points", len(body.Data.Forecast), len(body.Data.Daily))
+	}
+
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("forecast has no ETag")
+	}
+	revalidated := s.expect(t, get("/api/v1/weather/forecast/"+village).with("If-None-Match", etag), http.StatusNotModified)
+	if revalidated.Body.Len() != 0 {
+		t.Errorf("304 with a body of %d bytes", revalidated.Body.Len())
+	}
+
+	s.expect(t, get("/api/v1/weather/forecast/"+missing), http.StatusNotFound)
+}
+
+func TestCompareWeather(t *testing.T) {
+	s := newTestServer(t)
+
+	var body struct {
+		Data models.WeatherComparison `json:"data"`
+	}
+	decode(t, s.expect(t, get("/api/v1/weather/compare?codes="+village+","+otherVillage), http.StatusOK), &body)
+	if body.Data.Baseline != village || len(body.Data.Regions) != 2 || len(body.Data.Timeline) != 16 {
+		t.Fatalf("comparison baseline %s, %d regions, %d points", body.Data.Baseline, len(body.Data.Regions), len(body.Data.Timeline))
+	}
+	first := body.Data.Timeline[0].Regions
+	if len(first) != 2 || first[1].Code != otherVillage || first[1].TemperatureDelta != -2 {
+		t.Errorf("first comparison point %+v, want %s 2° below the baseline", first, otherVillage)
+	}
+
+	s.expect(t, get("/api/v1/weather/compare?codes="+village), http.StatusBadRequest)
+	s.expect(t, get("/api/v1/weather/compare?codes="+village+","+village), http.StatusBadRequest)
+	s.expect(t, get("/api/v1/weather/compare?codes="+village+","+missing), http.StatusNotFound)
+}
+
+func TestGetConditions(t *testing.T) {
+	s := newTestServer(t)
+	var body struct {
+		Data    []map[string]any `json:"data"`
+		Unknown []string         `json:"unknown"`
+	}
+	decode(t, s.expect(t, get("/api/v1/weather/conditions"), http.StatusOK), &body)
+	if len(body.Data) == 0 {
+		t.Error("conditions list is empty")
+	}
+}
+
+func TestSearchRegionsPlaceholder(t *testing.T) {
+	s := newTestServer(t)
+	var body struct {
+		Query   string `json:"query"`
+		Results []any  `json:"results"`
+	}
+	decode(t, s.expect(t, get("/api/v1/weather/search?q=bandung"), http.StatusOK), &body)
+	if body.Query != "bandung" || body.Results == nil {
+		t.Errorf("search = %+v", body)
+	}
+}
+
+func TestSyncWeatherData(t *testing.T) {
+	s := newTestServer(t)
+	sync := func(code string) request {
+		return request{method: http.MethodPost, path: "/api/v1/weather/sync/" + code}
+	}
+
+	var body struct {
+		RegionCode string `json:"regionCode"`
+		Points     int    `json:"points"`
+	}
+	decode(t, s.expect(t, sync(village), http.StatusOK), &body)
+	if body.RegionCode != village || body.Points != 16 {
+		t.Errorf("sync = %+v, want 16 points", body)
+	}
+
+	s.expect(t, sync(district), http.StatusBadRequest)
+	s.expect(t, sync("32.73.01.1888"), http.StatusNotFound)
+}
+
+func TestGetWeatherHistory(t *testing.T) {
+	s := newTestServer(t)
+	path := "/api/v1/weather/history/" + village
+
+	s.expect(t, get("/api/v1/weather/history/99.99"), http.StatusNotFound)
+	s.sync(t, village)
+
+	rec := s.expect(t, get(path), http.StatusOK)
+	var history models.WeatherHistoryResponse
+	decode(t, rec, &history)
+	if history.Region.Code != village || history.Granularity != "3h" || len(history.Points) == 0 {
+		t.Fatalf("history region %s, granularity %s, %d points", history.Region.Code, history.Granularity, len(history.Points))
+	}
+	points := len(history.Points)
+
+	// Answered from the data version until the next sync
+	etag := rec.Header().Get("ETag")
+	if etag == "" || rec.Header().Get("Last-Modified") == "" {
+		t.Fatalf("history validators ETag %q, Last-Modified %q", etag, rec.Header().Get("Last-Modified"))
+	}
+	s.expect(t, get(path).with("If-None-Match", etag), http.StatusNotModified)
+	// The version follows the last update to the millisecond
+	time.Sleep(2 * time.Millisecond)
+	s.sync(t, otherVillage)
+	s.expect(t, get(path).with("If-None-Match", etag), http.StatusOK)
+
+	decode(t, s.expect(t, get(path+"?granularity=daily"), http.StatusOK), &history)
+	if len(history.Stats) == 0 || history.Stats[0].SampleCount == 0 {
+		t.Errorf("daily history %+v", history.Stats)
+	}
+
+	// A daily from late in the day still includes that day
+	first := time.Now().UTC().Truncate(3 * time.Hour).Add(7 * time.Hour)
+	query := "?granularity=daily&from=" + first.Format("2006-01-02") + "T23:00:00Z&to=" + first.AddDate(0, 0, 3).Format("2006-01-02")
+	history = models.WeatherHistoryResponse{}
+	decode(t, s.expect(t, get(path+query), http.StatusOK), &history)
+	if len(history.Stats) == 0 || history.Stats[0].Period.Format("2006-01-02") != first.Format("2006-01-02") {
+		t.Errorf("daily history from 23:00 on %s starts %+v", first.Format("2006-01-02"), history.Stats)
+	}
+
+	// Cursors only continue the listing they came from
+	decode(t, s.expect(t, get(path+"?limit=1"), http.StatusOK), &history)
+	cursor := history.NextCursor
+	if cursor == "" {
+		t.Fatal("first page of one point has no cursor")
+	}
+	s.expect(t, get(path+"?limit=1&cursor="+cursor), http.StatusOK)
+	s.expect(t, get("/api/v1/weather/history/"+otherVillage+"?limit=1&cursor="+cursor), http.StatusBadRequest)
+	s.expect(t, get(path+"?granularity=daily&cursor="+cursor), http.StatusBadRequest)
+
+	rec = s.expect(t, get(path+"?format=csv"), http.StatusOK)
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != points+1 || records[0][0] != "local_datetime" {
+		t.Errorf("CSV history starts %v", records[0])
+	}
+
+	rec = s.expect(t, get(path).with("Accept", "application/x-ndjson"), http.StatusOK)
+	if lines := strings.Count(rec.Body.String(), "\n"); lines != points {
+		t.Errorf("NDJSON history has %d lines, want one per point of %d", lines, points)
+	}
+
+	for _, query := range []string{
+		"?granularity=hourly",
+		"?format=xml",
+		"?limit=0",
+		"?to=yesterday",
+		"?from=2026-02-01&to=2026-01-01",
+		"?cursor=nonsense",
+	} {
+		s.expect(t, get(path+query), http.StatusBadRequest)
+	}
+}
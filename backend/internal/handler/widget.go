@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/internal/condition"
+	"eamagineweather-backend/internal/middleware"
+	"eamagineweather-backend/internal/service"
+	"eamagineweather-backend/internal/widget"
+
+	"github.com/gin-gonic/gin"
+)
+
+// widgetDays is how many days a forecast widget shows
+const widgetDays = 3
+
+// GET /api/v1/widget/:file where file is <regionCode>.svg or <regionCode>.png,
+// with optional theme=light|dark, size=small|medium|large, lang and units
+func (h *WeatherHandler) GetWidget(c *gin.Context) {
+	file := c.Param("file")
+	dot := strings.LastIndex(file, ".")
+	if dot <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Widget must be requested as <regionCode>.svg or <regionCode>.png",
+		})
+		return
+	}
+	regionCode, format := file[:dot], file[dot+1:]
+	if format != "svg" && format != "png" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Widget must be requested as <regionCode>.svg or <regionCode>.png",
+		})
+		return
+	}
+
+	theme, ok := widget.Themes[c.DefaultQuery("theme", "light")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "theme must be one of light, dark",
+		})
+		return
+	}
+	scale, ok := widget.Sizes[c.DefaultQuery("size", "small")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "size must be one of small, medium, large",
+		})
+		return
+	}
+
+	forecast, err := h.weatherService.GetWeatherForecast(c.Request.Context(), regionCode)
+	if errors.Is(err, service.ErrRegionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Region not found",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch weather forecast",
+			"details": err.Error(),
+		})
+		return
+	}
+	prefs := middleware.GetPreferences(c)
+	service.LocalizeWeather(forecast, prefs)
+
+	card := widget.Card{
+		Title: forecast.Region.Name,
+		Unit:  forecast.Units.Temperature,
+		Lang:  prefs.Lang,
+	}
+	if card.Title == "" {
+		card.Title = regionCode
+	}
+	for _, day := range forecast.Daily {
+		if len(card.Days) == widgetDays {
+			break
+		}
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			continue
+		}
+		card.Days = append(card.Days, widget.Day{
+			Date:       date,
+			Min:        day.MinTemperature,
+			Max:        day.MaxTemperature,
+			RainChance: day.RainChance,
+			Condition:  condition.Code(day.ConditionCode),
+		})
+	}
+
+	var body bytes.Buffer
+	contentType := "image/svg+xml"
+	if format == "png" {
+		contentType = "image/png"
+		err = widget.RenderPNG(&body, card, theme, scale)
+	} else {
+		err = widget.RenderSVG(&body, card, theme, scale)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to render widget",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Cache until the data can next change
+	c.Header("Cache-Control", middleware.CacheControl(c, untilNextSync(h.weatherService)))
+	c.Header("Expires", h.weatherService.NextSyncAt().UTC().Format(http.TimeFormat))
+	c.Data(http.StatusOK, contentType, body.Bytes())
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetWidget(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.expect(t, get("/api/v1/widget/"+village+".svg?theme=dark&size=large"), http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "image/svg+xml" {
+		t.Errorf("SVG widget Content-Type %q", got)
+	}
+	if rec.Header().Get("Expires") == "" || !strings.HasPrefix(rec.Header().Get("Cache-Control"), "public") {
+		t.Errorf("SVG widget caching %q, expires %q", rec.Header().Get("Cache-Control"), rec.Header().Get("Expires"))
+	}
+	var svg struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &svg); err != nil || svg.XMLName.Local != "svg" {
+		t.Errorf("SVG widget is not an svg document: %v", err)
+	}
+	if !strings.Contains(rec.Body.String(), "Sukarasa") {
+		t.Error("SVG widget does not name the region")
+	}
+
+	rec = s.expect(t, get("/api/v1/widget/"+village+".png"), http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("PNG widget Content-Type %q", got)
+	}
+	if !bytes.HasPrefix(rec.Body.Bytes(), []byte("\x89PNG\r\n\x1a\n")) {
+		t.Error("PNG widget lacks the PNG signature")
+	}
+
+	s.expect(t, get("/api/v1/widget/"+village+".gif"), http.StatusNotFound)
+	s.expect(t, get("/api/v1/widget/"+village), http.StatusNotFound)
+	s.expect(t, get("/api/v1/widget/"+village+".svg?theme=sepia"), http.StatusBadRequest)
+	s.expect(t, get("/api/v1/widget/"+village+".svg?size=huge"), http.StatusBadRequest)
+	s.expect(t, get("/api/v1/widget/"+missing+".svg"), http.StatusNotFound)
+}
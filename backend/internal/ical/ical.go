@@ -0,0 +1,135 @@
+// Package ical writes iCalendar (RFC 5545) feeds. It covers only what our
+// feeds publish: all-day and timed events with text properties.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event is a VEVENT. Clients match events across refreshes by UID, so it
+// must not change when the event's content does.
+type Event struct {
+	UID          string
+	Stamp        time.Time // DTSTAMP, when this version of the event was published
+	LastModified time.Time // Optional
+	Start        time.Time
+	End          time.Time // Optional for timed events; exclusive
+	AllDay       bool      // Start and End are dates in the calendar's local sense
+	Summary      string
+	Description  string
+	Location     string
+	Categories   []string
+}
+
+// Calendar is a VCALENDAR
+type Calendar struct {
+	ProdID  string
+	Name    string        // Shown by clients as the calendar title
+	Refresh time.Duration // Suggested polling interval, 0 to omit
+	Events  []Event
+}
+
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+	maxLineOctets  = 75
+)
+
+// Write encodes the calendar with CRLF line endings and folded lines
+func (c Calendar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", c.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escape(c.Name))
+	}
+	if c.Refresh > 0 {
+		duration := fmt.Sprintf("PT%dM", int(c.Refresh.Minutes()))
+		line("REFRESH-INTERVAL;VALUE=DURATION", duration)
+		line("X-PUBLISHED-TTL", duration)
+	}
+
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", e.Stamp.UTC().Format(dateTimeFormat))
+		if !e.LastModified.IsZero() {
+			line("LAST-MODIFIED", e.LastModified.UTC().Format(dateTimeFormat))
+		}
+		if e.AllDay {
+			line("DTSTART;VALUE=DATE", e.Start.Format(dateFormat))
+			end := e.End
+			if end.IsZero() {
+				end = e.Start.AddDate(0, 0, 1)
+			}
+			line("DTEND;VALUE=DATE", end.Format(dateFormat))
+			line("TRANSP", "TRANSPARENT")
+		} else {
+			line("DTSTART", e.Start.UTC().Format(dateTimeFormat))
+			if !e.End.IsZero() {
+				line("DTEND", e.End.UTC().Format(dateTimeFormat))
+			}
+		}
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escape(e.Location))
+		}
+		if len(e.Categories) > 0 {
+			categories := make([]string, len(e.Categories))
+			for i, category := range e.Categories {
+				categories[i] = escape(category)
+			}
+			line("CATEGORIES", strings.Join(categories, ","))
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// escape encodes a TEXT value
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// writeFolded writes a content line, folding it at 75 octets without
+// splitting a UTF-8 sequence
+func writeFolded(w *bufio.Writer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1 // The leading space counts
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
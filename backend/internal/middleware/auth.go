@@ -1,17 +1,25 @@
 package middleware
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware provides JWT authentication middleware
+// AuthMiddleware provides JWT authentication middleware. A valid HS256
+// bearer token signed with jwtSecret is required; its subject is stored
+// under UserIDKey.
 func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Simple auth middleware - you can implement JWT validation here
 		authHeader := c.GetHeader("Authorization")
-		
+
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Authorization header required",
@@ -20,8 +28,97 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		// TODO: Implement proper JWT validation
-		// For now, just pass through
+		userID, err := verifyBearer(authHeader, jwtSecret, time.Now())
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Invalid token",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(UserIDKey, userID)
+		c.Next()
+	}
+}
+
+// OptionalAuth stores the subject of a valid bearer token under UserIDKey,
+// so public routes can apply a signed-in user's preferences. Requests
+// without a valid token pass through anonymously. Either way the response
+// depends on the token, so it varies by Authorization.
+func OptionalAuth(jwtSecret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Authorization")
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			if userID, err := verifyBearer(authHeader, jwtSecret, time.Now()); err == nil {
+				c.Set(UserIDKey, userID)
+			}
+		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// verifyBearer checks an "Authorization: Bearer <jwt>" header: an HS256
+// signature by secret, and exp and nbf when present. It returns the sub
+// claim.
+func verifyBearer(header, secret string, now time.Time) (string, error) {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return "", errors.New("expected a bearer token")
+	}
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return "", errors.New("malformed token")
+	}
+
+	var head struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &head); err != nil {
+		return "", err
+	}
+	if head.Alg != "HS256" {
+		return "", errors.New("unsupported signing algorithm " + head.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("malformed signature")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", errors.New("signature mismatch")
+	}
+
+	var claims struct {
+		Sub string   `json:"sub"`
+		Exp *float64 `json:"exp"`
+		Nbf *float64 `json:"nbf"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", err
+	}
+	if claims.Exp != nil && now.Unix() >= int64(*claims.Exp) {
+		return "", errors.New("token expired")
+	}
+	if claims.Nbf != nil && now.Unix() < int64(*claims.Nbf) {
+		return "", errors.New("token not yet valid")
+	}
+	if claims.Sub == "" {
+		return "", errors.New("token has no subject")
+	}
+	return claims.Sub, nil
+}
+
+func decodeSegment(segment string, value any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("malformed token")
+	}
+	if err := json.Unmarshal(raw, value); err != nil {
+		return errors.New("malformed token")
+	}
+	return nil
+}
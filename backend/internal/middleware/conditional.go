@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version identifies the data behind a response: Tag changes whenever the
+// data does, and Modified is when it last did (zero when unknown)
+type Version struct {
+	Tag      string
+	Modified time.Time
+}
+
+// VersionFunc returns the current version of the data a route serves
+type VersionFunc func(ctx context.Context) (Version, error)
+
+// CacheControlFunc returns the Cache-Control value for a response
+type CacheControlFunc func(c *gin.Context) string
+
+// Conditional answers conditional GET requests from the data version alone,
+// before the handler runs. The strong ETag is derived from the version, the
+// request URI, the resolved preferences and the vary request headers, which
+// are also listed in Vary. If-None-Match is checked first and
+// If-Modified-Since only without it, as RFC 9110 requires. ETag,
+// Last-Modified and cacheControl are kept only on 200 responses, so errors
+// are never cached under a validator. When the version cannot be loaded the
+// request is served uncached.
+func Conditional(version VersionFunc, cacheControl CacheControlFunc, vary ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		current, err := version(c.Request.Context())
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		etag := conditionalETag(c, current.Tag, vary)
+		header := c.Writer.Header()
+		header.Set("ETag", etag)
+		header.Set("Cache-Control", CacheControl(c, cacheControl(c)))
+		if !current.Modified.IsZero() {
+			header.Set("Last-Modified", current.Modified.UTC().Format(http.TimeFormat))
+		}
+		if len(vary) > 0 {
+			header.Add("Vary", strings.Join(vary, ", "))
+		}
+
+		if notModified(c.Request, etag, current.Modified) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+
+		c.Writer = &validatedWriter{ResponseWriter: c.Writer}
+		c.Next()
+	}
+}
+
+// conditionalETag derives a strong ETag from a data version and everything
+// else the response depends on
+func conditionalETag(c *gin.Context, tag string, vary []string) string {
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s\n%s\n", tag, c.Request.URL.RequestURI())
+	if prefs, ok := c.Get(PreferencesKey); ok {
+		fmt.Fprintf(sum, "%v\n", prefs)
+	}
+	for _, name := range vary {
+		fmt.Fprintf(sum, "%s: %s\n", name, c.GetHeader(name))
+	}
+	return `"` + hex.EncodeToString(sum.Sum(nil)[:16]) + `"`
+}
+
+// notModified evaluates If-None-Match, or without it If-Modified-Since
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, candidate := range strings.Split(header, ",") {
+			// Weak validators match their strong form for GET
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if modified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// HTTP dates have whole seconds
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// validatedWriter drops the validators and Cache-Control Conditional set
+// when the handler answers with anything but 200
+type validatedWriter struct {
+	gin.ResponseWriter
+}
+
+func (w *validatedWriter) WriteHeader(code int) {
+	if code != http.StatusOK {
+		header := w.Header()
+		header.Del("ETag")
+		header.Del("Last-Modified")
+		header.Del("Cache-Control")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
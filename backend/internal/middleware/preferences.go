@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"eamagineweather-backend/internal/units"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// UserIDKey is the context key holding the authenticated user's ID
+	UserIDKey = "userID"
+	// PreferencesKey is the context key holding the resolved units.Preferences
+	PreferencesKey = "preferences"
+)
+
+// PreferenceLookup loads the saved preferences of a signed-in user
+type PreferenceLookup func(ctx context.Context, userID string) (units.Preferences, error)
+
+// Preferences resolves the units and language for a request. The units,
+// wind_unit and lang query parameters win, then the signed-in user's saved
+// preferences, then Accept-Language, then BMKG's own units.
+func Preferences(lookup PreferenceLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		prefs := units.Default
+		savedLang := false
+
+		if userID := c.GetString(UserIDKey); userID != "" && lookup != nil {
+			if saved, err := lookup(c.Request.Context(), userID); err == nil {
+				prefs = saved
+				savedLang = saved.Lang != ""
+			}
+		}
+
+		if !savedLang {
+			if lang, ok := units.ParseLang(c.GetHeader("Accept-Language")); ok {
+				prefs.Lang = lang
+			}
+		}
+
+		if value := c.Query("units"); value != "" {
+			system, ok := units.ParseSystem(value)
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error": "units must be one of metric, imperial, si",
+				})
+				return
+			}
+			prefs.System = system
+			prefs.Wind = units.DefaultWindUnit(system)
+		}
+
+		if value := c.Query("wind_unit"); value != "" {
+			wind, ok := units.ParseWindUnit(value)
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error": "wind_unit must be one of kmh, ms, kn, mph",
+				})
+				return
+			}
+			prefs.Wind = wind
+		}
+
+		if value := c.Query("lang"); value != "" {
+			lang, ok := units.ParseLang(value)
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error": "lang must be one of id, en",
+				})
+				return
+			}
+			prefs.Lang = lang
+		}
+
+		c.Set(PreferencesKey, prefs)
+		c.Next()
+	}
+}
+
+// CacheControl scopes a Cache-Control value to the request. Responses to a
+// signed-in user follow their saved preferences, so shared caches must not
+// keep them. OptionalAuth already marks such routes Vary: Authorization.
+func CacheControl(c *gin.Context, value string) string {
+	if c.GetString(UserIDKey) == "" {
+		return value
+	}
+	return strings.Replace(value, "public", "private", 1)
+}
+
+// GetPreferences returns the preferences resolved by Preferences, or the
+// defaults when the middleware did not run
+func GetPreferences(c *gin.Context) units.Preferences {
+	if value, ok := c.Get(PreferencesKey); ok {
+		if prefs, ok := value.(units.Preferences); ok {
+			return prefs
+		}
+	}
+	return units.Default
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// regionParams are the route parameters that hold a region code
+var regionParams = []string{"regionCode", "provinceCode", "regencyCode", "code"}
+
+// AliasResolver returns the code that replaced a retired region code
+type AliasResolver func(ctx context.Context, code string) (string, bool)
+
+// RegionAliases redirects requests for a retired region code to the same
+// URL with its successor, so links and bookmarks survive pemekaran. GET
+// requests get 301; other methods 308, which keeps the method and body.
+func RegionAliases(resolve AliasResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, name := range regionParams {
+			code := c.Param(name)
+			if code == "" {
+				continue
+			}
+			successor, ok := resolve(c.Request.Context(), code)
+			if !ok {
+				continue
+			}
+
+			target := *c.Request.URL
+			i := strings.LastIndex(target.Path, "/"+code)
+			if i < 0 {
+				continue
+			}
+			target.Path = target.Path[:i+1] + successor + target.Path[i+1+len(code):]
+			target.RawPath = ""
+
+			status := http.StatusMovedPermanently
+			if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+				status = http.StatusPermanentRedirect
+			}
+			c.Header("X-Region-Alias", code+" -> "+successor)
+			c.Redirect(status, target.String())
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -6,7 +6,15 @@ import (
 
 // BMKGWeatherResponse represents the response from BMKG API
 type BMKGWeatherResponse struct {
-	Data []BMKGWeatherData `json:"data"`
+	Lokasi *BMKGLocation     `json:"lokasi,omitempty"`
+	Data   []BMKGWeatherData `json:"data"`
+}
+
+// BMKGLocation represents the location metadata BMKG returns with a forecast
+type BMKGLocation struct {
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lon"`
+	Timezone  string  `json:"timezone"`
 }
 
 // BMKGWeatherData represents individual weather data point from BMKG
@@ -21,14 +29,78 @@ type BMKGWeatherData struct {
 	WindDirection  string  `json:"wd"`
 	CloudCover     int     `json:"tcc"`
 	Visibility     string  `json:"vs_text"`
+	Precipitation  float64 `json:"tp"`
 	AnalysisDate   string  `json:"analysis_date"`
 }
 
 // WeatherResponse represents the API response for weather data
 type WeatherResponse struct {
-	Current  *CurrentWeather    `json:"current,omitempty"`
-	Forecast []ForecastWeather  `json:"forecast,omitempty"`
-	Region   RegionInfo         `json:"region"`
+	Current   *CurrentWeather    `json:"current,omitempty"`
+	Forecast  []ForecastWeather  `json:"forecast,omitempty"`
+	Daily     []DailyForecast    `json:"daily,omitempty"`
+	Astronomy *Astronomy         `json:"astronomy,omitempty"`
+	Regional  *RegionalWeather   `json:"regional,omitempty"`
+	Region    RegionInfo         `json:"region"`
+	Units     *UnitsInfo         `json:"units,omitempty"`
+}
+
+// RegionalWeather summarises the current weather of all synced villages in
+// a province, regency or district
+type RegionalWeather struct {
+	Level             int       `json:"level"` // 1=Province, 2=Regency/City, 3=District
+	VillageCount      int       `json:"village_count"`
+	MinTemperature    float64   `json:"min_temperature"`
+	MedianTemperature float64   `json:"median_temperature"`
+	MaxTemperature    float64   `json:"max_temperature"`
+	RainShare         float64   `json:"rain_share"`     // Fraction of villages with rain, 0–1
+	ConditionCode     int       `json:"condition_code"` // Most common condition
+	Condition         string    `json:"condition"`
+	MaxWindSpeed      float64   `json:"max_wind_speed"`
+	ValidAt           time.Time `json:"valid_at"` // Forecast slot the summary describes
+	SyncRunID         string    `json:"sync_run_id,omitempty"`
+}
+
+// BatchWeatherResult is the outcome for one region of a batch request.
+// Exactly one of Weather and Error is set.
+type BatchWeatherResult struct {
+	Weather *WeatherResponse `json:"weather,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// UnitsInfo names the units and language a response was rendered in
+type UnitsInfo struct {
+	System        string `json:"system"`
+	Temperature   string `json:"temperature"`
+	WindSpeed     string `json:"wind_speed"`
+	Visibility    string `json:"visibility"`
+	Precipitation string `json:"precipitation"`
+	Lang          string `json:"lang"`
+}
+
+// DailyForecast summarises forecast points for one local calendar day
+type DailyForecast struct {
+	Date           string     `json:"date"` // YYYY-MM-DD in the region's time zone
+	MinTemperature float64    `json:"min_temperature"`
+	MaxTemperature float64    `json:"max_temperature"`
+	ConditionCode  int        `json:"condition_code"` // Most severe condition of the day
+	RainChance     int        `json:"rain_chance"`    // Percentage of the day's forecast points with rain
+	Astronomy      *Astronomy `json:"astronomy,omitempty"`
+}
+
+// Astronomy represents sun and moon events for one local calendar day
+type Astronomy struct {
+	Date             string    `json:"date"`
+	Timezone         string    `json:"timezone"` // WIB, WITA or WIT
+	Sunrise          time.Time `json:"sunrise"`
+	Sunset           time.Time `json:"sunset"`
+	SolarNoon        time.Time `json:"solar_noon"`
+	CivilDawn        time.Time `json:"civil_dawn"`
+	CivilDusk        time.Time `json:"civil_dusk"`
+	DayLengthMinutes int       `json:"day_length_minutes"`
+	MoonPhase        float64   `json:"moon_phase"`        // 0 new, 0.5 full
+	MoonIllumination float64   `json:"moon_illumination"` // 0–1
+	MoonPhaseName    string    `json:"moon_phase_name"`
+	MoonPhaseNameEn  string    `json:"moon_phase_name_en"`
 }
 
 // CurrentWeather represents current weather conditions
@@ -41,8 +113,12 @@ type CurrentWeather struct {
 	WindDirection string  `json:"wind_direction"`
 	CloudCover    int     `json:"cloud_cover"`
 	Visibility    string  `json:"visibility"`
+	Precipitation float64 `json:"precipitation"`
+	Description   string  `json:"description,omitempty"` // Localized weather description
 	DateTime      time.Time `json:"datetime"`
 	LocalDateTime time.Time `json:"local_datetime"`
+	WeatherCondition
+	DerivedIndices
 }
 
 // ForecastWeather represents forecast weather data
@@ -55,8 +131,33 @@ type ForecastWeather struct {
 	WindDirection string    `json:"wind_direction"`
 	CloudCover    int       `json:"cloud_cover"`
 	Visibility    string    `json:"visibility"`
+	Precipitation float64   `json:"precipitation"`
+	Description   string    `json:"description,omitempty"` // Localized weather description
 	DateTime      time.Time `json:"datetime"`
 	LocalDateTime time.Time `json:"local_datetime"`
+	WeatherCondition
+	DerivedIndices
+}
+
+// WeatherCondition is the normalized form of the BMKG description
+type WeatherCondition struct {
+	ConditionCode int    `json:"condition_code"`
+	Condition     string `json:"condition"` // Stable key, e.g. "light_rain"
+	Severity      int    `json:"condition_severity"`
+	WMOCode       int    `json:"wmo_code"`
+	Icon          string `json:"icon"` // Day or night variant for the point's local time
+}
+
+// DerivedIndices holds indices computed from temperature, humidity and wind
+type DerivedIndices struct {
+	FeelsLike      float64 `json:"feels_like"` // Apparent temperature in °C
+	HeatIndex      float64 `json:"heat_index"` // NOAA heat index in °C
+	DewPoint       float64 `json:"dew_point"`  // °C
+	Humidex        float64 `json:"humidex"`
+	WindChill      float64 `json:"wind_chill"` // °C
+	BeaufortForce  int     `json:"beaufort_force"`
+	BeaufortDesc   string  `json:"beaufort_desc"`
+	BeaufortDescEn string  `json:"beaufort_desc_en"`
 }
 
 // RegionInfo represents region information
@@ -69,6 +170,7 @@ type RegionInfo struct {
 	Village   string   `json:"village"`
 	Latitude  *float64 `json:"latitude,omitempty"`
 	Longitude *float64 `json:"longitude,omitempty"`
+	Timezone  string   `json:"timezone,omitempty"`
 }
 
 // SearchRegionResponse represents search results for regions
@@ -84,10 +186,12 @@ type WeatherAlert struct {
 	Description string    `json:"description"`
 	Severity    string    `json:"severity"`
 	Region      string    `json:"region,omitempty"`
+	RegionName  string    `json:"region_name,omitempty"`
 	StartTime   time.Time `json:"start_time"`
 	EndTime     *time.Time `json:"end_time,omitempty"`
 	IsActive    bool      `json:"is_active"`
 	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // UserFavorite represents user's favorite region
@@ -97,4 +201,92 @@ type UserFavorite struct {
 	Region    RegionInfo `json:"region"`
 	Order     int        `json:"order"`
 	CreatedAt time.Time  `json:"created_at"`
-}
\ No newline at end of file
+}
+
+// WeatherHistoryStats summarises stored weather over one day or month
+type WeatherHistoryStats struct {
+	Period         time.Time `json:"period"` // Local start of the bucket
+	MinTemperature float64   `json:"min_temperature"`
+	MaxTemperature float64   `json:"max_temperature"`
+	AvgTemperature float64   `json:"avg_temperature"`
+	AvgHumidity    float64   `json:"avg_humidity"`
+	MaxWindSpeed   float64   `json:"max_wind_speed"`
+	AvgCloudCover  float64   `json:"avg_cloud_cover"`
+	WeatherDesc    string    `json:"weather_desc"`    // Dominant condition
+	WeatherDescEn  string    `json:"weather_desc_en"` // Dominant condition
+	ConditionCode  int       `json:"condition_code"`
+	SampleCount    int       `json:"sample_count"`
+}
+
+// WeatherHistoryResponse represents one page of stored weather history.
+// Points is filled for 3-hourly granularity, Stats for daily and monthly.
+type WeatherHistoryResponse struct {
+	Region      RegionInfo            `json:"region"`
+	Granularity string                `json:"granularity"`
+	From        time.Time             `json:"from"`
+	To          time.Time             `json:"to"`
+	Points      []ForecastWeather     `json:"points,omitempty"`
+	Stats       []WeatherHistoryStats `json:"stats,omitempty"`
+	NextCursor  string                `json:"next_cursor,omitempty"`
+	Units       *UnitsInfo            `json:"units,omitempty"`
+}
+
+// WeatherComparison aligns the forecasts of several regions on the UTC
+// timestamps they have in common. The first region is the baseline that
+// deltas are measured against.
+type WeatherComparison struct {
+	Baseline string            `json:"baseline"`
+	Timezone string            `json:"timezone"` // Zone of the baseline, which defines Daily dates
+	Regions  []RegionInfo      `json:"regions"`
+	Timeline []ComparisonPoint `json:"timeline"`
+	Daily    []ComparisonDay   `json:"daily"`
+	Units    *UnitsInfo        `json:"units,omitempty"`
+}
+
+// ComparisonPoint holds every region's forecast for one UTC timestamp
+type ComparisonPoint struct {
+	DateTime time.Time               `json:"datetime"`
+	Regions  []ComparisonRegionPoint `json:"regions"`
+}
+
+// ComparisonRegionPoint is one region's forecast at a ComparisonPoint.
+// Deltas are this region minus the baseline.
+type ComparisonRegionPoint struct {
+	Code               string    `json:"code"`
+	LocalDateTime      time.Time `json:"local_datetime"` // With the region's own UTC offset
+	Temperature        float64   `json:"temperature"`
+	Humidity           int       `json:"humidity"`
+	WindSpeed          float64   `json:"wind_speed"`
+	Precipitation      float64   `json:"precipitation"`
+	ConditionCode      int       `json:"condition_code"`
+	TemperatureDelta   float64   `json:"temperature_delta"`
+	HumidityDelta      int       `json:"humidity_delta"`
+	WindSpeedDelta     float64   `json:"wind_speed_delta"`
+	PrecipitationDelta float64   `json:"precipitation_delta"`
+}
+
+// ComparisonDay names the warmest, wettest and windiest region of a day
+type ComparisonDay struct {
+	Date             string  `json:"date"` // YYYY-MM-DD in the baseline's time zone
+	Warmest          string  `json:"warmest"`
+	MaxTemperature   float64 `json:"max_temperature"`
+	Wettest          string  `json:"wettest"`
+	MaxPrecipitation float64 `json:"max_precipitation"` // Total for the day
+	Windiest         string  `json:"windiest"`
+	MaxWindSpeed     float64 `json:"max_wind_speed"`
+}
+
+// MapLayer holds one value per synced village as parallel arrays, so a map
+// can colour thousands of villages from a small, well-compressing payload.
+// Index i of Codes, Lats, Lons and Values describes the same village.
+type MapLayer struct {
+	Region    string     `json:"region"`
+	Field     string     `json:"field"`
+	Unit      string     `json:"unit,omitempty"`
+	ValidAt   time.Time  `json:"valid_at"`
+	SyncRunID string     `json:"sync_run_id,omitempty"`
+	Codes     []string   `json:"codes"`
+	Lats      []*float64 `json:"lats"` // null while a village has no coordinates
+	Lons      []*float64 `json:"lons"`
+	Values    []float64  `json:"values"`
+}
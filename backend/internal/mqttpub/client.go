@@ -0,0 +1,93 @@
+package mqttpub
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// brokerTimeout bounds every wait on the broker
+const brokerTimeout = 10 * time.Second
+
+// Broker is what the publisher needs from an MQTT connection
+type Broker interface {
+	Publish(topic string, payload []byte) error
+	RetainedTopics(filter string, window time.Duration) ([]string, error)
+	Close()
+}
+
+// BrokerConfig locates and authenticates against the broker
+type BrokerConfig struct {
+	URL      string // e.g. tcp://broker:1883 or ssl://broker:8883
+	Username string
+	Password string
+	ClientID string
+}
+
+type client struct {
+	conn mqtt.Client
+}
+
+// Dial connects to the broker. The connection reconnects on its own after
+// it is lost.
+func Dial(cfg BrokerConfig) (Broker, error) {
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.URL).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectTimeout(brokerTimeout)
+
+	conn := mqtt.NewClient(opts)
+	if err := wait(conn.Connect()); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+	return &client{conn: conn}, nil
+}
+
+// Publish sends a retained message at QoS 1. An empty payload clears the
+// retained message of the topic.
+func (c *client) Publish(topic string, payload []byte) error {
+	return wait(c.conn.Publish(topic, 1, true, payload))
+}
+
+// RetainedTopics lists the topics matching filter that hold a retained
+// message, by subscribing for window
+func (c *client) RetainedTopics(filter string, window time.Duration) ([]string, error) {
+	var mu sync.Mutex
+	var topics []string
+	token := c.conn.Subscribe(filter, 1, func(_ mqtt.Client, msg mqtt.Message) {
+		if msg.Retained() && len(msg.Payload()) > 0 {
+			mu.Lock()
+			topics = append(topics, msg.Topic())
+			mu.Unlock()
+		}
+	})
+	if !token.WaitTimeout(brokerTimeout) {
+		return nil, fmt.Errorf("subscribe to %s timed out", filter)
+	}
+	if err := token.Error(); err != nil {
+		return nil, err
+	}
+
+	time.Sleep(window)
+	c.conn.Unsubscribe(filter).WaitTimeout(brokerTimeout)
+
+	mu.Lock()
+	defer mu.Unlock()
+	return topics, nil
+}
+
+func (c *client) Close() {
+	c.conn.Disconnect(250)
+}
+
+func wait(token mqtt.Token) error {
+	if !token.WaitTimeout(brokerTimeout) {
+		return fmt.Errorf("MQTT broker did not answer within %s", brokerTimeout)
+	}
+	return token.Error()
+}
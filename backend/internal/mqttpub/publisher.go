@@ -0,0 +1,240 @@
+// Package mqttpub publishes weather to an MQTT broker for IoT dashboards
+// and field controllers. After each sync run every synced village's current
+// weather and forecast are published as retained messages to
+//
+//	<prefix>/weather/<province>/<regency>/<village>/current
+//	<prefix>/weather/<province>/<regency>/<village>/forecast
+//
+// and each active alert to <prefix>/alerts/<region path>/<alert id>, or
+// <prefix>/alerts/national/<alert id> for an alert without a region.
+// Topics use full region codes, e.g. eamagine/weather/32/32.73/32.73.01.1001/current.
+// Only payloads that changed since they were last published are sent, and
+// the retained message of an alert that is no longer active is cleared.
+package mqttpub
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/repository"
+	"eamagineweather-backend/internal/service"
+	"eamagineweather-backend/internal/units"
+)
+
+// retainedWindow is how long the publisher listens for alert topics left
+// retained by an earlier process, so it can clear them too
+const retainedWindow = 2 * time.Second
+
+type Publisher struct {
+	broker         Broker
+	prefix         string
+	maxRegions     int
+	weatherService *service.WeatherService
+	alertService   *service.AlertService
+	regionRepo     repository.RegionRepository
+
+	mu     sync.Mutex
+	hashes map[string][sha256.Size]byte // Last payload published per topic
+	alerts map[string]bool              // Alert topics holding a retained message
+}
+
+// New creates a publisher over a connected broker. maxRegions matches the
+// sync's limit, so the same villages are published as were synced.
+func New(
+	broker Broker,
+	prefix string,
+	maxRegions int,
+	weatherService *service.WeatherService,
+	alertService *service.AlertService,
+	regionRepo repository.RegionRepository,
+) *Publisher {
+	return &Publisher{
+		broker:         broker,
+		prefix:         strings.TrimSuffix(prefix, "/"),
+		maxRegions:     maxRegions,
+		weatherService: weatherService,
+		alertService:   alertService,
+		regionRepo:     regionRepo,
+		hashes:         make(map[string][sha256.Size]byte),
+		alerts:         make(map[string]bool),
+	}
+}
+
+// Run publishes once, then after every sync run until ctx is done
+func (p *Publisher) Run(ctx context.Context) {
+	runs, unsubscribe := p.weatherService.SubscribeSyncRuns()
+	defer unsubscribe()
+
+	topics, err := p.broker.RetainedTopics(p.prefix+"/alerts/#", retainedWindow)
+	if err != nil {
+		log.Printf("Failed to list retained MQTT alert topics: %v", err)
+	}
+	p.mu.Lock()
+	for _, topic := range topics {
+		p.alerts[topic] = true
+	}
+	p.mu.Unlock()
+
+	for {
+		if err := p.PublishAll(ctx); err != nil {
+			log.Printf("MQTT publish failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-runs:
+		}
+	}
+}
+
+// PublishAll publishes the weather of every synced village and the active
+// alerts, skipping payloads that have not changed
+func (p *Publisher) PublishAll(ctx context.Context) error {
+	weatherSent, err := p.publishWeather(ctx)
+	if err != nil {
+		return err
+	}
+	alertsSent, err := p.publishAlerts(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("MQTT: published %d weather and %d alert messages", weatherSent, alertsSent)
+	return nil
+}
+
+func (p *Publisher) publishWeather(ctx context.Context) (int, error) {
+	targets, err := p.regionRepo.FindSyncTargets(ctx, p.maxRegions)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load synced villages: %w", err)
+	}
+	codes := make([]string, len(targets))
+	for i, target := range targets {
+		codes[i] = target.Code
+	}
+	found, err := p.regionRepo.FindByCodes(ctx, codes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load regions: %w", err)
+	}
+	regions := make([]*db.RegionModel, len(found))
+	for i := range found {
+		regions[i] = &found[i]
+	}
+
+	sent := 0
+	for code, weather := range p.weatherService.GetVillageWeatherBatch(ctx, regions) {
+		base := weatherTopic(p.prefix, code)
+		for suffix, response := range map[string]*models.WeatherResponse{
+			"current":  weather.Current,
+			"forecast": weather.Forecast,
+		} {
+			service.LocalizeWeather(response, units.Default)
+			ok, err := p.publishJSON(base+"/"+suffix, response)
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+func (p *Publisher) publishAlerts(ctx context.Context) (int, error) {
+	alerts, err := p.alertService.GetActiveAlerts(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	active := make(map[string]bool, len(alerts))
+	for _, alert := range alerts {
+		topic := alertTopic(p.prefix, alert)
+		active[topic] = true
+		ok, err := p.publishJSON(topic, alert)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	p.mu.Lock()
+	var ended []string
+	for topic := range p.alerts {
+		if !active[topic] {
+			ended = append(ended, topic)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, topic := range ended {
+		if err := p.broker.Publish(topic, nil); err != nil {
+			return sent, fmt.Errorf("failed to clear %s: %w", topic, err)
+		}
+		p.mu.Lock()
+		delete(p.alerts, topic)
+		delete(p.hashes, topic)
+		p.mu.Unlock()
+		sent++
+	}
+
+	p.mu.Lock()
+	for topic := range active {
+		p.alerts[topic] = true
+	}
+	p.mu.Unlock()
+	return sent, nil
+}
+
+// publishJSON publishes v to topic unless the same payload was the last
+// one published there, and reports whether it was sent
+func (p *Publisher) publishJSON(topic string, v any) (bool, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return false, err
+	}
+	hash := sha256.Sum256(payload)
+
+	p.mu.Lock()
+	unchanged := p.hashes[topic] == hash
+	p.mu.Unlock()
+	if unchanged {
+		return false, nil
+	}
+
+	if err := p.broker.Publish(topic, payload); err != nil {
+		return false, fmt.Errorf("failed to publish %s: %w", topic, err)
+	}
+	p.mu.Lock()
+	p.hashes[topic] = hash
+	p.mu.Unlock()
+	return true, nil
+}
+
+// weatherTopic is the topic root of a village: its province, regency and
+// own code, e.g. eamagine/weather/32/32.73/32.73.01.1001
+func weatherTopic(prefix, villageCode string) string {
+	ancestors := repository.RegionAncestors(villageCode)
+	return strings.Join([]string{prefix, "weather", ancestors[0], ancestors[1], villageCode}, "/")
+}
+
+// alertTopic places an alert under every code of its region, so a
+// subscriber to eamagine/alerts/32/# sees alerts anywhere in province 32
+func alertTopic(prefix string, alert models.WeatherAlert) string {
+	if alert.Region == "" {
+		return prefix + "/alerts/national/" + alert.ID
+	}
+	path := repository.RegionAncestors(alert.Region)
+	return prefix + "/alerts/" + strings.Join(path, "/") + "/" + alert.ID
+}
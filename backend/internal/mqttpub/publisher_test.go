@@ -0,0 +1,320 @@
+package mqttpub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/repository/memory"
+	"eamagineweather-backend/internal/service"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+const (
+	testPrefix  = "eamagine"
+	testVillage = "32.73.01.1001"
+)
+
+// startBroker runs an in-process MQTT broker on a free local port and
+// returns its URL
+func startBroker(t *testing.T) string {
+	t.Helper()
+	server := mochi.New(&mochi.Options{InlineClient: true})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	tcp := listeners.NewTCP(listeners.Config{ID: "test", Address: "127.0.0.1:0"})
+	if err := server.AddListener(tcp); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return "tcp://" + tcp.Address()
+}
+
+// fakeBMKG serves forecasts like the BMKG API, with a temperature the test
+// can change
+type fakeBMKG struct {
+	mu          sync.Mutex
+	temperature float64
+}
+
+func (f *fakeBMKG) setTemperature(t float64) {
+	f.mu.Lock()
+	f.temperature = t
+	f.mu.Unlock()
+}
+
+func (f *fakeBMKG) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	temperature := f.temperature
+	f.mu.Unlock()
+
+	response := models.BMKGWeatherResponse{
+		Lokasi: &models.BMKGLocation{Latitude: -6.9, Longitude: 107.6, Timezone: "Asia/Jakarta"},
+	}
+	start := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 8; i++ {
+		at := start.Add(time.Duration(3*i) * time.Hour)
+		response.Data = append(response.Data, models.BMKGWeatherData{
+			UTCDatetime:   at.Format("2006-01-02 15:04:05"),
+			LocalDatetime: at.Add(7 * time.Hour).Format("2006-01-02 15:04:05"),
+			Temperature:   temperature,
+			Humidity:      80,
+			WeatherDesc:   "Hujan Ringan",
+			WeatherDescEn: "Light Rain",
+			WindSpeed:     9,
+			WindDirection: "W",
+			CloudCover:    90,
+			Visibility:    "> 10 km",
+			Precipitation: 1.2,
+			AnalysisDate:  "2026-01-15T00:00:00",
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// countingBroker records the topics published through it
+type countingBroker struct {
+	Broker
+	mu     sync.Mutex
+	topics []string
+}
+
+func (b *countingBroker) Publish(topic string, payload []byte) error {
+	b.mu.Lock()
+	b.topics = append(b.topics, topic)
+	b.mu.Unlock()
+	return b.Broker.Publish(topic, payload)
+}
+
+func (b *countingBroker) take() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	topics := b.topics
+	b.topics = nil
+	sort.Strings(topics)
+	return topics
+}
+
+func ptr[T any](v T) *T { return &v }
+
+type fixture struct {
+	publisher *Publisher
+	broker    *countingBroker
+	bmkg      *fakeBMKG
+	url       string
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	store := memory.New()
+	store.AddRegions(db.RegionModel{InnerRegion: db.InnerRegion{
+		Code:           testVillage,
+		Name:           "Cihaurgeulis",
+		Level:          4,
+		ProvinceCode:   ptr("32"),
+		ProvinceName:   ptr("Jawa Barat"),
+		RegencyCode:    ptr("32.73"),
+		RegencyName:    ptr("Kota Bandung"),
+		DistrictCode:   ptr("32.73.01"),
+		DistrictName:   ptr("Sukasari"),
+		VillageCode:    ptr(testVillage),
+		VillageName:    ptr("Cihaurgeulis"),
+		HasWeatherData: true,
+		IsActive:       true,
+	}})
+	store.AddAlerts(
+		db.WeatherAlertModel{InnerWeatherAlert: db.InnerWeatherAlert{
+			ID:          "alert-bandung",
+			Title:       "Hujan lebat",
+			Description: "Hujan lebat di Kota Bandung",
+			Severity:    db.AlertSeverityHigh,
+			Region:      ptr("32.73"),
+			StartTime:   time.Now().Add(-time.Hour),
+			IsActive:    true,
+		}},
+		db.WeatherAlertModel{InnerWeatherAlert: db.InnerWeatherAlert{
+			ID:          "alert-national",
+			Title:       "Gelombang tinggi",
+			Description: "Gelombang tinggi di perairan selatan",
+			Severity:    db.AlertSeverityMedium,
+			StartTime:   time.Now().Add(-time.Hour),
+			IsActive:    true,
+		}},
+	)
+
+	bmkg := &fakeBMKG{temperature: 24}
+	api := httptest.NewServer(bmkg)
+	t.Cleanup(api.Close)
+
+	weatherService := service.NewWeatherService(
+		store.Weather(), store.Regions(), store.SyncRuns(),
+		service.NewBMKGService(api.URL, 100, nil), nil,
+		service.SyncPolicy{Interval: time.Hour, MaxRegions: 10},
+	)
+	alertService := service.NewAlertService(store.Alerts(), store.Regions())
+
+	url := startBroker(t)
+	conn, err := Dial(BrokerConfig{URL: url, ClientID: "publisher"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(conn.Close)
+	broker := &countingBroker{Broker: conn}
+
+	return &fixture{
+		publisher: New(broker, testPrefix+"/", 10, weatherService, alertService, store.Regions()),
+		broker:    broker,
+		bmkg:      bmkg,
+		url:       url,
+	}
+}
+
+// retained subscribes to filter with a fresh client and returns the
+// retained messages by topic once want of them have arrived
+func retained(t *testing.T, url, filter string, want int) map[string][]byte {
+	t.Helper()
+	var mu sync.Mutex
+	messages := make(map[string][]byte)
+	arrived := make(chan struct{}, 64)
+
+	conn := mqtt.NewClient(mqtt.NewClientOptions().AddBroker(url).SetClientID("reader"))
+	if err := wait(conn.Connect()); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Disconnect(0)
+	err := wait(conn.Subscribe(filter, 1, func(_ mqtt.Client, msg mqtt.Message) {
+		if !msg.Retained() {
+			return
+		}
+		mu.Lock()
+		messages[msg.Topic()] = msg.Payload()
+		mu.Unlock()
+		arrived <- struct{}{}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for received := 0; received < want; received++ {
+		select {
+		case <-arrived:
+		case <-timeout:
+			t.Fatalf("got %d retained messages on %s, want %d", received, filter, want)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return messages
+}
+
+func TestPublishAllRetainsWeather(t *testing.T) {
+	f := newFixture(t)
+	if err := f.publisher.PublishAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	base := fmt.Sprintf("%s/weather/32/32.73/%s", testPrefix, testVillage)
+	messages := retained(t, f.url, testPrefix+"/weather/#", 2)
+	if len(messages) != 2 {
+		t.Fatalf("retained weather topics %v, want current and forecast", messages)
+	}
+
+	var current models.WeatherResponse
+	if err := json.Unmarshal(messages[base+"/current"], &current); err != nil {
+		t.Fatalf("%s/current: %v", base, err)
+	}
+	if current.Current == nil || current.Current.Temperature != 24 {
+		t.Errorf("current payload %+v, want a temperature of 24", current.Current)
+	}
+	if current.Region.Code != testVillage {
+		t.Errorf("current region %q, want %q", current.Region.Code, testVillage)
+	}
+
+	var forecast models.WeatherResponse
+	if err := json.Unmarshal(messages[base+"/forecast"], &forecast); err != nil {
+		t.Fatalf("%s/forecast: %v", base, err)
+	}
+	if len(forecast.Forecast) != 8 {
+		t.Errorf("forecast has %d points, want 8", len(forecast.Forecast))
+	}
+	if len(forecast.Daily) == 0 {
+		t.Error("forecast has no daily summaries")
+	}
+}
+
+func TestPublishAllRetainsAlerts(t *testing.T) {
+	f := newFixture(t)
+	if err := f.publisher.PublishAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := retained(t, f.url, testPrefix+"/alerts/#", 2)
+	want := map[string]string{
+		testPrefix + "/alerts/32/32.73/alert-bandung":  "Hujan lebat",
+		testPrefix + "/alerts/national/alert-national": "Gelombang tinggi",
+	}
+	for topic, title := range want {
+		var alert models.WeatherAlert
+		if err := json.Unmarshal(messages[topic], &alert); err != nil {
+			t.Errorf("%s: %v", topic, err)
+			continue
+		}
+		if alert.Title != title {
+			t.Errorf("%s: title %q, want %q", topic, alert.Title, title)
+		}
+	}
+
+	// Alerts stay out of the weather tree
+	for topic := range retained(t, f.url, testPrefix+"/weather/#", 2) {
+		if _, ok := want[topic]; ok {
+			t.Errorf("alert retained under the weather tree at %s", topic)
+		}
+	}
+}
+
+func TestPublishAllSkipsUnchangedPayloads(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	if err := f.publisher.PublishAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if sent := f.broker.take(); len(sent) != 4 {
+		t.Fatalf("first publish sent %v, want current, forecast and two alerts", sent)
+	}
+
+	if err := f.publisher.PublishAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if sent := f.broker.take(); len(sent) != 0 {
+		t.Errorf("unchanged data republished to %v", sent)
+	}
+
+	f.bmkg.setTemperature(26)
+	if err := f.publisher.PublishAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	base := fmt.Sprintf("%s/weather/32/32.73/%s", testPrefix, testVillage)
+	want := []string{base + "/current", base + "/forecast"}
+	if sent := f.broker.take(); fmt.Sprint(sent) != fmt.Sprint(want) {
+		t.Errorf("after a forecast change sent %v, want %v", sent, want)
+	}
+}
@@ -0,0 +1,81 @@
+package regionimport
+
+import (
+	"context"
+	"fmt"
+
+	"eamagineweather-backend/db"
+)
+
+// moveFavoritesSQL points favorites of an old code at its successor,
+// unless the user already has the successor. $1 is the old code, $2 the new.
+const moveFavoritesSQL = `
+UPDATE user_favorites f SET "regionId" = n.id, "updatedAt" = NOW()
+FROM regions o, regions n
+WHERE o.code = $1 AND n.code = $2 AND f."regionId" = o.id
+	AND NOT EXISTS (
+		SELECT 1 FROM user_favorites g WHERE g."userId" = f."userId" AND g."regionId" = n.id
+	)`
+
+// dropRevivedAliasesSQL removes aliases whose old code is active again
+const dropRevivedAliasesSQL = `
+DELETE FROM region_aliases a
+USING regions r
+WHERE r.code = a."oldCode" AND r."isActive"`
+
+// applyDiff soft-deactivates the regions missing from the import and
+// records an alias from each moved code to its successor. Favorites follow
+// moved codes. Regions are never deleted, so weather history and links to
+// old codes keep working.
+func (im *Importer) applyDiff(ctx context.Context, report *Report) error {
+	diff := report.Diff
+
+	var inactive []string
+	for _, region := range diff.Removed {
+		inactive = append(inactive, region.Code)
+	}
+	for _, move := range diff.Moved {
+		inactive = append(inactive, move.OldCode)
+	}
+	for start := 0; start < len(inactive); start += im.opts.BatchSize {
+		end := min(start+im.opts.BatchSize, len(inactive))
+		result, err := im.db.Region.FindMany(
+			db.Region.Code.In(inactive[start:end]),
+			db.Region.IsActive.Equals(true),
+		).Update(
+			db.Region.IsActive.Set(false),
+		).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to deactivate regions: %w", err)
+		}
+		report.Deactivated += result.Count
+	}
+
+	for _, move := range diff.Moved {
+		_, err := im.db.RegionAlias.UpsertOne(db.RegionAlias.OldCode.Equals(move.OldCode)).
+			Create(
+				db.RegionAlias.OldCode.Set(move.OldCode),
+				db.RegionAlias.NewCode.Set(move.NewCode),
+				db.RegionAlias.Reason.Set("moved"),
+			).
+			Update(
+				db.RegionAlias.NewCode.Set(move.NewCode),
+				db.RegionAlias.Reason.Set("moved"),
+			).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to record alias %s -> %s: %w", move.OldCode, move.NewCode, err)
+		}
+		report.Aliased++
+
+		result, err := im.db.Prisma.ExecuteRaw(moveFavoritesSQL, move.OldCode, move.NewCode).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to move favorites of %s: %w", move.OldCode, err)
+		}
+		report.FavoritesMoved += result.Count
+	}
+
+	if _, err := im.db.Prisma.ExecuteRaw(dropRevivedAliasesSQL).Exec(ctx); err != nil {
+		return fmt.Errorf("failed to drop aliases of active regions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package regionimport
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// checkpoint records how far an import got, so an interrupted run resumes
+// after the last committed batch instead of starting over
+type checkpoint struct {
+	Digest    string `json:"digest"` // SHA-256 of the input; another file starts over
+	Line      int    `json:"line"`   // Last line of the last committed batch
+	Inserted  int    `json:"inserted"`
+	Updated   int    `json:"updated"`
+	Unchanged int    `json:"unchanged"`
+
+	// The diff against the database before the first batch, which later
+	// batches change
+	Removed []StoredRegion `json:"removed"`
+	Moved   []Move         `json:"moved"`
+}
+
+// loadCheckpoint returns nil when there is no checkpoint file
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// save writes the checkpoint through a temporary file, so a crash while
+// saving leaves the previous checkpoint intact
+func (cp *checkpoint) save(path string) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,136 @@
+package regionimport
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"eamagineweather-backend/db"
+)
+
+// StoredRegion is an active region as the database holds it
+type StoredRegion struct {
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	Level int    `json:"level"`
+}
+
+// Rename is a code whose name changed
+type Rename struct {
+	Code    string
+	OldName string
+	NewName string
+}
+
+// Move is a region that got a new code: renumbered, or moved to another
+// parent when a district or regency was split (pemekaran)
+type Move struct {
+	OldCode string `json:"old_code"`
+	NewCode string `json:"new_code"`
+	Name    string `json:"name"`
+}
+
+// Diff compares a region list with the active regions in the database
+type Diff struct {
+	Added   []Region       // Codes only in the list
+	Removed []StoredRegion // Active codes missing from the list
+	Renamed []Rename
+	Moved   []Move // Removed codes matched to an added one; in neither list above
+}
+
+const activeRegionsSQL = `SELECT code, name, level FROM regions WHERE "isActive" ORDER BY code`
+
+// loadActive returns every active region
+func loadActive(ctx context.Context, client *db.PrismaClient) ([]StoredRegion, error) {
+	var stored []StoredRegion
+	if err := client.Prisma.QueryRaw(activeRegionsSQL).Exec(ctx, &stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// ComputeDiff compares regions with the stored ones. A removed code and an
+// added code are taken to be the same region moved when they have the same
+// level and name within the same regency (villages and districts) or
+// province (regencies), and no other removed or added code there does.
+func ComputeDiff(stored []StoredRegion, regions []Region) *Diff {
+	diff := &Diff{}
+
+	storedByCode := make(map[string]StoredRegion, len(stored))
+	for _, region := range stored {
+		storedByCode[region.Code] = region
+	}
+	listed := make(map[string]bool, len(regions))
+
+	var added []Region
+	for _, region := range regions {
+		listed[region.Code] = true
+		current, ok := storedByCode[region.Code]
+		switch {
+		case !ok:
+			added = append(added, region)
+		case current.Name != region.Name:
+			diff.Renamed = append(diff.Renamed, Rename{Code: region.Code, OldName: current.Name, NewName: region.Name})
+		}
+	}
+
+	var removed []StoredRegion
+	for _, region := range stored {
+		if !listed[region.Code] {
+			removed = append(removed, region)
+		}
+	}
+
+	addedByKey := make(map[string][]int)
+	for i, region := range added {
+		key := moveKey(region.Code, region.Level, region.Name)
+		addedByKey[key] = append(addedByKey[key], i)
+	}
+	removedByKey := make(map[string][]int)
+	for i, region := range removed {
+		key := moveKey(region.Code, region.Level, region.Name)
+		removedByKey[key] = append(removedByKey[key], i)
+	}
+
+	movedAdded := make(map[int]bool)
+	movedRemoved := make(map[int]bool)
+	for key, from := range removedByKey {
+		to := addedByKey[key]
+		if len(from) != 1 || len(to) != 1 {
+			continue // Ambiguous, or no successor
+		}
+		diff.Moved = append(diff.Moved, Move{
+			OldCode: removed[from[0]].Code,
+			NewCode: added[to[0]].Code,
+			Name:    added[to[0]].Name,
+		})
+		movedRemoved[from[0]] = true
+		movedAdded[to[0]] = true
+	}
+	sort.Slice(diff.Moved, func(i, j int) bool { return diff.Moved[i].OldCode < diff.Moved[j].OldCode })
+
+	for i, region := range added {
+		if !movedAdded[i] {
+			diff.Added = append(diff.Added, region)
+		}
+	}
+	for i, region := range removed {
+		if !movedRemoved[i] {
+			diff.Removed = append(diff.Removed, region)
+		}
+	}
+	return diff
+}
+
+// moveKey groups regions that could be the same region under another code
+func moveKey(code string, level int, name string) string {
+	scope := ""
+	switch parts := strings.Split(code, "."); {
+	case level >= 3:
+		scope = strings.Join(parts[:2], ".")
+	case level == 2:
+		scope = parts[0]
+	}
+	return strings.Join([]string{scope, strconv.Itoa(level), strings.ToLower(strings.Join(strings.Fields(name), " "))}, "|")
+}
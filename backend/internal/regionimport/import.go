@@ -0,0 +1,340 @@
+package regionimport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"eamagineweather-backend/db"
+
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
+)
+
+const defaultBatchSize = 500
+
+// Options tune an import
+type Options struct {
+	BatchSize  int    // Rows upserted per transaction
+	Checkpoint string // Checkpoint file for resuming; empty disables it
+	DryRun     bool   // Classify rows against the database without writing
+
+	// DeactivateMissing marks active regions missing from the input as
+	// inactive and records aliases for moved codes. Leave it off when
+	// importing part of the country.
+	DeactivateMissing bool
+}
+
+// Report counts what an import did with each row
+type Report struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+	Invalid   []Invalid // Rows left out, with why
+	Warnings  []Problem // Rows imported that should be looked at
+	Resumed   bool // Started after the batches of an earlier, interrupted run
+
+	Diff           *Diff // Against the database before the import
+	Deactivated    int
+	Aliased        int
+	FavoritesMoved int
+}
+
+type Importer struct {
+	db   *db.PrismaClient
+	opts Options
+}
+
+func New(client *db.PrismaClient, opts Options) *Importer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	return &Importer{db: client, opts: opts}
+}
+
+// Import upserts every valid row of the CSV at path. Each batch is written
+// in one transaction, and rows that already match the database are not
+// written at all, so running the same file twice changes nothing. After
+// each batch the checkpoint is saved; it is removed once the import
+// completes.
+func (im *Importer) Import(ctx context.Context, path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	rows, invalid, err := ReadCSV(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	stored, err := loadActive(ctx, im.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load active regions: %w", err)
+	}
+
+	report := &Report{Invalid: invalid}
+	regions := prepare(rows, stored, report)
+
+	cp := &checkpoint{Digest: digest}
+	if im.opts.Checkpoint != "" && !im.opts.DryRun {
+		saved, err := loadCheckpoint(im.opts.Checkpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+		}
+		if saved != nil && saved.Digest == digest {
+			cp = saved
+			report.Resumed = true
+			report.Inserted, report.Updated, report.Unchanged = saved.Inserted, saved.Updated, saved.Unchanged
+			report.Diff = &Diff{Removed: saved.Removed, Moved: saved.Moved}
+			log.Printf("Resuming import after line %d", saved.Line)
+		} else if saved != nil {
+			log.Printf("Checkpoint %s is for a different input, starting over", im.opts.Checkpoint)
+		}
+	}
+
+	if report.Diff == nil {
+		report.Diff = diff(stored, regions)
+		cp.Removed, cp.Moved = report.Diff.Removed, report.Diff.Moved
+	}
+
+	var pending []lineRegion
+	for _, region := range regions {
+		if region.line > cp.Line {
+			pending = append(pending, region)
+		}
+	}
+
+	for start := 0; start < len(pending); start += im.opts.BatchSize {
+		end := min(start+im.opts.BatchSize, len(pending))
+		batch := pending[start:end]
+		if err := im.importBatch(ctx, batch, report); err != nil {
+			return report, fmt.Errorf("batch ending at line %d: %w", batch[len(batch)-1].line, err)
+		}
+		log.Printf("Imported up to line %d (%d inserted, %d updated, %d unchanged)",
+			batch[len(batch)-1].line, report.Inserted, report.Updated, report.Unchanged)
+
+		if im.opts.Checkpoint != "" && !im.opts.DryRun {
+			cp.Line = batch[len(batch)-1].line
+			cp.Inserted, cp.Updated, cp.Unchanged = report.Inserted, report.Updated, report.Unchanged
+			if err := cp.save(im.opts.Checkpoint); err != nil {
+				return report, fmt.Errorf("failed to save checkpoint: %w", err)
+			}
+		}
+	}
+
+	if im.opts.DeactivateMissing && !im.opts.DryRun {
+		if err := im.applyDiff(ctx, report); err != nil {
+			return report, err
+		}
+	}
+
+	if im.opts.Checkpoint != "" && !im.opts.DryRun {
+		if err := os.Remove(im.opts.Checkpoint); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove checkpoint: %v", err)
+		}
+	}
+	return report, nil
+}
+
+// Diff compares the CSV at path with the database without writing
+func (im *Importer) Diff(ctx context.Context, path string) (*Diff, []Invalid, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, invalid, err := ReadCSV(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	stored, err := loadActive(ctx, im.db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load active regions: %w", err)
+	}
+	report := &Report{Invalid: invalid}
+	return diff(stored, prepare(rows, stored, report)), report.Invalid, nil
+}
+
+// Load reads the CSV at path and returns its valid regions for storage
+// backends that write them themselves, such as SQLite. The report lists
+// the rows left out and the warnings.
+func Load(path string) ([]Region, *Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, invalid, err := ReadCSV(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	report := &Report{Invalid: invalid}
+	prepared := prepare(rows, nil, report)
+	regions := make([]Region, len(prepared))
+	for i, region := range prepared {
+		regions[i] = region.Region
+	}
+	return regions, report, nil
+}
+
+func diff(stored []StoredRegion, regions []lineRegion) *Diff {
+	list := make([]Region, len(regions))
+	for i, region := range regions {
+		list[i] = region.Region
+	}
+	return ComputeDiff(stored, list)
+}
+
+type lineRegion struct {
+	line int
+	Region
+}
+
+// prepare validates the rows against each other and the stored regions,
+// records the problems in the report, and places the rows without errors
+// in the hierarchy
+func prepare(rows []Row, stored []StoredRegion, report *Report) []lineRegion {
+	existing := make(map[string]bool, len(stored))
+	for _, region := range stored {
+		existing[region.Code] = true
+	}
+
+	rejected := make(map[int]bool)
+	for _, problem := range Validate(rows, existing) {
+		if problem.Severity == SeverityError {
+			rejected[problem.Line] = true
+			report.Invalid = append(report.Invalid, Invalid{Line: problem.Line, Code: problem.Code, Reason: problem.Message})
+		} else {
+			report.Warnings = append(report.Warnings, problem)
+		}
+	}
+
+	names := make(map[string]string, len(rows))
+	var valid []Row
+	for _, row := range rows {
+		if rejected[row.Line] {
+			continue
+		}
+		row.Code = strings.TrimSpace(row.Code)
+		row.Name = strings.TrimSpace(row.Name)
+		names[row.Code] = row.Name
+		valid = append(valid, row)
+	}
+
+	regions := make([]lineRegion, len(valid))
+	for i, row := range valid {
+		regions[i] = lineRegion{line: row.Line, Region: Parse(row, names)}
+	}
+	return regions
+}
+
+// importBatch compares a batch with the stored regions and upserts the new
+// and changed ones in one transaction
+func (im *Importer) importBatch(ctx context.Context, batch []lineRegion, report *Report) error {
+	codes := make([]string, len(batch))
+	for i, region := range batch {
+		codes[i] = region.Code
+	}
+	existing, err := im.db.Region.FindMany(db.Region.Code.In(codes)).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load existing regions: %w", err)
+	}
+	stored := make(map[string]*db.RegionModel, len(existing))
+	for i := range existing {
+		stored[existing[i].Code] = &existing[i]
+	}
+
+	var ops []transaction.Transaction
+	inserted, updated, unchanged := 0, 0, 0
+	for _, region := range batch {
+		current, ok := stored[region.Code]
+		switch {
+		case !ok:
+			inserted++
+		case matches(current, region.Region):
+			unchanged++
+			continue
+		default:
+			updated++
+		}
+		ops = append(ops, upsert(im.db, region.Region).Tx())
+	}
+
+	if len(ops) > 0 && !im.opts.DryRun {
+		if err := im.db.Prisma.Transaction(ops...).Exec(ctx); err != nil {
+			return err
+		}
+	}
+	report.Inserted += inserted
+	report.Updated += updated
+	report.Unchanged += unchanged
+	return nil
+}
+
+// upsert writes a region. Only villages get weather from BMKG; new ones are
+// taken to have it until the availability probe checks them, and an update
+// leaves the flag to the probe.
+func upsert(client *db.PrismaClient, region Region) db.RegionUpsertOne {
+	fields := []db.RegionSetParam{
+		db.Region.ProvinceCode.SetIfPresent(region.ProvinceCode),
+		db.Region.ProvinceName.SetIfPresent(region.ProvinceName),
+		db.Region.RegencyCode.SetIfPresent(region.RegencyCode),
+		db.Region.RegencyName.SetIfPresent(region.RegencyName),
+		db.Region.DistrictCode.SetIfPresent(region.DistrictCode),
+		db.Region.DistrictName.SetIfPresent(region.DistrictName),
+		db.Region.VillageCode.SetIfPresent(region.VillageCode),
+		db.Region.VillageName.SetIfPresent(region.VillageName),
+		db.Region.IsActive.Set(true),
+	}
+	create := append(fields, db.Region.HasWeatherData.Set(region.Level == 4))
+	update := append([]db.RegionSetParam{
+		db.Region.Name.Set(region.Name),
+		db.Region.Level.Set(region.Level),
+	}, fields...)
+
+	return client.Region.UpsertOne(db.Region.Code.Equals(region.Code)).
+		Create(
+			db.Region.Code.Set(region.Code),
+			db.Region.Name.Set(region.Name),
+			db.Region.Level.Set(region.Level),
+			create...,
+		).
+		Update(update...)
+}
+
+// matches reports whether a stored region already holds what the import
+// would write
+func matches(stored *db.RegionModel, region Region) bool {
+	if stored.Name != region.Name || stored.Level != region.Level || !stored.IsActive {
+		return false
+	}
+	pairs := []struct {
+		want *string
+		get  func() (string, bool)
+	}{
+		{region.ProvinceCode, stored.ProvinceCode},
+		{region.ProvinceName, stored.ProvinceName},
+		{region.RegencyCode, stored.RegencyCode},
+		{region.RegencyName, stored.RegencyName},
+		{region.DistrictCode, stored.DistrictCode},
+		{region.DistrictName, stored.DistrictName},
+		{region.VillageCode, stored.VillageCode},
+		{region.VillageName, stored.VillageName},
+	}
+	for _, pair := range pairs {
+		got, ok := pair.get()
+		if pair.want == nil {
+			// SetIfPresent leaves a missing value alone, so anything stored stays
+			continue
+		}
+		if !ok || got != *pair.want {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+// Package regionimport loads the Kemendagri region list (indonesian_regions.csv)
+// into the regions table. Rows are upserted in transactional batches, so an
+// import can be repeated or resumed from a checkpoint without duplicating or
+// losing rows.
+package regionimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// codePattern matches province (32), regency (32.73), district (32.73.01)
+// and village (32.73.01.1001) codes
+var codePattern = regexp.MustCompile(`^\d{2}(\.\d{2}(\.\d{2}(\.\d{4})?)?)?$`)
+
+// Row is one line of the CSV
+type Row struct {
+	Line int
+	Code string
+	Name string
+}
+
+// Region is a row with its place in the hierarchy worked out
+type Region struct {
+	Code         string
+	Name         string
+	Level        int
+	ProvinceCode *string
+	ProvinceName *string
+	RegencyCode  *string
+	RegencyName  *string
+	DistrictCode *string
+	DistrictName *string
+	VillageCode  *string
+	VillageName  *string
+}
+
+// Invalid is a row that cannot be imported
+type Invalid struct {
+	Line   int
+	Code   string
+	Reason string
+}
+
+// ReadCSV reads code,name rows as they are written; Validate checks them.
+// Lines that cannot be read as two fields are returned as invalid rather
+// than stopping the read.
+func ReadCSV(r io.Reader) ([]Row, []Invalid, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var rows []Row
+	var invalid []Invalid
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				invalid = append(invalid, Invalid{Line: parseErr.StartLine, Reason: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, err
+		}
+		if len(record) != 2 {
+			invalid = append(invalid, Invalid{Line: line, Reason: fmt.Sprintf("expected 2 fields, got %d", len(record))})
+			continue
+		}
+		rows = append(rows, Row{Line: line, Code: record[0], Name: record[1]})
+	}
+	return rows, invalid, nil
+}
+
+// Parse places a valid, trimmed row in the hierarchy by its code. names resolves the
+// names of its province, regency and district; ancestors missing from it
+// keep their code but get no name.
+func Parse(row Row, names map[string]string) Region {
+	parts := strings.Split(row.Code, ".")
+	region := Region{
+		Code:  row.Code,
+		Name:  row.Name,
+		Level: len(parts),
+	}
+
+	ancestor := func(n int) (*string, *string) {
+		code := strings.Join(parts[:n], ".")
+		if n == len(parts) {
+			name := row.Name
+			return &code, &name
+		}
+		if name, ok := names[code]; ok {
+			return &code, &name
+		}
+		return &code, nil
+	}
+
+	region.ProvinceCode, region.ProvinceName = ancestor(1)
+	if region.Level >= 2 {
+		region.RegencyCode, region.RegencyName = ancestor(2)
+	}
+	if region.Level >= 3 {
+		region.DistrictCode, region.DistrictName = ancestor(3)
+	}
+	if region.Level == 4 {
+		region.VillageCode, region.VillageName = ancestor(4)
+	}
+	return region
+}
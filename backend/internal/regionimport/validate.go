@@ -0,0 +1,132 @@
+package regionimport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Severity says whether a problem keeps a row out of the import
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The row is not imported
+	SeverityWarning Severity = "warning" // The row is imported, but should be looked at
+)
+
+// Problem is something wrong with one row of the CSV
+type Problem struct {
+	Line     int      `json:"line"`
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Validate checks the structure and hierarchy of a region list:
+//   - codes must look like 32, 32.73, 32.73.01 or 32.73.01.1001 and names
+//     must not be empty
+//   - each code may appear once; a repeat with another name is reported as
+//     a conflict, since children would get inconsistent parent names
+//   - every code's parent (32.73.01 for 32.73.01.1001) must be in the list
+//     or in existing, which may be nil
+//
+// Names with surrounding or repeated whitespace, unexpected characters, or
+// all in capitals below regency level are warnings. Problems are returned
+// in line order.
+func Validate(rows []Row, existing map[string]bool) []Problem {
+	var problems []Problem
+	report := func(row Row, severity Severity, format string, args ...any) {
+		problems = append(problems, Problem{
+			Line:     row.Line,
+			Code:     row.Code,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	first := make(map[string]Row, len(rows))
+	for _, row := range rows {
+		code := strings.TrimSpace(row.Code)
+		name := strings.TrimSpace(row.Name)
+		switch {
+		case code == "":
+			report(row, SeverityError, "empty code")
+			continue
+		case !codePattern.MatchString(code):
+			report(row, SeverityError, "malformed code %q", row.Code)
+			continue
+		case name == "":
+			report(row, SeverityError, "empty name")
+			continue
+		}
+
+		if earlier, ok := first[code]; ok {
+			if strings.TrimSpace(earlier.Name) == name {
+				report(row, SeverityError, "duplicate of line %d", earlier.Line)
+			} else {
+				report(row, SeverityError, "conflicts with line %d: %q vs %q", earlier.Line, strings.TrimSpace(earlier.Name), name)
+			}
+			continue
+		}
+		first[code] = row
+
+		if code != row.Code {
+			report(row, SeverityWarning, "code has surrounding whitespace")
+		}
+		checkName(row, regionLevel(code), func(message string) {
+			report(row, SeverityWarning, "%s", message)
+		})
+	}
+
+	for _, row := range rows {
+		code := strings.TrimSpace(row.Code)
+		if first[code].Line != row.Line || !strings.Contains(code, ".") {
+			continue
+		}
+		parent := code[:strings.LastIndex(code, ".")]
+		if _, ok := first[parent]; !ok && !existing[parent] {
+			report(row, SeverityError, "parent %s is missing", parent)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems
+}
+
+// checkName warns about names that were probably mangled on the way in
+func checkName(row Row, level int, warn func(string)) {
+	name := row.Name
+	if name != strings.TrimSpace(name) {
+		warn("name has leading or trailing whitespace")
+	}
+	if strings.Contains(strings.TrimSpace(name), "  ") {
+		warn("name has repeated spaces")
+	}
+	for _, r := range name {
+		if r == unicode.ReplacementChar || (unicode.IsControl(r) && r != ' ') {
+			warn(fmt.Sprintf("name has unexpected character %U", r))
+			break
+		}
+	}
+	// Kemendagri writes provinces and regencies in capitals, districts and
+	// villages in title case
+	if level >= 3 && strings.IndexFunc(name, unicode.IsLetter) >= 0 && name == strings.ToUpper(name) && len(strings.TrimSpace(name)) > 3 {
+		warn("name is all capitals")
+	}
+}
+
+func regionLevel(code string) int {
+	return strings.Count(code, ".") + 1
+}
+
+// Errors counts the problems that keep rows out of an import
+func Errors(problems []Problem) int {
+	n := 0
+	for _, problem := range problems {
+		if problem.Severity == SeverityError {
+			n++
+		}
+	}
+	return n
+}
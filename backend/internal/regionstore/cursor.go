@@ -0,0 +1,93 @@
+package regionstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned for a page cursor no store handed out
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the sort key of the last region on a page. Listings by name
+// order on Name then Code; search orders on HasWeatherData (true first),
+// Level (descending), Name and Code.
+type Cursor struct {
+	HasWeatherData bool   `json:"w,omitempty"`
+	Level          int    `json:"l"`
+	Name           string `json:"n"`
+	Code           string `json:"c"`
+}
+
+// CursorAfter returns the cursor of the page ending with region
+func CursorAfter(region Region) Cursor {
+	return Cursor{
+		HasWeatherData: region.HasWeatherData,
+		Level:          region.Level,
+		Name:           region.Name,
+		Code:           region.Code,
+	}
+}
+
+// String encodes the cursor for a NextCursor
+func (c Cursor) String() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// ParseCursor decodes a page cursor; ok is false for an empty one
+func ParseCursor(s string) (cursor Cursor, ok bool, err error) {
+	if s == "" {
+		return Cursor{}, false, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, false, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Code == "" {
+		return Cursor{}, false, ErrInvalidCursor
+	}
+	return cursor, true, nil
+}
+
+// NewList makes a page from regions fetched with one more than the page
+// limit, the extra one showing that another page follows
+func NewList(regions []Region, total int, page Page) List {
+	list := List{Regions: regions, Total: total}
+	if page.Limit > 0 && len(regions) > page.Limit {
+		list.Regions = regions[:page.Limit]
+		list.NextCursor = CursorAfter(list.Regions[page.Limit-1]).String()
+	}
+	if list.Regions == nil {
+		list.Regions = []Region{}
+	}
+	return list
+}
+
+// FetchLimit is how many regions to fetch for the page, one more than its
+// limit, or 0 for all
+func (p Page) FetchLimit() int {
+	if p.Limit <= 0 {
+		return 0
+	}
+	return p.Limit + 1
+}
+
+// byName reports whether a comes before b in a listing by name
+func byName(a, b Cursor) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.Code < b.Code
+}
+
+// bySearchRank reports whether a comes before b in search results
+func bySearchRank(a, b Cursor) bool {
+	if a.HasWeatherData != b.HasWeatherData {
+		return a.HasWeatherData
+	}
+	if a.Level != b.Level {
+		return a.Level > b.Level
+	}
+	return byName(a, b)
+}
@@ -0,0 +1,7 @@
+//go:build !embedregions
+
+package regionstore
+
+// embeddedCSV is only compiled in with the embedregions build tag, so
+// binaries that always have a database do not carry the dataset
+var embeddedCSV []byte
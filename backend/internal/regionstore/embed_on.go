@@ -0,0 +1,8 @@
+//go:build embedregions
+
+package regionstore
+
+import _ "embed"
+
+//go:embed regions.csv.gz
+var embeddedCSV []byte
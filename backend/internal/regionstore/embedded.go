@@ -0,0 +1,79 @@
+package regionstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"strings"
+
+	"eamagineweather-backend/internal/regionimport"
+)
+
+//go:generate go run ../../scripts/embedregions -in ../../../indonesian_regions.csv -out regions.csv.gz
+
+// ErrNoEmbeddedData is returned by LoadEmbedded from binaries built without
+// the embedregions tag
+var ErrNoEmbeddedData = errors.New("binary was built without the embedded region dataset (go build -tags embedregions)")
+
+// LoadEmbedded returns a store over the region dataset compiled into the
+// binary. Rows the importer would reject are left out, and every village
+// is taken to have weather data, as the importer assumes before probing.
+func LoadEmbedded() (Store, error) {
+	if embeddedCSV == nil {
+		return nil, ErrNoEmbeddedData
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(embeddedCSV))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded regions: %w", err)
+	}
+	rows, _, err := regionimport.ReadCSV(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded regions: %w", err)
+	}
+
+	rejected := make(map[int]bool)
+	for _, problem := range regionimport.Validate(rows, nil) {
+		if problem.Severity == regionimport.SeverityError {
+			rejected[problem.Line] = true
+		}
+	}
+	names := make(map[string]string, len(rows))
+	var valid []regionimport.Row
+	for _, row := range rows {
+		if rejected[row.Line] {
+			continue
+		}
+		row.Code = strings.TrimSpace(row.Code)
+		row.Name = strings.TrimSpace(row.Name)
+		names[row.Code] = row.Name
+		valid = append(valid, row)
+	}
+
+	regions := make([]Region, len(valid))
+	for i, row := range valid {
+		parsed := regionimport.Parse(row, names)
+		regions[i] = Region{
+			ID:             parsed.Code, // There is no database id; the code is unique too
+			Code:           parsed.Code,
+			Name:           parsed.Name,
+			Level:          parsed.Level,
+			ProvinceCode:   value(parsed.ProvinceCode),
+			ProvinceName:   value(parsed.ProvinceName),
+			RegencyCode:    value(parsed.RegencyCode),
+			RegencyName:    value(parsed.RegencyName),
+			DistrictCode:   value(parsed.DistrictCode),
+			DistrictName:   value(parsed.DistrictName),
+			VillageName:    value(parsed.VillageName),
+			HasWeatherData: parsed.Level == 4,
+		}
+	}
+	return NewMemory(regions), nil
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,131 @@
+package regionstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type memoryStore struct {
+	regions  []Region         // In search order: weather data first, then by level descending, name and code
+	children map[string][]int // Parent code to child indexes, by name and code
+	villages map[string][]int // Regency code to villages with weather data, by name and code
+	version  Version          // Hash of the regions; the list never changes
+}
+
+// NewMemory returns a store over a fixed list of regions
+func NewMemory(regions []Region) Store {
+	sorted := make([]Region, len(regions))
+	copy(sorted, regions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bySearchRank(CursorAfter(sorted[i]), CursorAfter(sorted[j]))
+	})
+
+	s := &memoryStore{
+		regions:  sorted,
+		children: make(map[string][]int),
+		villages: make(map[string][]int),
+	}
+	for i, region := range sorted {
+		parent := ""
+		if dot := strings.LastIndex(region.Code, "."); dot >= 0 {
+			parent = region.Code[:dot]
+		}
+		s.children[parent] = append(s.children[parent], i)
+		if region.Level == 4 && region.HasWeatherData {
+			s.villages[region.RegencyCode] = append(s.villages[region.RegencyCode], i)
+		}
+	}
+	byName := func(indexes []int) {
+		sort.Slice(indexes, func(i, j int) bool {
+			return byName(CursorAfter(sorted[indexes[i]]), CursorAfter(sorted[indexes[j]]))
+		})
+	}
+	for _, indexes := range s.children {
+		byName(indexes)
+	}
+	for _, indexes := range s.villages {
+		byName(indexes)
+	}
+
+	sum := sha256.New()
+	for _, region := range sorted {
+		fmt.Fprintf(sum, "%+v\n", region)
+	}
+	s.version.Tag = hex.EncodeToString(sum.Sum(nil)[:16])
+	return s
+}
+
+func (s *memoryStore) Provinces(ctx context.Context, page Page) (List, error) {
+	return s.list(s.children[""], page)
+}
+
+func (s *memoryStore) Regencies(ctx context.Context, provinceCode string, page Page) (List, error) {
+	return s.list(s.children[provinceCode], page)
+}
+
+func (s *memoryStore) Villages(ctx context.Context, regencyCode string, page Page) (List, error) {
+	return s.list(s.villages[regencyCode], page)
+}
+
+func (s *memoryStore) Search(ctx context.Context, query string, level int, page Page) (List, error) {
+	after, ok, err := ParseCursor(page.Cursor)
+	if err != nil {
+		return List{}, err
+	}
+	start := 0
+	if ok {
+		start = sort.Search(len(s.regions), func(i int) bool {
+			return bySearchRank(after, CursorAfter(s.regions[i]))
+		})
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	limit := page.FetchLimit()
+	var found []Region
+	total := 0
+	for i, region := range s.regions {
+		if level > 0 && region.Level != level {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(region.Name), query) {
+			continue
+		}
+		total++
+		if i >= start && (limit == 0 || len(found) < limit) {
+			found = append(found, region)
+		}
+	}
+	return NewList(found, total, page), nil
+}
+
+func (s *memoryStore) Version(ctx context.Context) (Version, error) {
+	return s.version, nil
+}
+
+// list pages through indexes ordered by name
+func (s *memoryStore) list(indexes []int, page Page) (List, error) {
+	after, ok, err := ParseCursor(page.Cursor)
+	if err != nil {
+		return List{}, err
+	}
+	start := 0
+	if ok {
+		start = sort.Search(len(indexes), func(i int) bool {
+			return byName(after, CursorAfter(s.regions[indexes[i]]))
+		})
+	}
+	end := len(indexes)
+	if limit := page.FetchLimit(); limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	regions := make([]Region, 0, end-start)
+	for _, index := range indexes[start:end] {
+		regions = append(regions, s.regions[index])
+	}
+	return NewList(regions, len(indexes), page), nil
+}
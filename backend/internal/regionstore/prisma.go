@@ -0,0 +1,194 @@
+package regionstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+)
+
+type prismaStore struct {
+	db *db.PrismaClient
+}
+
+// NewPrisma returns a store reading the regions table
+func NewPrisma(client *db.PrismaClient) Store {
+	return &prismaStore{db: client}
+}
+
+// Counts use raw SQL; the filters match the FindMany conditions below
+const (
+	provinceCountSQL = `SELECT COUNT(*)::int AS count FROM regions WHERE level = 1 AND "isActive"`
+	regencyCountSQL  = `SELECT COUNT(*)::int AS count FROM regions WHERE level = 2 AND "provinceCode" = $1 AND "isActive"`
+	villageCountSQL  = `
+SELECT COUNT(*)::int AS count FROM regions
+WHERE level = 4 AND "regencyCode" = $1 AND "hasWeatherData" AND "isActive"`
+	searchCountSQL = `
+SELECT COUNT(*)::int AS count FROM regions
+WHERE "isActive" AND strpos(name, $1) > 0 AND ($2 = 0 OR level = $2)`
+)
+
+// versionSQL summarises the regions table. Every change sets updatedAt,
+// deactivation included; the count catches deleted rows.
+const versionSQL = `SELECT COUNT(*)::int AS count, MAX("updatedAt") AS modified FROM regions`
+
+func (s *prismaStore) Provinces(ctx context.Context, page Page) (List, error) {
+	return s.listByName(ctx, page, []db.RegionWhereParam{
+		db.Region.Level.Equals(1),
+		db.Region.IsActive.Equals(true),
+	}, provinceCountSQL)
+}
+
+func (s *prismaStore) Regencies(ctx context.Context, provinceCode string, page Page) (List, error) {
+	return s.listByName(ctx, page, []db.RegionWhereParam{
+		db.Region.Level.Equals(2),
+		db.Region.ProvinceCode.Equals(provinceCode),
+		db.Region.IsActive.Equals(true),
+	}, regencyCountSQL, provinceCode)
+}
+
+func (s *prismaStore) Villages(ctx context.Context, regencyCode string, page Page) (List, error) {
+	return s.listByName(ctx, page, []db.RegionWhereParam{
+		db.Region.Level.Equals(4),
+		db.Region.RegencyCode.Equals(regencyCode),
+		db.Region.HasWeatherData.Equals(true),
+		db.Region.IsActive.Equals(true),
+	}, villageCountSQL, regencyCode)
+}
+
+func (s *prismaStore) Search(ctx context.Context, query string, level int, page Page) (List, error) {
+	after, ok, err := ParseCursor(page.Cursor)
+	if err != nil {
+		return List{}, err
+	}
+
+	// Names are stored in title case, so the query is title-cased too
+	name := strings.Title(strings.ToLower(strings.TrimSpace(query)))
+	conditions := []db.RegionWhereParam{
+		db.Region.IsActive.Equals(true),
+		db.Region.Name.Contains(name),
+	}
+	if level > 0 {
+		conditions = append(conditions, db.Region.Level.Equals(level))
+	}
+	if ok {
+		// Later in the ranking: without weather data after with it, then
+		// a lower level, then by name and code
+		later := []db.RegionWhereParam{
+			db.Region.And(
+				db.Region.HasWeatherData.Equals(after.HasWeatherData),
+				db.Region.Level.Lt(after.Level),
+			),
+			db.Region.And(
+				db.Region.HasWeatherData.Equals(after.HasWeatherData),
+				db.Region.Level.Equals(after.Level),
+				afterName(after),
+			),
+		}
+		if after.HasWeatherData {
+			later = append(later, db.Region.HasWeatherData.Equals(false))
+		}
+		conditions = append(conditions, db.Region.Or(later...))
+	}
+
+	return s.list(ctx, page, conditions, []db.RegionOrderByParam{
+		db.Region.HasWeatherData.Order(db.SortOrderDesc),
+		db.Region.Level.Order(db.SortOrderDesc),
+		db.Region.Name.Order(db.SortOrderAsc),
+		db.Region.Code.Order(db.SortOrderAsc),
+	}, searchCountSQL, name, level)
+}
+
+func (s *prismaStore) Version(ctx context.Context) (Version, error) {
+	var result []struct {
+		Count    int        `json:"count"`
+		Modified *time.Time `json:"modified"`
+	}
+	if err := s.db.Prisma.QueryRaw(versionSQL).Exec(ctx, &result); err != nil {
+		return Version{}, err
+	}
+	if len(result) == 0 || result[0].Modified == nil {
+		return Version{Tag: "empty"}, nil
+	}
+	modified := *result[0].Modified
+	return Version{
+		Tag:      fmt.Sprintf("%d-%d", result[0].Count, modified.UnixMilli()),
+		Modified: modified,
+	}, nil
+}
+
+// listByName pages through the regions matching conditions by name
+func (s *prismaStore) listByName(ctx context.Context, page Page, conditions []db.RegionWhereParam, countSQL string, countArgs ...interface{}) (List, error) {
+	after, ok, err := ParseCursor(page.Cursor)
+	if err != nil {
+		return List{}, err
+	}
+	if ok {
+		conditions = append(conditions, afterName(after))
+	}
+	return s.list(ctx, page, conditions, []db.RegionOrderByParam{
+		db.Region.Name.Order(db.SortOrderAsc),
+		db.Region.Code.Order(db.SortOrderAsc),
+	}, countSQL, countArgs...)
+}
+
+// afterName matches regions after the cursor by name, then code for
+// regions sharing a name
+func afterName(after Cursor) db.RegionWhereParam {
+	return db.Region.Or(
+		db.Region.Name.Gt(after.Name),
+		db.Region.And(
+			db.Region.Name.Equals(after.Name),
+			db.Region.Code.Gt(after.Code),
+		),
+	)
+}
+
+// list fetches a page of regions and counts the whole listing with countSQL
+func (s *prismaStore) list(ctx context.Context, page Page, conditions []db.RegionWhereParam, order []db.RegionOrderByParam, countSQL string, countArgs ...interface{}) (List, error) {
+	query := s.db.Region.FindMany(conditions...).OrderBy(order...)
+	if limit := page.FetchLimit(); limit > 0 {
+		query = query.Take(limit)
+	}
+	regions, err := query.Exec(ctx)
+	if err != nil {
+		return List{}, err
+	}
+
+	var count []struct {
+		Count int `json:"count"`
+	}
+	if err := s.db.Prisma.QueryRaw(countSQL, countArgs...).Exec(ctx, &count); err != nil {
+		return List{}, err
+	}
+	total := 0
+	if len(count) > 0 {
+		total = count[0].Count
+	}
+	return NewList(FromModels(regions), total, page), nil
+}
+
+// FromModels converts region models, from Prisma or the SQLite repository
+func FromModels(models []db.RegionModel) []Region {
+	regions := make([]Region, len(models))
+	for i, model := range models {
+		region := Region{
+			ID:             model.ID,
+			Code:           model.Code,
+			Name:           model.Name,
+			Level:          model.Level,
+			HasWeatherData: model.HasWeatherData,
+		}
+		region.ProvinceCode, _ = model.ProvinceCode()
+		region.ProvinceName, _ = model.ProvinceName()
+		region.RegencyCode, _ = model.RegencyCode()
+		region.RegencyName, _ = model.RegencyName()
+		region.DistrictCode, _ = model.DistrictCode()
+		region.DistrictName, _ = model.DistrictName()
+		region.VillageName, _ = model.VillageName()
+		regions[i] = region
+	}
+	return regions
+}
@@ -0,0 +1,65 @@
+// Package regionstore serves the region hierarchy behind the /regions
+// endpoints, either from the database or from a dataset held in memory, so
+// those endpoints work without Postgres in development and at the edge.
+package regionstore
+
+import (
+	"context"
+	"time"
+)
+
+// Region is one entry of the hierarchy. Names of levels above the region
+// are empty when unknown.
+type Region struct {
+	ID             string
+	Code           string
+	Name           string
+	Level          int // 1=Province, 2=Regency/City, 3=District, 4=Village
+	ProvinceCode   string
+	ProvinceName   string
+	RegencyCode    string
+	RegencyName    string
+	DistrictCode   string
+	DistrictName   string
+	VillageName    string
+	HasWeatherData bool
+}
+
+// Page selects part of a listing: at most Limit regions after Cursor, the
+// NextCursor of the previous page. An empty cursor starts at the beginning
+// and a limit of 0 takes every region.
+type Page struct {
+	Cursor string
+	Limit  int
+}
+
+// List is one page of a listing
+type List struct {
+	Regions    []Region
+	Total      int    // Regions in the whole listing
+	NextCursor string // Empty on the last page
+}
+
+// Version identifies the stored region dataset: Tag changes whenever any
+// region does, and Modified is when one last did (zero when unknown)
+type Version struct {
+	Tag      string
+	Modified time.Time
+}
+
+// Store lists active regions. Listings are paged on the last region's sort
+// key rather than an offset, so later pages cost the same as the first; an
+// unreadable cursor fails with ErrInvalidCursor.
+type Store interface {
+	// Provinces lists the provinces by name
+	Provinces(ctx context.Context, page Page) (List, error)
+	// Regencies lists the regencies and cities of a province by name
+	Regencies(ctx context.Context, provinceCode string, page Page) (List, error)
+	// Villages lists a regency's villages with weather data by name
+	Villages(ctx context.Context, regencyCode string, page Page) (List, error)
+	// Search lists regions whose name contains query, optionally at one
+	// level (0 for all). Villages with weather data come first.
+	Search(ctx context.Context, query string, level int, page Page) (List, error)
+	// Version returns the version of the dataset, for HTTP validators
+	Version(ctx context.Context) (Version, error)
+}
@@ -0,0 +1,67 @@
+// Package regiontz resolves the Indonesian time zone (WIB, WITA or WIT) of a
+// region. Indonesia does not observe daylight saving time, so fixed zones are
+// exact and work without a tzdata installation.
+package regiontz
+
+import (
+	"strings"
+	"time"
+)
+
+var (
+	WIB  = time.FixedZone("WIB", 7*60*60)
+	WITA = time.FixedZone("WITA", 8*60*60)
+	WIT  = time.FixedZone("WIT", 9*60*60)
+)
+
+// IANA names BMKG reports in location metadata
+var zonesByName = map[string]*time.Location{
+	"asia/jakarta":   WIB,
+	"asia/pontianak": WIB,
+	"asia/makassar":  WITA,
+	"asia/jayapura":  WIT,
+	"wib":            WIB,
+	"wita":           WITA,
+	"wit":            WIT,
+}
+
+// Provinces outside WIB, keyed by Kemendagri province code
+var zonesByProvince = map[string]*time.Location{
+	"51": WITA, // Bali
+	"52": WITA, // Nusa Tenggara Barat
+	"53": WITA, // Nusa Tenggara Timur
+	"63": WITA, // Kalimantan Selatan
+	"64": WITA, // Kalimantan Timur
+	"65": WITA, // Kalimantan Utara
+	"71": WITA, // Sulawesi Utara
+	"72": WITA, // Sulawesi Tengah
+	"73": WITA, // Sulawesi Selatan
+	"74": WITA, // Sulawesi Tenggara
+	"75": WITA, // Gorontalo
+	"76": WITA, // Sulawesi Barat
+	"81": WIT,  // Maluku
+	"82": WIT,  // Maluku Utara
+	"91": WIT,  // Papua
+	"92": WIT,  // Papua Barat
+	"93": WIT,  // Papua Selatan
+	"94": WIT,  // Papua Tengah
+	"95": WIT,  // Papua Pegunungan
+	"96": WIT,  // Papua Barat Daya
+}
+
+// Location returns the zone for a region. A stored timezone name wins;
+// otherwise the zone is derived from the province prefix of the region code.
+func Location(regionCode, timezone string) *time.Location {
+	if loc, ok := zonesByName[strings.ToLower(strings.TrimSpace(timezone))]; ok {
+		return loc
+	}
+	return ForProvince(strings.SplitN(regionCode, ".", 2)[0])
+}
+
+// ForProvince returns the zone for a two-digit province code, defaulting to WIB
+func ForProvince(provinceCode string) *time.Location {
+	if loc, ok := zonesByProvince[provinceCode]; ok {
+		return loc
+	}
+	return WIB
+}
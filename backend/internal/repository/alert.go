@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+)
+
+type prismaAlertRepository struct {
+	db *db.PrismaClient
+}
+
+func NewAlertRepository(database *db.PrismaClient) AlertRepository {
+	return &prismaAlertRepository{db: database}
+}
+
+// FindActive returns active alerts that have not ended, soonest first. With
+// region codes it keeps alerts without a region, which cover the whole
+// country, and alerts for any of the codes, a region containing one or a
+// region within one. Without codes every active alert is returned.
+func (r *prismaAlertRepository) FindActive(ctx context.Context, regionCodes []string) ([]db.WeatherAlertModel, error) {
+	params := []db.WeatherAlertWhereParam{
+		db.WeatherAlert.IsActive.Equals(true),
+		db.WeatherAlert.Or(
+			db.WeatherAlert.EndTime.IsNull(),
+			db.WeatherAlert.EndTime.Gt(time.Now()),
+		),
+	}
+	if len(regionCodes) > 0 {
+		var ancestors []string
+		regionFilters := []db.WeatherAlertWhereParam{db.WeatherAlert.Region.IsNull()}
+		for _, code := range regionCodes {
+			ancestors = append(ancestors, RegionAncestors(code)...)
+			regionFilters = append(regionFilters, db.WeatherAlert.Region.StartsWith(code+"."))
+		}
+		regionFilters = append(regionFilters, db.WeatherAlert.Region.In(ancestors))
+		params = append(params, db.WeatherAlert.Or(regionFilters...))
+	}
+
+	return r.db.WeatherAlert.FindMany(params...).OrderBy(
+		db.WeatherAlert.StartTime.Order(db.SortOrderAsc),
+	).Exec(ctx)
+}
+
+// FindByID returns one alert, active or not
+func (r *prismaAlertRepository) FindByID(ctx context.Context, id string) (*db.WeatherAlertModel, error) {
+	return r.db.WeatherAlert.FindUnique(
+		db.WeatherAlert.ID.Equals(id),
+	).Exec(ctx)
+}
+
+// RegionAncestors lists a code and every code containing it, e.g.
+// 32.73.01 gives 32, 32.73 and 32.73.01
+func RegionAncestors(code string) []string {
+	parts := strings.Split(code, ".")
+	codes := make([]string, len(parts))
+	for i := range parts {
+		codes[i] = strings.Join(parts[:i+1], ".")
+	}
+	return codes
+}
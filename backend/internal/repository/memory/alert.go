@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository"
+)
+
+type alertRepository struct {
+	s *Store
+}
+
+func (r *alertRepository) FindActive(ctx context.Context, regionCodes []string) ([]db.WeatherAlertModel, error) {
+	ancestors := make(map[string]bool)
+	for _, code := range regionCodes {
+		for _, ancestor := range repository.RegionAncestors(code) {
+			ancestors[ancestor] = true
+		}
+	}
+	covers := func(region *string) bool {
+		if len(regionCodes) == 0 || region == nil || ancestors[*region] {
+			return true
+		}
+		for _, code := range regionCodes {
+			if strings.HasPrefix(*region, code+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	r.s.mu.RLock()
+	now := time.Now()
+	var alerts []db.WeatherAlertModel
+	for _, alert := range r.s.alerts {
+		end := alert.InnerWeatherAlert.EndTime
+		if alert.IsActive && (end == nil || end.After(now)) && covers(alert.InnerWeatherAlert.Region) {
+			alerts = append(alerts, alert)
+		}
+	}
+	r.s.mu.RUnlock()
+
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].StartTime.Before(alerts[j].StartTime) })
+	return alerts, nil
+}
+
+func (r *alertRepository) FindByID(ctx context.Context, id string) (*db.WeatherAlertModel, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, alert := range r.s.alerts {
+		if alert.ID == id {
+			return &alert, nil
+		}
+	}
+	return nil, db.ErrNotFound
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository/repositorytest"
+)
+
+func TestRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Backend {
+		s := New()
+		return repositorytest.Backend{
+			Regions:       s.Regions(),
+			Weather:       s.Weather(),
+			Users:         s.Users(),
+			SyncRuns:      s.SyncRuns(),
+			Alerts:        s.Alerts(),
+			RegionAliases: s.RegionAliases(),
+			AddRegions: func(ctx context.Context, regions ...db.RegionModel) error {
+				s.AddRegions(regions...)
+				return nil
+			},
+			AddFavorites: func(ctx context.Context, favorites ...db.UserFavoriteModel) error {
+				s.AddFavorites(favorites...)
+				return nil
+			},
+			AddAlerts: func(ctx context.Context, alerts ...db.WeatherAlertModel) error {
+				s.AddAlerts(alerts...)
+				return nil
+			},
+		}
+	})
+}
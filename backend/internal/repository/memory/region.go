@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository"
+)
+
+type regionRepository struct {
+	s *Store
+}
+
+func (r *regionRepository) FindByCode(ctx context.Context, code string) (*db.RegionModel, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, region := range r.s.regions {
+		if region.Code == code && region.IsActive {
+			return &region, nil
+		}
+	}
+	return nil, db.ErrNotFound
+}
+
+func (r *regionRepository) FindByCodes(ctx context.Context, codes []string) ([]db.RegionModel, error) {
+	wanted := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		wanted[code] = true
+	}
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var found []db.RegionModel
+	for _, region := range r.s.regions {
+		if wanted[region.Code] && region.IsActive {
+			found = append(found, region)
+		}
+	}
+	return found, nil
+}
+
+func (r *regionRepository) Search(ctx context.Context, query string, level int, limit int) ([]db.RegionModel, error) {
+	name := strings.Title(strings.ToLower(strings.TrimSpace(query)))
+
+	r.s.mu.RLock()
+	var found []db.RegionModel
+	for _, region := range r.s.regions {
+		if region.IsActive && strings.Contains(region.Name, name) && (level <= 0 || region.Level == level) {
+			found = append(found, region)
+		}
+	}
+	r.s.mu.RUnlock()
+
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.HasWeatherData != b.HasWeatherData {
+			return a.HasWeatherData
+		}
+		if a.Level != b.Level {
+			return a.Level > b.Level
+		}
+		return a.Name < b.Name
+	})
+	return take(found, limit), nil
+}
+
+func (r *regionRepository) FindPopular(ctx context.Context, limit int) ([]db.RegionModel, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var found []db.RegionModel
+	for _, region := range r.s.regions {
+		if region.HasWeatherData && region.IsActive {
+			found = append(found, region)
+		}
+	}
+	return take(found, limit), nil
+}
+
+func (r *regionRepository) UpdateLocation(ctx context.Context, id string, latitude, longitude float64, timezone string) (*db.RegionModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	i := r.s.regionIndex(id)
+	if i < 0 {
+		return nil, db.ErrNotFound
+	}
+	region := &r.s.regions[i]
+	region.InnerRegion.Latitude = &latitude
+	region.InnerRegion.Longitude = &longitude
+	region.InnerRegion.Timezone = &timezone
+	region.UpdatedAt = time.Now()
+	updated := *region
+	return &updated, nil
+}
+
+func (r *regionRepository) FindSyncTargets(ctx context.Context, limit int) ([]repository.SyncTarget, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	favorite := make(map[string]bool)
+	for _, f := range r.s.favorites {
+		favorite[f.RegionID] = true
+	}
+	synced := make(map[string]time.Time)
+	for _, point := range r.s.points {
+		if point.UpdatedAt.After(synced[point.RegionID]) {
+			synced[point.RegionID] = point.UpdatedAt
+		}
+	}
+
+	// Favorites first, then the villages synced longest ago, as in Postgres
+	var targets []db.RegionModel
+	for _, region := range r.s.regions {
+		if region.Level == 4 && region.IsActive && region.HasWeatherData {
+			targets = append(targets, region)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		a, b := targets[i], targets[j]
+		if favorite[a.ID] != favorite[b.ID] {
+			return favorite[a.ID]
+		}
+		if at, bt := synced[a.ID], synced[b.ID]; !at.Equal(bt) {
+			return at.Before(bt)
+		}
+		return a.Code < b.Code
+	})
+
+	result := make([]repository.SyncTarget, len(targets))
+	for i, region := range targets {
+		result[i] = repository.SyncTarget{ID: region.ID, Code: region.Code}
+	}
+	return take(result, limit), nil
+}
+
+func (r *regionRepository) FindProbeTargets(ctx context.Context, limit int, unavailableBefore, availableBefore time.Time) ([]repository.SyncTarget, error) {
+	r.s.mu.RLock()
+	var due []db.RegionModel
+	for _, region := range r.s.regions {
+		if region.Level != 4 || !region.IsActive {
+			continue
+		}
+		probed := region.InnerRegion.WeatherProbedAt
+		switch {
+		case probed == nil,
+			!region.HasWeatherData && probed.Before(unavailableBefore),
+			region.HasWeatherData && probed.Before(availableBefore):
+			due = append(due, region)
+		}
+	}
+	r.s.mu.RUnlock()
+
+	sort.SliceStable(due, func(i, j int) bool {
+		a, b := due[i].InnerRegion.WeatherProbedAt, due[j].InnerRegion.WeatherProbedAt
+		switch {
+		case a == nil && b != nil:
+			return true
+		case a != nil && b == nil:
+			return false
+		case a != nil && !a.Equal(*b):
+			return a.Before(*b)
+		}
+		return due[i].Code < due[j].Code
+	})
+
+	targets := make([]repository.SyncTarget, 0, len(due))
+	for _, region := range take(due, limit) {
+		targets = append(targets, repository.SyncTarget{ID: region.ID, Code: region.Code})
+	}
+	return targets, nil
+}
+
+func (r *regionRepository) RecordProbe(ctx context.Context, id string, available bool, reason string) error {
+	var failure *string
+	if reason != "" {
+		failure = &reason
+	}
+	return r.update(id, func(region *db.RegionModel) {
+		region.HasWeatherData = available
+		region.InnerRegion.WeatherProbeError = failure
+	})
+}
+
+func (r *regionRepository) RecordProbeFailure(ctx context.Context, id string, reason string) error {
+	return r.update(id, func(region *db.RegionModel) {
+		region.InnerRegion.WeatherProbeError = &reason
+	})
+}
+
+// update applies a probe result to a region and stamps the probe time
+func (r *regionRepository) update(id string, apply func(region *db.RegionModel)) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	i := r.s.regionIndex(id)
+	if i < 0 {
+		return db.ErrNotFound
+	}
+	now := time.Now()
+	apply(&r.s.regions[i])
+	r.s.regions[i].InnerRegion.WeatherProbedAt = &now
+	r.s.regions[i].UpdatedAt = now
+	return nil
+}
+
+// take returns at most limit items, like Prisma's Take
+func take[T any](items []T, limit int) []T {
+	if limit >= 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
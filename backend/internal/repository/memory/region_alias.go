@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"eamagineweather-backend/db"
+)
+
+type regionAliasRepository struct {
+	s *Store
+}
+
+func (r *regionAliasRepository) FindAll(ctx context.Context) ([]db.RegionAliasModel, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	aliases := make([]db.RegionAliasModel, len(r.s.aliases))
+	copy(aliases, r.s.aliases)
+	return aliases, nil
+}
+
+func (r *regionAliasRepository) Upsert(ctx context.Context, oldCode, newCode, reason string) (*db.RegionAliasModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for i := range r.s.aliases {
+		if r.s.aliases[i].OldCode == oldCode {
+			r.s.aliases[i].NewCode = newCode
+			r.s.aliases[i].InnerRegionAlias.Reason = &reason
+			alias := r.s.aliases[i]
+			return &alias, nil
+		}
+	}
+	alias := db.RegionAliasModel{InnerRegionAlias: db.InnerRegionAlias{
+		ID:        r.s.newID(),
+		OldCode:   oldCode,
+		NewCode:   newCode,
+		Reason:    &reason,
+		CreatedAt: time.Now(),
+	}}
+	r.s.aliases = append(r.s.aliases, alias)
+	return &alias, nil
+}
@@ -0,0 +1,117 @@
+// Package memory implements the repository interfaces over plain Go values,
+// for running services and handlers without Postgres. Queries follow the
+// Prisma-backed repositories, including db.ErrNotFound for missing records,
+// but scan every record, so it is only meant for small data sets.
+package memory
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository"
+)
+
+// Store holds the records shared by the repositories it returns, so that for
+// example favorites added to it make their villages sync targets
+type Store struct {
+	mu     sync.RWMutex
+	nextID int
+
+	regions   []db.RegionModel
+	points    []db.WeatherDataModel
+	summaries map[summaryKey]repository.WeatherHistoryRow
+	users     []db.UserModel
+	favorites []db.UserFavoriteModel
+	runs      []db.WeatherSyncRunModel
+	alerts    []db.WeatherAlertModel
+	aliases   []db.RegionAliasModel
+}
+
+// summaryKey identifies the daily summary of a region, like the
+// (regionId, date) unique key of weather_daily_summaries
+type summaryKey struct {
+	regionID string
+	date     time.Time
+}
+
+// New returns an empty store
+func New() *Store {
+	return &Store{summaries: make(map[summaryKey]repository.WeatherHistoryRow)}
+}
+
+func (s *Store) Regions() repository.RegionRepository            { return &regionRepository{s} }
+func (s *Store) Weather() repository.WeatherRepository           { return &weatherRepository{s} }
+func (s *Store) Users() repository.UserRepository                { return &userRepository{s} }
+func (s *Store) SyncRuns() repository.SyncRunRepository          { return &syncRunRepository{s} }
+func (s *Store) Alerts() repository.AlertRepository              { return &alertRepository{s} }
+func (s *Store) RegionAliases() repository.RegionAliasRepository { return &regionAliasRepository{s} }
+
+// AddRegions stores regions, giving an id to those without one. Regions are
+// stored as given, so set IsActive for regions that should be found.
+func (s *Store) AddRegions(regions ...db.RegionModel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, region := range regions {
+		if region.ID == "" {
+			region.ID = s.newID()
+		}
+		s.regions = append(s.regions, region)
+	}
+}
+
+// AddUsers stores users, giving an id to those without one
+func (s *Store) AddUsers(users ...db.UserModel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range users {
+		if user.ID == "" {
+			user.ID = s.newID()
+		}
+		s.users = append(s.users, user)
+	}
+}
+
+// AddFavorites stores favorites, giving an id to those without one. The
+// region relation is filled in when favorites are read.
+func (s *Store) AddFavorites(favorites ...db.UserFavoriteModel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, favorite := range favorites {
+		if favorite.ID == "" {
+			favorite.ID = s.newID()
+		}
+		favorite.RelationsUserFavorite = db.RelationsUserFavorite{}
+		s.favorites = append(s.favorites, favorite)
+	}
+}
+
+// AddAlerts stores alerts, giving an id to those without one
+func (s *Store) AddAlerts(alerts ...db.WeatherAlertModel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, alert := range alerts {
+		if alert.ID == "" {
+			alert.ID = s.newID()
+		}
+		s.alerts = append(s.alerts, alert)
+	}
+}
+
+// newID returns a new record id. Callers hold the write lock.
+func (s *Store) newID() string {
+	s.nextID++
+	return "mem" + strconv.Itoa(s.nextID)
+}
+
+// regionIndex returns the index of the region with the id, or -1. Callers
+// hold a lock.
+func (s *Store) regionIndex(id string) int {
+	for i := range s.regions {
+		if s.regions[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"eamagineweather-backend/db"
+)
+
+type syncRunRepository struct {
+	s *Store
+}
+
+func (r *syncRunRepository) Start(ctx context.Context) (*db.WeatherSyncRunModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	run := db.WeatherSyncRunModel{InnerWeatherSyncRun: db.InnerWeatherSyncRun{
+		ID:        r.s.newID(),
+		StartedAt: time.Now(),
+	}}
+	r.s.runs = append(r.s.runs, run)
+	return &run, nil
+}
+
+func (r *syncRunRepository) Finish(ctx context.Context, id string, regions, points, failed int, reason string) (*db.WeatherSyncRunModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for i := range r.s.runs {
+		if r.s.runs[i].ID != id {
+			continue
+		}
+		now := time.Now()
+		run := &r.s.runs[i]
+		run.InnerWeatherSyncRun.FinishedAt = &now
+		run.RegionCount = regions
+		run.PointCount = points
+		run.FailedCount = failed
+		run.InnerWeatherSyncRun.RunError = nil
+		if reason != "" {
+			run.InnerWeatherSyncRun.RunError = &reason
+		}
+		finished := *run
+		return &finished, nil
+	}
+	return nil, db.ErrNotFound
+}
+
+func (r *syncRunRepository) FindLatestFinished(ctx context.Context) (*db.WeatherSyncRunModel, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	now := time.Now()
+	var latest *db.WeatherSyncRunModel
+	for i, run := range r.s.runs {
+		finished := run.InnerWeatherSyncRun.FinishedAt
+		if finished == nil || finished.After(now) {
+			continue
+		}
+		if latest == nil || finished.After(*latest.InnerWeatherSyncRun.FinishedAt) {
+			latest = &r.s.runs[i]
+		}
+	}
+	if latest == nil {
+		return nil, db.ErrNotFound
+	}
+	run := *latest
+	return &run, nil
+}
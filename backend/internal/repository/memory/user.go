@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"eamagineweather-backend/db"
+)
+
+type userRepository struct {
+	s *Store
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*db.UserModel, error) {
+	return r.find(func(user db.UserModel) bool { return user.Email == email })
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id string) (*db.UserModel, error) {
+	return r.find(func(user db.UserModel) bool { return user.ID == id })
+}
+
+func (r *userRepository) UpdatePreferences(ctx context.Context, id, units, windUnit, language string) (*db.UserModel, error) {
+	return r.update(id, func(user *db.UserModel) {
+		user.Units = units
+		user.InnerUser.WindUnit = &windUnit
+		user.Language = language
+	})
+}
+
+func (r *userRepository) Create(ctx context.Context, email string, name string) (*db.UserModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, user := range r.s.users {
+		if user.Email == email {
+			return nil, fmt.Errorf("user %s already exists", email)
+		}
+	}
+	now := time.Now()
+	user := db.UserModel{InnerUser: db.InnerUser{
+		ID:        r.s.newID(),
+		Email:     email,
+		Name:      &name,
+		Units:     "metric",
+		Language:  "id",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+	r.s.users = append(r.s.users, user)
+	return &user, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, id string, name string) (*db.UserModel, error) {
+	return r.update(id, func(user *db.UserModel) {
+		user.InnerUser.Name = &name
+	})
+}
+
+func (r *userRepository) FindFavorites(ctx context.Context, userID string) ([]db.UserFavoriteModel, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var favorites []db.UserFavoriteModel
+	for _, favorite := range r.s.favorites {
+		if favorite.UserID != userID {
+			continue
+		}
+		if i := r.s.regionIndex(favorite.RegionID); i >= 0 {
+			region := r.s.regions[i]
+			favorite.RelationsUserFavorite.Region = &region
+		}
+		favorites = append(favorites, favorite)
+	}
+	sort.SliceStable(favorites, func(i, j int) bool { return favorites[i].Order < favorites[j].Order })
+	return favorites, nil
+}
+
+func (r *userRepository) find(match func(db.UserModel) bool) (*db.UserModel, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, user := range r.s.users {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, db.ErrNotFound
+}
+
+func (r *userRepository) update(id string, apply func(user *db.UserModel)) (*db.UserModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for i := range r.s.users {
+		if r.s.users[i].ID == id {
+			apply(&r.s.users[i])
+			r.s.users[i].UpdatedAt = time.Now()
+			user := r.s.users[i]
+			return &user, nil
+		}
+	}
+	return nil, db.ErrNotFound
+}
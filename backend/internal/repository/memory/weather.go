@@ -0,0 +1,354 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository"
+)
+
+type weatherRepository struct {
+	s *Store
+}
+
+func (r *weatherRepository) FindByRegionCode(ctx context.Context, regionCode string) ([]db.WeatherDataModel, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	regionID := ""
+	for _, region := range r.s.regions {
+		if region.Code == regionCode {
+			regionID = region.ID
+			break
+		}
+	}
+	if regionID == "" {
+		return nil, db.ErrNotFound
+	}
+
+	points := r.s.pointsOf(regionID, func(db.WeatherDataModel) bool { return true })
+	sort.Slice(points, func(i, j int) bool { return points[i].UtcDatetime.After(points[j].UtcDatetime) })
+	return take(points, 24), nil
+}
+
+func (r *weatherRepository) Create(ctx context.Context, regionID string, temp float64, humidity int, desc string) (*db.WeatherDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if r.s.regionIndex(regionID) < 0 {
+		return nil, db.ErrNotFound
+	}
+	now := time.Now()
+	point := db.WeatherDataModel{InnerWeatherData: db.InnerWeatherData{
+		ID:            r.s.newID(),
+		RegionID:      regionID,
+		UtcDatetime:   now.UTC(),
+		LocalDatetime: now.UTC(),
+		Temperature:   temp,
+		Humidity:      humidity,
+		WeatherDesc:   desc,
+		AnalysisDate:  now.UTC(),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}}
+	r.s.points = append(r.s.points, point)
+	return &point, nil
+}
+
+func (r *weatherRepository) CompactBefore(ctx context.Context, cutoff time.Time) (rows int, days int, err error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var old []db.WeatherDataModel
+	for _, point := range r.s.points {
+		if point.LocalDatetime.Before(cutoff) {
+			old = append(old, point)
+		}
+	}
+	for key, summary := range summarizeDays(old) {
+		if stored, ok := r.s.summaries[key]; ok && stored.SampleCount >= summary.SampleCount {
+			continue
+		}
+		r.s.summaries[key] = summary
+		rows += summary.SampleCount
+		days++
+	}
+	return rows, days, nil
+}
+
+func (r *weatherRepository) DeleteBefore(ctx context.Context, cutoff time.Time, batchSize int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	kept := r.s.points[:0]
+	for _, point := range r.s.points {
+		if !point.LocalDatetime.Before(cutoff) {
+			kept = append(kept, point)
+		}
+	}
+	deleted := len(r.s.points) - len(kept)
+	r.s.points = kept
+	return deleted, nil
+}
+
+func (r *weatherRepository) FindHistoryPoints(ctx context.Context, regionID string, from, to time.Time, limit int) ([]db.WeatherDataModel, error) {
+	r.s.mu.RLock()
+	points := r.s.pointsOf(regionID, func(point db.WeatherDataModel) bool {
+		return !point.LocalDatetime.Before(from) && point.LocalDatetime.Before(to)
+	})
+	r.s.mu.RUnlock()
+
+	sort.Slice(points, func(i, j int) bool { return points[i].LocalDatetime.Before(points[j].LocalDatetime) })
+	return take(points, limit), nil
+}
+
+func (r *weatherRepository) AggregateHistory(ctx context.Context, regionID string, monthly bool, from, to time.Time, limit int) ([]repository.WeatherHistoryRow, error) {
+	fromDate, toDate := truncateDay(from), truncateDay(to)
+
+	r.s.mu.RLock()
+	var days []repository.WeatherHistoryRow
+	for key, summary := range r.s.summaries {
+		if key.regionID == regionID && !key.date.Before(fromDate) && key.date.Before(toDate) {
+			days = append(days, summary)
+		}
+	}
+	raw := r.s.pointsOf(regionID, func(point db.WeatherDataModel) bool {
+		_, compacted := r.s.summaries[summaryKey{regionID, truncateDay(point.LocalDatetime)}]
+		return !compacted && !point.LocalDatetime.Before(from) && point.LocalDatetime.Before(to)
+	})
+	r.s.mu.RUnlock()
+
+	for _, summary := range summarizeDays(raw) {
+		days = append(days, summary)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Period.Before(days[j].Period) })
+	if monthly {
+		days = summarizeMonths(days)
+	}
+	return take(days, limit), nil
+}
+
+func (r *weatherRepository) UpsertPoints(ctx context.Context, regionID string, points []repository.WeatherPoint) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	written := 0
+	now := time.Now()
+	for _, p := range points {
+		utc := p.UTCDatetime.UTC()
+		stored := -1
+		for i := range r.s.points {
+			if r.s.points[i].RegionID == regionID && r.s.points[i].UtcDatetime.Equal(utc) {
+				stored = i
+				break
+			}
+		}
+
+		point := db.InnerWeatherData{
+			ID:            regionID + ":" + utc.Format("200601021504"),
+			RegionID:      regionID,
+			UtcDatetime:   utc,
+			LocalDatetime: p.LocalDatetime,
+			Temperature:   p.Temperature,
+			Humidity:      p.Humidity,
+			WeatherDesc:   p.WeatherDesc,
+			WeatherDescEn: p.WeatherDescEn,
+			WindSpeed:     p.WindSpeed,
+			WindDirection: p.WindDirection,
+			CloudCover:    p.CloudCover,
+			Visibility:    p.Visibility,
+			Precipitation: p.Precipitation,
+			AnalysisDate:  p.AnalysisDate,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if stored < 0 {
+			r.s.points = append(r.s.points, db.WeatherDataModel{InnerWeatherData: point})
+		} else if !r.s.points[stored].AnalysisDate.After(p.AnalysisDate) {
+			point.CreatedAt = r.s.points[stored].CreatedAt
+			r.s.points[stored].InnerWeatherData = point
+		} else {
+			continue
+		}
+		written++
+	}
+	return written, nil
+}
+
+func (r *weatherRepository) FindVillagePoints(ctx context.Context, parentCode string, at time.Time) ([]repository.VillagePoint, error) {
+	at = at.UTC()
+	slotStart := at.Add(-3 * time.Hour)
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var villagePoints []repository.VillagePoint
+	for _, region := range r.s.regions {
+		if region.Level != 4 || !region.IsActive || !strings.HasPrefix(region.Code, parentCode+".") {
+			continue
+		}
+		var latest *db.WeatherDataModel
+		for i, point := range r.s.points {
+			if point.RegionID != region.ID || point.UtcDatetime.After(at) || !point.UtcDatetime.After(slotStart) {
+				continue
+			}
+			if latest == nil || point.UtcDatetime.After(latest.UtcDatetime) {
+				latest = &r.s.points[i]
+			}
+		}
+		if latest == nil {
+			continue
+		}
+		villagePoints = append(villagePoints, repository.VillagePoint{
+			RegionID:      region.ID,
+			Code:          region.Code,
+			Latitude:      region.InnerRegion.Latitude,
+			Longitude:     region.InnerRegion.Longitude,
+			UTCDatetime:   latest.UtcDatetime,
+			Temperature:   latest.Temperature,
+			Humidity:      latest.Humidity,
+			WindSpeed:     latest.WindSpeed,
+			Precipitation: latest.Precipitation,
+			WeatherDesc:   latest.WeatherDesc,
+			WeatherDescEn: latest.WeatherDescEn,
+		})
+	}
+	sort.Slice(villagePoints, func(i, j int) bool { return villagePoints[i].Code < villagePoints[j].Code })
+	return villagePoints, nil
+}
+
+// pointsOf returns copies of the points of a region that match. Callers hold
+// a lock.
+func (s *Store) pointsOf(regionID string, match func(db.WeatherDataModel) bool) []db.WeatherDataModel {
+	var points []db.WeatherDataModel
+	for _, point := range s.points {
+		if point.RegionID == regionID && match(point) {
+			points = append(points, point)
+		}
+	}
+	return points
+}
+
+// summarizeDays aggregates points into one row per region and local day, as
+// compactSQL does
+func summarizeDays(points []db.WeatherDataModel) map[summaryKey]repository.WeatherHistoryRow {
+	groups := make(map[summaryKey][]db.WeatherDataModel)
+	for _, point := range points {
+		key := summaryKey{point.RegionID, truncateDay(point.LocalDatetime)}
+		groups[key] = append(groups[key], point)
+	}
+
+	summaries := make(map[summaryKey]repository.WeatherHistoryRow, len(groups))
+	for key, group := range groups {
+		row := repository.WeatherHistoryRow{
+			Period:         key.date,
+			MinTemperature: group[0].Temperature,
+			MaxTemperature: group[0].Temperature,
+			MaxWindSpeed:   group[0].WindSpeed,
+			SampleCount:    len(group),
+		}
+		var desc, descEn []string
+		for _, point := range group {
+			row.MinTemperature = min(row.MinTemperature, point.Temperature)
+			row.MaxTemperature = max(row.MaxTemperature, point.Temperature)
+			row.MaxWindSpeed = max(row.MaxWindSpeed, point.WindSpeed)
+			row.AvgTemperature += point.Temperature
+			row.AvgHumidity += float64(point.Humidity)
+			row.AvgCloudCover += float64(point.CloudCover)
+			desc = append(desc, point.WeatherDesc)
+			descEn = append(descEn, point.WeatherDescEn)
+		}
+		n := float64(len(group))
+		row.AvgTemperature /= n
+		row.AvgHumidity /= n
+		row.AvgCloudCover /= n
+		row.WeatherDesc = mode(desc)
+		row.WeatherDescEn = mode(descEn)
+		summaries[key] = row
+	}
+	return summaries
+}
+
+// summarizeMonths merges daily rows, oldest first, into monthly ones, as
+// historyMonthlySQL does
+func summarizeMonths(days []repository.WeatherHistoryRow) []repository.WeatherHistoryRow {
+	var months []repository.WeatherHistoryRow
+	var desc, descEn []string
+	flush := func() {
+		month := &months[len(months)-1]
+		n := float64(month.SampleCount)
+		month.AvgTemperature /= n
+		month.AvgHumidity /= n
+		month.AvgCloudCover /= n
+		month.WeatherDesc = mode(desc)
+		month.WeatherDescEn = mode(descEn)
+		desc, descEn = nil, nil
+	}
+
+	for _, day := range days {
+		period := time.Date(day.Period.Year(), day.Period.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if len(months) == 0 || !months[len(months)-1].Period.Equal(period) {
+			if len(months) > 0 {
+				flush()
+			}
+			months = append(months, repository.WeatherHistoryRow{
+				Period:         period,
+				MinTemperature: day.MinTemperature,
+				MaxTemperature: day.MaxTemperature,
+				MaxWindSpeed:   day.MaxWindSpeed,
+			})
+		}
+		month := &months[len(months)-1]
+		n := float64(day.SampleCount)
+		month.MinTemperature = min(month.MinTemperature, day.MinTemperature)
+		month.MaxTemperature = max(month.MaxTemperature, day.MaxTemperature)
+		month.MaxWindSpeed = max(month.MaxWindSpeed, day.MaxWindSpeed)
+		month.AvgTemperature += day.AvgTemperature * n
+		month.AvgHumidity += day.AvgHumidity * n
+		month.AvgCloudCover += day.AvgCloudCover * n
+		month.SampleCount += day.SampleCount
+		desc = append(desc, day.WeatherDesc)
+		descEn = append(descEn, day.WeatherDescEn)
+	}
+	if len(months) > 0 {
+		flush()
+	}
+	return months
+}
+
+// mode returns the most frequent value, the smallest one on ties
+func mode(values []string) string {
+	counts := make(map[string]int, len(values))
+	best := ""
+	for _, value := range values {
+		counts[value]++
+	}
+	for value, count := range counts {
+		if count > counts[best] || (count == counts[best] && value < best) {
+			best = value
+		}
+	}
+	return best
+}
+
+// truncateDay returns the calendar date of a wall clock time labelled as UTC
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (r *weatherRepository) LatestUpdate(ctx context.Context) (time.Time, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var latest time.Time
+	for _, point := range r.s.points {
+		if point.UpdatedAt.After(latest) {
+			latest = point.UpdatedAt
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/database"
+	"eamagineweather-backend/internal/repository"
+	"eamagineweather-backend/internal/repository/repositorytest"
+	"eamagineweather-backend/internal/repository/sqlite"
+)
+
+const truncateSQL = `TRUNCATE regions, region_aliases, weather_data, weather_daily_summaries,
+	weather_sync_runs, users, user_favorites, weather_alerts CASCADE`
+
+// TestRepositories runs against the Postgres database DATABASE_URL names.
+// Every test empties its tables, so point it at a disposable database.
+func TestRepositories(t *testing.T) {
+	url := os.Getenv("DATABASE_URL")
+	if url == "" || sqlite.IsURL(url) {
+		t.Skip("DATABASE_URL does not name a Postgres database")
+	}
+	connection, err := database.NewConnection(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connection.Disconnect()
+	client := connection.Client
+
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Backend {
+		if _, err := client.Prisma.ExecuteRaw(truncateSQL).Exec(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return repositorytest.Backend{
+			Regions:       repository.NewRegionRepository(client),
+			Weather:       repository.NewWeatherRepository(client),
+			Users:         repository.NewUserRepository(client),
+			SyncRuns:      repository.NewSyncRunRepository(client),
+			Alerts:        repository.NewAlertRepository(client),
+			RegionAliases: repository.NewRegionAliasRepository(client),
+			AddRegions: func(ctx context.Context, regions ...db.RegionModel) error {
+				for _, r := range regions {
+					_, err := client.Region.CreateOne(
+						db.Region.Code.Set(r.Code),
+						db.Region.Name.Set(r.InnerRegion.Name),
+						db.Region.Level.Set(r.Level),
+						db.Region.ID.Set(r.ID),
+						db.Region.ProvinceCode.SetIfPresent(r.InnerRegion.ProvinceCode),
+						db.Region.RegencyCode.SetIfPresent(r.InnerRegion.RegencyCode),
+						db.Region.DistrictCode.SetIfPresent(r.InnerRegion.DistrictCode),
+						db.Region.VillageCode.SetIfPresent(r.InnerRegion.VillageCode),
+						db.Region.HasWeatherData.Set(r.HasWeatherData),
+						db.Region.IsActive.Set(r.IsActive),
+					).Exec(ctx)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			AddFavorites: func(ctx context.Context, favorites ...db.UserFavoriteModel) error {
+				for _, f := range favorites {
+					_, err := client.UserFavorite.CreateOne(
+						db.UserFavorite.User.Link(db.User.ID.Equals(f.UserID)),
+						db.UserFavorite.Region.Link(db.Region.ID.Equals(f.RegionID)),
+						db.UserFavorite.ID.Set(f.ID),
+						db.UserFavorite.Order.Set(f.Order),
+					).Exec(ctx)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			AddAlerts: func(ctx context.Context, alerts ...db.WeatherAlertModel) error {
+				for _, a := range alerts {
+					_, err := client.WeatherAlert.CreateOne(
+						db.WeatherAlert.Title.Set(a.Title),
+						db.WeatherAlert.Description.Set(a.Description),
+						db.WeatherAlert.Severity.Set(a.Severity),
+						db.WeatherAlert.StartTime.Set(a.StartTime),
+						db.WeatherAlert.ID.Set(a.ID),
+						db.WeatherAlert.Region.SetIfPresent(a.InnerWeatherAlert.Region),
+						db.WeatherAlert.EndTime.SetIfPresent(a.InnerWeatherAlert.EndTime),
+						db.WeatherAlert.IsActive.Set(a.IsActive),
+					).Exec(ctx)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}
+	})
+}
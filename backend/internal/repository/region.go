@@ -2,27 +2,153 @@ package repository
 
 import (
 	"context"
+	"strings"
+	"time"
+
 	"eamagineweather-backend/db"
 )
 
-type RegionRepository struct {
+type prismaRegionRepository struct {
 	db *db.PrismaClient
 }
 
-func NewRegionRepository(database *db.PrismaClient) *RegionRepository {
-	return &RegionRepository{db: database}
+func NewRegionRepository(database *db.PrismaClient) RegionRepository {
+	return &prismaRegionRepository{db: database}
 }
 
-func (r *RegionRepository) FindByCode(ctx context.Context, code string) (*db.RegionModel, error) {
+func (r *prismaRegionRepository) FindByCode(ctx context.Context, code string) (*db.RegionModel, error) {
 	return r.db.Region.FindFirst(
 		db.Region.Code.Equals(code),
 		db.Region.IsActive.Equals(true),
 	).Exec(ctx)
 }
 
-func (r *RegionRepository) FindPopular(ctx context.Context, limit int) ([]db.RegionModel, error) {
+// FindByCodes returns the active regions with any of the given codes
+func (r *prismaRegionRepository) FindByCodes(ctx context.Context, codes []string) ([]db.RegionModel, error) {
+	return r.db.Region.FindMany(
+		db.Region.Code.In(codes),
+		db.Region.IsActive.Equals(true),
+	).Exec(ctx)
+}
+
+// Search finds active regions whose name contains query, optionally at one
+// level. Names are stored in title case, so the query is title-cased too.
+// Regions with weather data and villages come first.
+func (r *prismaRegionRepository) Search(ctx context.Context, query string, level int, limit int) ([]db.RegionModel, error) {
+	conditions := []db.RegionWhereParam{
+		db.Region.IsActive.Equals(true),
+		db.Region.Name.Contains(strings.Title(strings.ToLower(strings.TrimSpace(query)))),
+	}
+	if level > 0 {
+		conditions = append(conditions, db.Region.Level.Equals(level))
+	}
+
+	return r.db.Region.FindMany(conditions...).
+		Take(limit).
+		OrderBy(
+			db.Region.HasWeatherData.Order(db.SortOrderDesc),
+			db.Region.Level.Order(db.SortOrderDesc),
+			db.Region.Name.Order(db.SortOrderAsc),
+		).
+		Exec(ctx)
+}
+
+func (r *prismaRegionRepository) FindPopular(ctx context.Context, limit int) ([]db.RegionModel, error) {
 	return r.db.Region.FindMany(
 		db.Region.HasWeatherData.Equals(true),
 		db.Region.IsActive.Equals(true),
 	).Take(limit).Exec(ctx)
-}
\ No newline at end of file
+}
+// UpdateLocation stores coordinates and time zone reported by BMKG
+func (r *prismaRegionRepository) UpdateLocation(ctx context.Context, id string, latitude, longitude float64, timezone string) (*db.RegionModel, error) {
+	return r.db.Region.FindUnique(
+		db.Region.ID.Equals(id),
+	).Update(
+		db.Region.Latitude.Set(latitude),
+		db.Region.Longitude.Set(longitude),
+		db.Region.Timezone.Set(timezone),
+	).Exec(ctx)
+}
+
+// SyncTarget is a village the periodic sync fetches from BMKG
+type SyncTarget struct {
+	ID   string `json:"id"`
+	Code string `json:"code"`
+}
+
+// syncTargetsSQL selects favorite villages first, then the villages synced
+// longest ago, never-synced ones before all others. Every village BMKG
+// forecasts gets its turn in a later run, so a fresh deployment without
+// favorites still syncs everywhere and has roll-ups.
+const syncTargetsSQL = `
+SELECT r.id, r.code
+FROM regions r
+WHERE r.level = 4 AND r."isActive" AND r."hasWeatherData"
+ORDER BY
+	EXISTS (SELECT 1 FROM user_favorites f WHERE f."regionId" = r.id) DESC,
+	(SELECT MAX(w."updatedAt") FROM weather_data w WHERE w."regionId" = r.id) ASC NULLS FIRST,
+	r.code
+LIMIT $1`
+
+// FindSyncTargets returns at most limit villages for the periodic sync
+func (r *prismaRegionRepository) FindSyncTargets(ctx context.Context, limit int) ([]SyncTarget, error) {
+	var targets []SyncTarget
+	if err := r.db.Prisma.QueryRaw(syncTargetsSQL, limit).Exec(ctx, &targets); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// probeTargetsSQL selects villages whose BMKG availability is due to be
+// checked: never probed first, then unavailable ones last probed before $2
+// and available ones last probed before $3, oldest first
+const probeTargetsSQL = `
+SELECT id, code
+FROM regions
+WHERE level = 4 AND "isActive"
+	AND (
+		"weatherProbedAt" IS NULL
+		OR (NOT "hasWeatherData" AND "weatherProbedAt" < $2)
+		OR ("hasWeatherData" AND "weatherProbedAt" < $3)
+	)
+ORDER BY "weatherProbedAt" ASC NULLS FIRST, code
+LIMIT $1`
+
+// FindProbeTargets returns at most limit villages due for a BMKG
+// availability probe
+func (r *prismaRegionRepository) FindProbeTargets(ctx context.Context, limit int, unavailableBefore, availableBefore time.Time) ([]SyncTarget, error) {
+	var targets []SyncTarget
+	if err := r.db.Prisma.QueryRaw(probeTargetsSQL, limit, unavailableBefore, availableBefore).Exec(ctx, &targets); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// RecordProbe stores whether BMKG has a forecast for a village. reason
+// says why not, and is cleared when it does.
+func (r *prismaRegionRepository) RecordProbe(ctx context.Context, id string, available bool, reason string) error {
+	var failure *string
+	if reason != "" {
+		failure = &reason
+	}
+	_, err := r.db.Region.FindUnique(
+		db.Region.ID.Equals(id),
+	).Update(
+		db.Region.HasWeatherData.Set(available),
+		db.Region.WeatherProbedAt.Set(time.Now()),
+		db.Region.WeatherProbeError.SetOptional(failure),
+	).Exec(ctx)
+	return err
+}
+
+// RecordProbeFailure stores a probe that could not tell whether BMKG has a
+// forecast, leaving the availability flag as it was
+func (r *prismaRegionRepository) RecordProbeFailure(ctx context.Context, id string, reason string) error {
+	_, err := r.db.Region.FindUnique(
+		db.Region.ID.Equals(id),
+	).Update(
+		db.Region.WeatherProbedAt.Set(time.Now()),
+		db.Region.WeatherProbeError.Set(reason),
+	).Exec(ctx)
+	return err
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"eamagineweather-backend/db"
+)
+
+type prismaRegionAliasRepository struct {
+	db *db.PrismaClient
+}
+
+func NewRegionAliasRepository(database *db.PrismaClient) RegionAliasRepository {
+	return &prismaRegionAliasRepository{db: database}
+}
+
+// FindAll returns every alias. There is one per renumbered region, so the
+// table stays small enough to hold in memory.
+func (r *prismaRegionAliasRepository) FindAll(ctx context.Context) ([]db.RegionAliasModel, error) {
+	return r.db.RegionAlias.FindMany().Exec(ctx)
+}
+
+// Upsert points oldCode at newCode
+func (r *prismaRegionAliasRepository) Upsert(ctx context.Context, oldCode, newCode, reason string) (*db.RegionAliasModel, error) {
+	return r.db.RegionAlias.UpsertOne(db.RegionAlias.OldCode.Equals(oldCode)).
+		Create(
+			db.RegionAlias.OldCode.Set(oldCode),
+			db.RegionAlias.NewCode.Set(newCode),
+			db.RegionAlias.Reason.Set(reason),
+		).
+		Update(
+			db.RegionAlias.NewCode.Set(newCode),
+			db.RegionAlias.Reason.Set(reason),
+		).Exec(ctx)
+}
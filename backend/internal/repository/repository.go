@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"eamagineweather-backend/db"
+)
+
+// The interfaces below are what services and handlers depend on. The
+// constructors in this package return Prisma-backed implementations; the
+// memory subpackage provides in-memory ones that need no database. Lookups of
+// a single record return db.ErrNotFound when there is none, whatever the
+// implementation.
+
+// RegionRepository reads regions and records their sync and probe state
+type RegionRepository interface {
+	FindByCode(ctx context.Context, code string) (*db.RegionModel, error)
+	FindByCodes(ctx context.Context, codes []string) ([]db.RegionModel, error)
+	Search(ctx context.Context, query string, level int, limit int) ([]db.RegionModel, error)
+	FindPopular(ctx context.Context, limit int) ([]db.RegionModel, error)
+	UpdateLocation(ctx context.Context, id string, latitude, longitude float64, timezone string) (*db.RegionModel, error)
+	FindSyncTargets(ctx context.Context, limit int) ([]SyncTarget, error)
+	FindProbeTargets(ctx context.Context, limit int, unavailableBefore, availableBefore time.Time) ([]SyncTarget, error)
+	RecordProbe(ctx context.Context, id string, available bool, reason string) error
+	RecordProbeFailure(ctx context.Context, id string, reason string) error
+}
+
+// WeatherRepository stores forecast points and their daily summaries
+type WeatherRepository interface {
+	FindByRegionCode(ctx context.Context, regionCode string) ([]db.WeatherDataModel, error)
+	Create(ctx context.Context, regionID string, temp float64, humidity int, desc string) (*db.WeatherDataModel, error)
+	CompactBefore(ctx context.Context, cutoff time.Time) (rows int, days int, err error)
+	DeleteBefore(ctx context.Context, cutoff time.Time, batchSize int) (int, error)
+	FindHistoryPoints(ctx context.Context, regionID string, from, to time.Time, limit int) ([]db.WeatherDataModel, error)
+	AggregateHistory(ctx context.Context, regionID string, monthly bool, from, to time.Time, limit int) ([]WeatherHistoryRow, error)
+	UpsertPoints(ctx context.Context, regionID string, points []WeatherPoint) (int, error)
+	FindVillagePoints(ctx context.Context, parentCode string, at time.Time) ([]VillagePoint, error)
+	LatestUpdate(ctx context.Context) (time.Time, error)
+}
+
+// UserRepository reads users, their preferences and favorites
+type UserRepository interface {
+	FindByEmail(ctx context.Context, email string) (*db.UserModel, error)
+	FindByID(ctx context.Context, id string) (*db.UserModel, error)
+	UpdatePreferences(ctx context.Context, id, units, windUnit, language string) (*db.UserModel, error)
+	Create(ctx context.Context, email string, name string) (*db.UserModel, error)
+	Update(ctx context.Context, id string, name string) (*db.UserModel, error)
+	FindFavorites(ctx context.Context, userID string) ([]db.UserFavoriteModel, error)
+}
+
+// SyncRunRepository records periodic sync runs
+type SyncRunRepository interface {
+	Start(ctx context.Context) (*db.WeatherSyncRunModel, error)
+	Finish(ctx context.Context, id string, regions, points, failed int, reason string) (*db.WeatherSyncRunModel, error)
+	FindLatestFinished(ctx context.Context) (*db.WeatherSyncRunModel, error)
+}
+
+// AlertRepository reads weather alerts
+type AlertRepository interface {
+	FindActive(ctx context.Context, regionCodes []string) ([]db.WeatherAlertModel, error)
+	FindByID(ctx context.Context, id string) (*db.WeatherAlertModel, error)
+}
+
+// RegionAliasRepository stores old region codes and the codes replacing them
+type RegionAliasRepository interface {
+	FindAll(ctx context.Context) ([]db.RegionAliasModel, error)
+	Upsert(ctx context.Context, oldCode, newCode, reason string) (*db.RegionAliasModel, error)
+}
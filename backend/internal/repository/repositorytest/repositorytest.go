@@ -0,0 +1,853 @@
+// Package repositorytest is a conformance suite for implementations of the
+// repository interfaces. Every implementation runs the same table of tests,
+// so behaviour the services rely on, such as ordering, upsert rules and
+// db.ErrNotFound, stays the same whichever storage DATABASE_URL selects.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository"
+)
+
+// Backend is an implementation under test. The Add functions seed records
+// the interfaces have no writes for; records are stored with the ids given.
+type Backend struct {
+	Regions       repository.RegionRepository
+	Weather       repository.WeatherRepository
+	Users         repository.UserRepository
+	SyncRuns      repository.SyncRunRepository
+	Alerts        repository.AlertRepository
+	RegionAliases repository.RegionAliasRepository
+
+	AddRegions   func(ctx context.Context, regions ...db.RegionModel) error
+	AddFavorites func(ctx context.Context, favorites ...db.UserFavoriteModel) error
+	AddAlerts    func(ctx context.Context, alerts ...db.WeatherAlertModel) error
+}
+
+// Run runs the suite. open returns an empty backend and is called once per
+// test.
+func Run(t *testing.T, open func(t *testing.T) Backend) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, b Backend)
+	}{
+		{"RegionLookup", testRegionLookup},
+		{"RegionSearch", testRegionSearch},
+		{"RegionLocation", testRegionLocation},
+		{"SyncTargets", testSyncTargets},
+		{"ProbeTargets", testProbeTargets},
+		{"WeatherUpsert", testWeatherUpsert},
+		{"WeatherHistory", testWeatherHistory},
+		{"WeatherRetention", testWeatherRetention},
+		{"VillagePoints", testVillagePoints},
+		{"Users", testUsers},
+		{"Favorites", testFavorites},
+		{"Alerts", testAlerts},
+		{"SyncRuns", testSyncRuns},
+		{"RegionAliases", testRegionAliases},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, context.Background(), open(t))
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// Regions of Kota Bandung: a province, regency and district, three active
+// villages of which two have BMKG forecasts, and an inactive village
+const (
+	province     = "32"
+	regency      = "32.73"
+	district     = "32.73.01"
+	villageA     = "32.73.01.1001"
+	villageB     = "32.73.01.1002"
+	villageC     = "32.73.01.1003"
+	villageGone  = "32.73.01.1004"
+	otherVillage = "31.71.01.1001"
+)
+
+func region(code, name string, level int, hasWeatherData, active bool) db.RegionModel {
+	r := db.InnerRegion{
+		ID:             "region-" + code,
+		Code:           code,
+		Name:           name,
+		Level:          level,
+		HasWeatherData: hasWeatherData,
+		IsActive:       active,
+	}
+	codes := repository.RegionAncestors(code)
+	r.ProvinceCode = ptr(codes[0])
+	if level >= 2 {
+		r.RegencyCode = ptr(codes[1])
+	}
+	if level >= 3 {
+		r.DistrictCode = ptr(codes[2])
+	}
+	if level == 4 {
+		r.VillageCode = ptr(code)
+	}
+	return db.RegionModel{InnerRegion: r}
+}
+
+func regionID(code string) string { return "region-" + code }
+
+func seedRegions(t *testing.T, ctx context.Context, b Backend) {
+	t.Helper()
+	err := b.AddRegions(ctx,
+		region(province, "Jawa Barat", 1, false, true),
+		region(regency, "Kota Bandung", 2, false, true),
+		region(district, "Sukasari", 3, false, true),
+		region(villageA, "Sukajadi", 4, true, true),
+		region(villageB, "Sukarasa", 4, true, true),
+		region(villageC, "Gegerkalong", 4, false, true),
+		region(villageGone, "Sukagalih", 4, true, false),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func codesOf(regions []db.RegionModel) []string {
+	codes := make([]string, len(regions))
+	for i, r := range regions {
+		codes[i] = r.Code
+	}
+	return codes
+}
+
+func targetCodes(targets []repository.SyncTarget) []string {
+	codes := make([]string, len(targets))
+	for i, target := range targets {
+		codes[i] = target.Code
+	}
+	return codes
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func wantNotFound(t *testing.T, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("%s: got error %v, want db.ErrNotFound", what, err)
+	}
+}
+
+// near compares floats that backends may round differently
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+// sameInstant compares times that backends store with millisecond or
+// microsecond precision
+func sameInstant(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d > -time.Millisecond && d < time.Millisecond
+}
+
+func testRegionLookup(t *testing.T, ctx context.Context, b Backend) {
+	seedRegions(t, ctx, b)
+
+	found, err := b.Regions.FindByCode(ctx, villageA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != regionID(villageA) || found.Name != "Sukajadi" || found.Level != 4 || !found.HasWeatherData {
+		t.Errorf("FindByCode(%s) = %+v", villageA, found.InnerRegion)
+	}
+	if code, _ := found.RegencyCode(); code != regency {
+		t.Errorf("FindByCode(%s) regency code %q, want %q", villageA, code, regency)
+	}
+
+	_, err = b.Regions.FindByCode(ctx, villageGone)
+	wantNotFound(t, "FindByCode of an inactive region", err)
+	_, err = b.Regions.FindByCode(ctx, "99.99")
+	wantNotFound(t, "FindByCode of an unknown code", err)
+
+	many, err := b.Regions.FindByCodes(ctx, []string{villageB, villageGone, villageA, "99.99"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := codesOf(many)
+	sort.Strings(codes)
+	if want := []string{villageA, villageB}; !equal(codes, want) {
+		t.Errorf("FindByCodes = %v, want the active %v", codes, want)
+	}
+
+	popular, err := b.Regions.FindPopular(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes = codesOf(popular)
+	sort.Strings(codes)
+	if want := []string{villageA, villageB}; !equal(codes, want) {
+		t.Errorf("FindPopular = %v, want the active regions with weather data %v", codes, want)
+	}
+}
+
+func testRegionSearch(t *testing.T, ctx context.Context, b Backend) {
+	seedRegions(t, ctx, b)
+
+	tests := []struct {
+		query string
+		level int
+		limit int
+		want  []string
+	}{
+		// Weather data first, then deeper levels, then by name; the query
+		// is title-cased and inactive regions are left out
+		{"suka", 0, 10, []string{villageA, villageB, district}},
+		{"  SUKA ", 0, 10, []string{villageA, villageB, district}},
+		{"suka", 0, 1, []string{villageA}},
+		{"suka", 3, 10, []string{district}},
+		{"bandung", 0, 10, []string{regency}},
+		{"jakarta", 0, 10, nil},
+	}
+	for _, tt := range tests {
+		found, err := b.Regions.Search(ctx, tt.query, tt.level, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := codesOf(found); !equal(got, tt.want) {
+			t.Errorf("Search(%q, %d, %d) = %v, want %v", tt.query, tt.level, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func testRegionLocation(t *testing.T, ctx context.Context, b Backend) {
+	seedRegions(t, ctx, b)
+
+	updated, err := b.Regions.UpdateLocation(ctx, regionID(villageA), -6.87, 107.59, "Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lat, _ := updated.Latitude()
+	lon, _ := updated.Longitude()
+	zone, _ := updated.Timezone()
+	if !near(lat, -6.87) || !near(lon, 107.59) || zone != "Asia/Jakarta" {
+		t.Errorf("UpdateLocation stored %v, %v, %q", lat, lon, zone)
+	}
+
+	found, err := b.Regions.FindByCode(ctx, villageA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lat, ok := found.Latitude(); !ok || !near(lat, -6.87) {
+		t.Errorf("FindByCode after UpdateLocation: latitude %v, %v", lat, ok)
+	}
+
+	_, err = b.Regions.UpdateLocation(ctx, "missing", 0, 0, "")
+	wantNotFound(t, "UpdateLocation of an unknown region", err)
+}
+
+func testSyncTargets(t *testing.T, ctx context.Context, b Backend) {
+	seedRegions(t, ctx, b)
+	find := func(limit int) []string {
+		t.Helper()
+		targets, err := b.Regions.FindSyncTargets(ctx, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return targetCodes(targets)
+	}
+
+	sync := func(code string) {
+		t.Helper()
+		if _, err := b.Weather.UpsertPoints(ctx, regionID(code), forecast(time.Now().UTC(), 1, 1, 25)); err != nil {
+			t.Fatal(err)
+		}
+		// Sync times are compared to the millisecond
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// Active villages with weather data, never-synced ones by code
+	if got, want := find(10), []string{villageA, villageB}; !equal(got, want) {
+		t.Errorf("FindSyncTargets before any sync = %v, want %v", got, want)
+	}
+
+	// Villages rotate: the one synced longest ago comes first
+	sync(villageA)
+	if got, want := find(10), []string{villageB, villageA}; !equal(got, want) {
+		t.Errorf("FindSyncTargets after syncing %s = %v, want %v", villageA, got, want)
+	}
+	sync(villageB)
+	if got, want := find(10), []string{villageA, villageB}; !equal(got, want) {
+		t.Errorf("FindSyncTargets after syncing %s = %v, want %v", villageB, got, want)
+	}
+	if got, want := find(1), []string{villageA}; !equal(got, want) {
+		t.Errorf("FindSyncTargets(1) = %v, want %v", got, want)
+	}
+	sync(villageA)
+	if got, want := find(1), []string{villageB}; !equal(got, want) {
+		t.Errorf("FindSyncTargets(1) after syncing %s again = %v, want %v", villageA, got, want)
+	}
+
+	// A favorite comes first however recently it was synced
+	user, err := b.Users.Create(ctx, "targets@example.com", "Targets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.AddFavorites(ctx, db.UserFavoriteModel{InnerUserFavorite: db.InnerUserFavorite{
+		ID: "favorite-targets", UserID: user.ID, RegionID: regionID(villageA),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := find(10), []string{villageA, villageB}; !equal(got, want) {
+		t.Errorf("FindSyncTargets with a favorite = %v, want %v", got, want)
+	}
+	if got, want := find(1), []string{villageA}; !equal(got, want) {
+		t.Errorf("FindSyncTargets(1) with a favorite = %v, want %v", got, want)
+	}
+}
+
+func testProbeTargets(t *testing.T, ctx context.Context, b Backend) {
+	seedRegions(t, ctx, b)
+	find := func(unavailableBefore, availableBefore time.Time) []string {
+		t.Helper()
+		targets, err := b.Regions.FindProbeTargets(ctx, 10, unavailableBefore, availableBefore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return targetCodes(targets)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if got, want := find(past, past), []string{villageA, villageB, villageC}; !equal(got, want) {
+		t.Errorf("FindProbeTargets before any probe = %v, want %v", got, want)
+	}
+
+	if err := b.Regions.RecordProbe(ctx, regionID(villageA), true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Regions.RecordProbe(ctx, regionID(villageC), false, "404"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Regions.RecordProbeFailure(ctx, regionID(villageB), "timeout"); err != nil {
+		t.Fatal(err)
+	}
+	if got := find(past, past); len(got) != 0 {
+		t.Errorf("FindProbeTargets right after probing = %v, want none", got)
+	}
+
+	// Unavailable villages are due before available ones
+	future := time.Now().Add(time.Hour)
+	if got, want := find(future, past), []string{villageC}; !equal(got, want) {
+		t.Errorf("FindProbeTargets of unavailable villages = %v, want %v", got, want)
+	}
+
+	found, err := b.Regions.FindByCode(ctx, villageC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason, _ := found.WeatherProbeError(); found.HasWeatherData || reason != "404" {
+		t.Errorf("RecordProbe stored hasWeatherData %v, reason %q", found.HasWeatherData, reason)
+	}
+	found, err = b.Regions.FindByCode(ctx, villageB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason, _ := found.WeatherProbeError(); !found.HasWeatherData || reason != "timeout" {
+		t.Errorf("RecordProbeFailure stored hasWeatherData %v, reason %q; want the flag kept", found.HasWeatherData, reason)
+	}
+
+	wantNotFound(t, "RecordProbe of an unknown region", b.Regions.RecordProbe(ctx, "missing", true, ""))
+}
+
+// wib is the offset of Western Indonesia Time, in which local times of the
+// test villages fall
+const wib = 7 * time.Hour
+
+// forecast returns count 3-hourly points from start (UTC), with local times
+// in WIB labelled as UTC and temperatures rising by one degree from temp
+func forecast(start time.Time, analysisHours, count int, temp float64) []repository.WeatherPoint {
+	analysis := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(analysisHours) * time.Hour)
+	points := make([]repository.WeatherPoint, count)
+	for i := range points {
+		utc := start.Add(time.Duration(3*i) * time.Hour).Truncate(time.Second)
+		points[i] = repository.WeatherPoint{
+			UTCDatetime:   utc,
+			LocalDatetime: utc.Add(wib),
+			Temperature:   temp + float64(i),
+			Humidity:      70 + i,
+			WeatherDesc:   "Berawan",
+			WeatherDescEn: "Mostly Cloudy",
+			WindSpeed:     5 + float64(i),
+			WindDirection: "NW",
+			CloudCover:    60,
+			Visibility:    "> 10 km",
+			Precipitation: 0,
+			AnalysisDate:  analysis,
+		}
+	}
+	return points
+}
+
+// day1 is local midnight of the first day the history tests cover, as a
+// wall clock time labelled UTC; dayStart is the same instant in UTC
+var (
+	day1     = time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	dayStart = day1.Add(-wib)
+)
+
+func testWeatherUpsert(t *testing.T, ctx context.Context, b Backend) {
+	seedRegions(t, ctx, b)
+	id := regionID(villageA)
+
+	if latest, err := b.Weather.LatestUpdate(ctx); err != nil || !latest.IsZero() {
+		t.Errorf("LatestUpdate without data = %v, %v; want zero", latest, err)
+	}
+
+	points := forecast(dayStart, 0, 8, 20)
+	written, err := b.Weather.UpsertPoints(ctx, id, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 8 {
+		t.Errorf("UpsertPoints of new points wrote %d, want 8", written)
+	}
+	if latest, err := b.Weather.LatestUpdate(ctx); err != nil || latest.IsZero() {
+		t.Errorf("LatestUpdate after an upsert = %v, %v", latest, err)
+	}
+
+	// An older analysis never replaces a newer one
+	older := forecast(dayStart, -6, 1, 99)
+	if written, err := b.Weather.UpsertPoints(ctx, id, older); err != nil || written != 0 {
+		t.Errorf("UpsertPoints of an older analysis wrote %d, %v; want 0", written, err)
+	}
+	// The same or a newer analysis does
+	newer := forecast(dayStart, 6, 2, 30)
+	if written, err := b.Weather.UpsertPoints(ctx, id, newer); err != nil || written != 2 {
+		t.Errorf("UpsertPoints of a newer analysis wrote %d, %v; want 2", written, err)
+	}
+
+	stored, err := b.Weather.FindHistoryPoints(ctx, id, day1, day1.AddDate(0, 0, 1), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 8 {
+		t.Fatalf("FindHistoryPoints returned %d points, want 8", len(stored))
+	}
+	wantTemps := []float64{30, 31, 22, 23, 24, 25, 26, 27}
+	for i, point := range stored {
+		if !point.UtcDatetime.Equal(points[i].UTCDatetime) || !point.LocalDatetime.Equal(points[i].LocalDatetime) {
+			t.Errorf("point %d at %v (local %v), want %v", i, point.UtcDatetime, point.LocalDatetime, points[i].UTCDatetime)
+		}
+		if !near(point.Temperature, wantTemps[i]) {
+			t.Errorf("point %d temperature %v, want %v", i, point.Temperature, wantTemps[i])
+		}
+	}
+
+	if written, err := b.Weather.UpsertPoints(ctx, id, nil); err != nil || written != 0 {
+		t.Errorf("UpsertPoints of nothing = %d, %v", written, err)
+	}
+
+	byCode, err := b.Weather.FindByRegionCode(ctx, villageA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byCode) != 8 || !byCode[0].UtcDatetime.After(byCode[len(byCode)-1].UtcDatetime) {
+		t.Errorf("FindByRegionCode returned %d points; want 8, newest first", len(byCode))
+	}
+}
+
+func testWeatherHistory(t *testing.T, ctx context.Context, b Backend) {
+	seedRegions(t, ctx, b)
+	id := regionID(villageA)
+	if _, err := b.Weather.UpsertPoints(ctx, id, forecast(dayStart, 0, 16, 20)); err != nil {
+		t.Fatal(err)
+	}
+
+	limited, err := b.Weather.FindHistoryPoints(ctx, id, day1, day1.AddDate(0, 0, 2), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limited) != 3 || !near(limited[0].Temperature, 20) {
+		t.Errorf("FindHistoryPoints with limit 3 returned %d points", len(limited))
+	}
+
+	daily, err := b.Weather.AggregateHistory(ctx, id, false, day1, day1.AddDate(0, 0, 3), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDays := []repository.WeatherHistoryRow{
+		{Period: day1, MinTemperature: 20, MaxTemperature: 27, AvgTemperature: 23.5, SampleCount: 8},
+		{Period: day1.AddDate(0, 0, 1), MinTemperature: 28, MaxTemperature: 35, AvgTemperature: 31.5, SampleCount: 8},
+	}
+	checkHistory(t, "daily", daily, wantDays)
+
+	monthly, err := b.Weather.AggregateHistory(ctx, id, true, day1, day1.AddDate(0, 0, 3), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMonths := []repository.WeatherHistoryRow{
+		{Period: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), MinTemperature: 20, MaxTemperature: 35, AvgTemperature: 27.5, SampleCount: 16},
+	}
+	checkHistory(t, "monthly", monthly, wantMonths)
+}
+
+func checkHistory(t *testing.T, name string, got, want []repository.WeatherHistoryRow) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s history has %d rows, want %d: %+v", name, len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if !g.Period.Equal(w.Period) || !near(g.MinTemperature, w.MinTemperature) ||
+			!near(g.MaxTemperature, w.MaxTemperature) || !near(g.AvgTemperature, w.AvgTemperature) ||
+			g.SampleCount != w.SampleCount {
+			t.Errorf("%s history row %d = %+v, want %+v", name, i, g, w)
+		}
+		if g.WeatherDesc != "Berawan" || g.WeatherDescEn != "Mostly Cloudy" {
+			t.Errorf("%s history row %d descriptions %q, %q", name, i, g.WeatherDesc, g.WeatherDescEn)
+		}
+	}
+}
+
+func testWeatherRetention(t *testing.T, ctx context.Context, b Backend) {
+	seedRegions(t, ctx, b)
+	id := regionID(villageA)
+	if _, err := b.Weather.UpsertPoints(ctx, id, forecast(dayStart, 0, 16, 20)); err != nil {
+		t.Fatal(err)
+	}
+	cutoff := day1.AddDate(0, 0, 1)
+
+	rows, days, err := b.Weather.CompactBefore(ctx, cutoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 8 || days != 1 {
+		t.Errorf("CompactBefore = %d rows, %d days; want 8, 1", rows, days)
+	}
+	// Compacting again finds nothing with more samples to store
+	if rows, days, err := b.Weather.CompactBefore(ctx, cutoff); err != nil || rows != 0 || days != 0 {
+		t.Errorf("CompactBefore again = %d rows, %d days, %v; want nothing", rows, days, err)
+	}
+
+	deleted, err := b.Weather.DeleteBefore(ctx, cutoff, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 8 {
+		t.Errorf("DeleteBefore deleted %d points in batches of 3, want 8", deleted)
+	}
+	remaining, err := b.Weather.FindHistoryPoints(ctx, id, day1, day1.AddDate(0, 0, 2), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 8 || !remaining[0].LocalDatetime.Equal(cutoff) {
+		t.Errorf("after DeleteBefore %d points remain, want the 8 from %v on", len(remaining), cutoff)
+	}
+
+	// History reads the compacted day from its summary
+	daily, err := b.Weather.AggregateHistory(ctx, id, false, day1, day1.AddDate(0, 0, 3), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHistory(t, "daily", daily, []repository.WeatherHistoryRow{
+		{Period: day1, MinTemperature: 20, MaxTemperature: 27, AvgTemperature: 23.5, SampleCount: 8},
+		{Period: day1.AddDate(0, 0, 1), MinTemperature: 28, MaxTemperature: 35, AvgTemperature: 31.5, SampleCount: 8},
+	})
+}
+
+func testVillagePoints(t *testing.T, ctx context.Context, b Backend) {
+	seedRegions(t, ctx, b)
+	if err := b.AddRegions(ctx, region(otherVillage, "Gambir", 4, true, true)); err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []string{villageA, villageB, otherVillage} {
+		if _, err := b.Weather.UpsertPoints(ctx, regionID(code), forecast(dayStart, 0, 4, 20)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := b.Regions.UpdateLocation(ctx, regionID(villageA), -6.87, 107.59, "Asia/Jakarta"); err != nil {
+		t.Fatal(err)
+	}
+
+	// One hour into the third slot
+	at := dayStart.Add(7 * time.Hour)
+	points, err := b.Weather.FindVillagePoints(ctx, regency, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 || points[0].Code != villageA || points[1].Code != villageB {
+		t.Fatalf("FindVillagePoints(%s) = %+v, want %s and %s", regency, points, villageA, villageB)
+	}
+	p := points[0]
+	if p.RegionID != regionID(villageA) || !p.UTCDatetime.Equal(dayStart.Add(6*time.Hour)) || !near(p.Temperature, 22) {
+		t.Errorf("FindVillagePoints point %+v, want the 22° point at %v", p, dayStart.Add(6*time.Hour))
+	}
+	if p.Latitude == nil || !near(*p.Latitude, -6.87) || points[1].Latitude != nil {
+		t.Errorf("FindVillagePoints coordinates %v and %v", p.Latitude, points[1].Latitude)
+	}
+
+	// Nothing is current before the forecast starts
+	if points, err := b.Weather.FindVillagePoints(ctx, regency, dayStart.Add(-time.Hour)); err != nil || len(points) != 0 {
+		t.Errorf("FindVillagePoints before the forecast = %+v, %v", points, err)
+	}
+}
+
+func testUsers(t *testing.T, ctx context.Context, b Backend) {
+	created, err := b.Users.Create(ctx, "ani@example.com", "Ani")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" || created.Email != "ani@example.com" || created.Units != "metric" || created.Language != "id" {
+		t.Errorf("Create = %+v, want an id and the default preferences", created.InnerUser)
+	}
+	if name, _ := created.Name(); name != "Ani" {
+		t.Errorf("Create stored name %q", name)
+	}
+	if _, err := b.Users.Create(ctx, "ani@example.com", "Ani again"); err == nil {
+		t.Error("Create with a taken email succeeded")
+	}
+
+	byEmail, err := b.Users.FindByEmail(ctx, "ani@example.com")
+	if err != nil || byEmail.ID != created.ID {
+		t.Errorf("FindByEmail = %v, %v; want %s", byEmail, err, created.ID)
+	}
+	_, err = b.Users.FindByEmail(ctx, "nobody@example.com")
+	wantNotFound(t, "FindByEmail of an unknown email", err)
+	_, err = b.Users.FindByID(ctx, "missing")
+	wantNotFound(t, "FindByID of an unknown id", err)
+
+	if _, err := b.Users.UpdatePreferences(ctx, created.ID, "imperial", "knots", "en"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Users.Update(ctx, created.ID, "Ani Rahma"); err != nil {
+		t.Fatal(err)
+	}
+	found, err := b.Users.FindByID(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, _ := found.Name()
+	windUnit, _ := found.WindUnit()
+	if found.Units != "imperial" || windUnit != "knots" || found.Language != "en" || name != "Ani Rahma" {
+		t.Errorf("after updates FindByID = %+v", found.InnerUser)
+	}
+
+	_, err = b.Users.UpdatePreferences(ctx, "missing", "metric", "", "id")
+	wantNotFound(t, "UpdatePreferences of an unknown user", err)
+	_, err = b.Users.Update(ctx, "missing", "Nobody")
+	wantNotFound(t, "Update of an unknown user", err)
+}
+
+func testFavorites(t *testing.T, ctx context.Context, b Backend) {
+	seedRegions(t, ctx, b)
+	ani, err := b.Users.Create(ctx, "ani@example.com", "Ani")
+	if err != nil {
+		t.Fatal(err)
+	}
+	budi, err := b.Users.Create(ctx, "budi@example.com", "Budi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	favorite := func(id, userID, code string, order int) db.UserFavoriteModel {
+		return db.UserFavoriteModel{InnerUserFavorite: db.InnerUserFavorite{
+			ID: id, UserID: userID, RegionID: regionID(code), Order: order,
+		}}
+	}
+	err = b.AddFavorites(ctx,
+		favorite("favorite-1", ani.ID, villageB, 2),
+		favorite("favorite-2", ani.ID, villageA, 1),
+		favorite("favorite-3", budi.ID, villageC, 1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	favorites, err := b.Users.FindFavorites(ctx, ani.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(favorites) != 2 {
+		t.Fatalf("FindFavorites returned %d favorites, want 2", len(favorites))
+	}
+	for i, want := range []string{villageA, villageB} {
+		if favorites[i].RegionID != regionID(want) || favorites[i].Region().Code != want {
+			t.Errorf("favorite %d is %s, want %s in order", i, favorites[i].RegionID, want)
+		}
+	}
+
+	none, err := b.Users.FindFavorites(ctx, "missing")
+	if err != nil || len(none) != 0 {
+		t.Errorf("FindFavorites of an unknown user = %v, %v; want none", none, err)
+	}
+}
+
+func testAlerts(t *testing.T, ctx context.Context, b Backend) {
+	now := time.Now().Truncate(time.Second)
+	alert := func(id, region string, start time.Time, end *time.Time, active bool) db.WeatherAlertModel {
+		a := db.InnerWeatherAlert{
+			ID:          id,
+			Title:       "Peringatan " + id,
+			Description: "Uji",
+			Severity:    db.AlertSeverityHigh,
+			StartTime:   start,
+			EndTime:     end,
+			IsActive:    active,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if region != "" {
+			a.Region = ptr(region)
+		}
+		return db.WeatherAlertModel{InnerWeatherAlert: a}
+	}
+	err := b.AddAlerts(ctx,
+		alert("national", "", now.Add(-4*time.Hour), nil, true),
+		alert("regency", regency, now.Add(-3*time.Hour), ptr(now.Add(time.Hour)), true),
+		alert("village", villageA, now.Add(-2*time.Hour), nil, true),
+		alert("elsewhere", "31", now.Add(-time.Hour), nil, true),
+		alert("inactive", regency, now.Add(-time.Hour), nil, false),
+		alert("ended", province, now.Add(-5*time.Hour), ptr(now.Add(-time.Hour)), true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(alerts []db.WeatherAlertModel) []string {
+		ids := make([]string, len(alerts))
+		for i, a := range alerts {
+			ids[i] = a.ID
+		}
+		return ids
+	}
+
+	tests := []struct {
+		codes []string
+		want  []string
+	}{
+		// Active and not ended, soonest first
+		{nil, []string{"national", "regency", "village", "elsewhere"}},
+		// Alerts for the district, a region containing it or one within it
+		{[]string{district}, []string{"national", "regency", "village"}},
+		{[]string{villageB}, []string{"national", "regency"}},
+		{[]string{"31.71", villageA}, []string{"national", "regency", "village", "elsewhere"}},
+	}
+	for _, tt := range tests {
+		found, err := b.Alerts.FindActive(ctx, tt.codes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(found); !equal(got, tt.want) {
+			t.Errorf("FindActive(%v) = %v, want %v", tt.codes, got, tt.want)
+		}
+	}
+
+	inactive, err := b.Alerts.FindByID(ctx, "inactive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if region, _ := inactive.Region(); inactive.IsActive || region != regency || inactive.Severity != db.AlertSeverityHigh {
+		t.Errorf("FindByID(inactive) = %+v", inactive.InnerWeatherAlert)
+	}
+	_, err = b.Alerts.FindByID(ctx, "missing")
+	wantNotFound(t, "FindByID of an unknown alert", err)
+}
+
+func testSyncRuns(t *testing.T, ctx context.Context, b Backend) {
+	_, err := b.SyncRuns.FindLatestFinished(ctx)
+	wantNotFound(t, "FindLatestFinished without runs", err)
+
+	first, err := b.SyncRuns.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, finished := first.FinishedAt(); first.ID == "" || finished {
+		t.Errorf("Start = %+v, want an unfinished run with an id", first.InnerWeatherSyncRun)
+	}
+	_, err = b.SyncRuns.FindLatestFinished(ctx)
+	wantNotFound(t, "FindLatestFinished while the only run is unfinished", err)
+
+	done, err := b.SyncRuns.Finish(ctx, first.ID, 3, 72, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, reason := done.RunError(); done.RegionCount != 3 || done.PointCount != 72 || done.FailedCount != 1 || reason {
+		t.Errorf("Finish = %+v, want the counts and no error", done.InnerWeatherSyncRun)
+	}
+	finishedAt, ok := done.FinishedAt()
+	if !ok {
+		t.Fatal("Finish left the run unfinished")
+	}
+
+	latest, err := b.SyncRuns.FindLatestFinished(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at, _ := latest.FinishedAt(); latest.ID != first.ID || !sameInstant(at, finishedAt) {
+		t.Errorf("FindLatestFinished = %s at %v, want %s at %v", latest.ID, at, first.ID, finishedAt)
+	}
+
+	// A run that stops early keeps its reason and becomes the latest
+	time.Sleep(5 * time.Millisecond)
+	second, err := b.SyncRuns.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.SyncRuns.Finish(ctx, second.ID, 0, 0, 0, "failed to load sync targets"); err != nil {
+		t.Fatal(err)
+	}
+	latest, err = b.SyncRuns.FindLatestFinished(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason, _ := latest.RunError(); latest.ID != second.ID || reason != "failed to load sync targets" {
+		t.Errorf("FindLatestFinished = %s with error %q, want %s with its reason", latest.ID, reason, second.ID)
+	}
+
+	_, err = b.SyncRuns.Finish(ctx, "missing", 0, 0, 0, "")
+	wantNotFound(t, "Finish of an unknown run", err)
+}
+
+func testRegionAliases(t *testing.T, ctx context.Context, b Backend) {
+	if aliases, err := b.RegionAliases.FindAll(ctx); err != nil || len(aliases) != 0 {
+		t.Errorf("FindAll without aliases = %v, %v", aliases, err)
+	}
+
+	first, err := b.RegionAliases.Upsert(ctx, "32.04.01.2001", "32.04.01.2010", "pemekaran")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Upserting the same old code replaces its target
+	second, err := b.RegionAliases.Upsert(ctx, "32.04.01.2001", "32.04.01.2011", "koreksi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID || second.NewCode != "32.04.01.2011" {
+		t.Errorf("Upsert of an existing alias = %+v, want %s updated", second.InnerRegionAlias, first.ID)
+	}
+	if _, err := b.RegionAliases.Upsert(ctx, "32.04.01.2002", "32.04.01.2012", "pemekaran"); err != nil {
+		t.Fatal(err)
+	}
+
+	aliases, err := b.RegionAliases.FindAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].OldCode < aliases[j].OldCode })
+	if len(aliases) != 2 {
+		t.Fatalf("FindAll returned %d aliases, want 2", len(aliases))
+	}
+	reason, _ := aliases[0].Reason()
+	if aliases[0].OldCode != "32.04.01.2001" || aliases[0].NewCode != "32.04.01.2011" || reason != "koreksi" {
+		t.Errorf("FindAll alias %+v", aliases[0].InnerRegionAlias)
+	}
+}
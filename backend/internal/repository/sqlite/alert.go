@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository"
+)
+
+type alertRepository struct {
+	db *sql.DB
+}
+
+const alertColumns = `id, title, description, severity, region, "startTime", "endTime", "isActive", "createdAt", "updatedAt"`
+
+func scanAlert(row interface{ Scan(...any) error }) (*db.WeatherAlertModel, error) {
+	var alert db.WeatherAlertModel
+	a := &alert.InnerWeatherAlert
+	if err := row.Scan(
+		&a.ID, &a.Title, &a.Description, &a.Severity, &a.Region,
+		timestamp{&a.StartTime}, nullTimestamp{&a.EndTime}, &a.IsActive,
+		timestamp{&a.CreatedAt}, timestamp{&a.UpdatedAt},
+	); err != nil {
+		return nil, notFound(err)
+	}
+	return &alert, nil
+}
+
+// FindActive filters like the Postgres repository: alerts without a region,
+// for a region containing one of the codes or within one
+func (r *alertRepository) FindActive(ctx context.Context, regionCodes []string) ([]db.WeatherAlertModel, error) {
+	query := `SELECT ` + alertColumns + ` FROM weather_alerts
+WHERE "isActive" AND ("endTime" IS NULL OR "endTime" > ?)`
+	args := []any{millis(time.Now())}
+	if len(regionCodes) > 0 {
+		filters := []string{"region IS NULL"}
+		var ancestors []any
+		for _, code := range regionCodes {
+			for _, ancestor := range repository.RegionAncestors(code) {
+				ancestors = append(ancestors, ancestor)
+			}
+			filters = append(filters, "substr(region, 1, length(?) + 1) = ? || '.'")
+			args = append(args, code, code)
+		}
+		filters = append(filters, "region IN ("+placeholders(len(ancestors))+")")
+		args = append(args, ancestors...)
+		query += " AND (" + strings.Join(filters, " OR ") + ")"
+	}
+	query += ` ORDER BY "startTime"`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []db.WeatherAlertModel
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+	return alerts, rows.Err()
+}
+
+func (r *alertRepository) FindByID(ctx context.Context, id string) (*db.WeatherAlertModel, error) {
+	return scanAlert(r.db.QueryRowContext(ctx, `SELECT `+alertColumns+` FROM weather_alerts WHERE id = ?`, id))
+}
@@ -0,0 +1,127 @@
+-- Skema awal, setara dengan schema.prisma. Waktu disimpan sebagai milidetik
+-- Unix (UTC); localDatetime dan date adalah jam dinding lokal yang dilabeli
+-- UTC, seperti di Postgres.
+
+CREATE TABLE regions (
+	id                  TEXT PRIMARY KEY,
+	code                TEXT NOT NULL UNIQUE,
+	name                TEXT NOT NULL,
+	level               INTEGER NOT NULL,
+	"provinceCode"      TEXT,
+	"provinceName"      TEXT,
+	"regencyCode"       TEXT,
+	"regencyName"       TEXT,
+	"districtCode"      TEXT,
+	"districtName"      TEXT,
+	"villageCode"       TEXT,
+	"villageName"       TEXT,
+	latitude            REAL,
+	longitude           REAL,
+	timezone            TEXT,
+	"hasWeatherData"    INTEGER NOT NULL DEFAULT 0,
+	"weatherProbedAt"   INTEGER,
+	"weatherProbeError" TEXT,
+	"isActive"          INTEGER NOT NULL DEFAULT 1,
+	"createdAt"         INTEGER NOT NULL,
+	"updatedAt"         INTEGER NOT NULL
+);
+CREATE INDEX regions_level_idx ON regions (level);
+CREATE INDEX regions_province_code_idx ON regions ("provinceCode");
+CREATE INDEX regions_regency_code_idx ON regions ("regencyCode");
+CREATE INDEX regions_name_idx ON regions (name);
+CREATE INDEX regions_has_weather_data_idx ON regions ("hasWeatherData");
+CREATE INDEX regions_weather_probed_at_idx ON regions ("weatherProbedAt");
+
+CREATE TABLE region_aliases (
+	id          TEXT PRIMARY KEY,
+	"oldCode"   TEXT NOT NULL UNIQUE,
+	"newCode"   TEXT NOT NULL,
+	reason      TEXT,
+	"createdAt" INTEGER NOT NULL
+);
+CREATE INDEX region_aliases_new_code_idx ON region_aliases ("newCode");
+
+CREATE TABLE weather_data (
+	id              TEXT PRIMARY KEY,
+	"regionId"      TEXT NOT NULL REFERENCES regions (id) ON DELETE CASCADE,
+	"utcDatetime"   INTEGER NOT NULL,
+	"localDatetime" INTEGER NOT NULL,
+	temperature     REAL NOT NULL,
+	humidity        INTEGER NOT NULL,
+	"weatherDesc"   TEXT NOT NULL,
+	"weatherDescEn" TEXT NOT NULL,
+	"windSpeed"     REAL NOT NULL,
+	"windDirection" TEXT NOT NULL,
+	"cloudCover"    INTEGER NOT NULL,
+	visibility      TEXT NOT NULL,
+	precipitation   REAL NOT NULL DEFAULT 0,
+	"analysisDate"  INTEGER NOT NULL,
+	"createdAt"     INTEGER NOT NULL,
+	"updatedAt"     INTEGER NOT NULL,
+	UNIQUE ("regionId", "utcDatetime")
+);
+CREATE INDEX weather_data_local_datetime_idx ON weather_data ("localDatetime");
+
+CREATE TABLE weather_daily_summaries (
+	id               TEXT PRIMARY KEY,
+	"regionId"       TEXT NOT NULL REFERENCES regions (id) ON DELETE CASCADE,
+	date             INTEGER NOT NULL,
+	"minTemperature" REAL NOT NULL,
+	"maxTemperature" REAL NOT NULL,
+	"avgTemperature" REAL NOT NULL,
+	"avgHumidity"    REAL NOT NULL,
+	"maxWindSpeed"   REAL NOT NULL,
+	"avgCloudCover"  REAL NOT NULL,
+	"weatherDesc"    TEXT NOT NULL,
+	"weatherDescEn"  TEXT NOT NULL,
+	"sampleCount"    INTEGER NOT NULL,
+	"createdAt"      INTEGER NOT NULL,
+	"updatedAt"      INTEGER NOT NULL,
+	UNIQUE ("regionId", date)
+);
+CREATE INDEX weather_daily_summaries_date_idx ON weather_daily_summaries (date);
+
+CREATE TABLE weather_sync_runs (
+	id            TEXT PRIMARY KEY,
+	"startedAt"   INTEGER NOT NULL,
+	"finishedAt"  INTEGER,
+	"regionCount" INTEGER NOT NULL DEFAULT 0,
+	"pointCount"  INTEGER NOT NULL DEFAULT 0,
+	"failedCount" INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX weather_sync_runs_finished_at_idx ON weather_sync_runs ("finishedAt");
+
+CREATE TABLE users (
+	id          TEXT PRIMARY KEY,
+	email       TEXT NOT NULL UNIQUE,
+	name        TEXT,
+	avatar      TEXT,
+	units       TEXT NOT NULL DEFAULT 'metric',
+	"windUnit"  TEXT,
+	language    TEXT NOT NULL DEFAULT 'id',
+	"createdAt" INTEGER NOT NULL,
+	"updatedAt" INTEGER NOT NULL
+);
+
+CREATE TABLE user_favorites (
+	id          TEXT PRIMARY KEY,
+	"userId"    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	"regionId"  TEXT NOT NULL REFERENCES regions (id) ON DELETE CASCADE,
+	"order"     INTEGER NOT NULL DEFAULT 0,
+	"createdAt" INTEGER NOT NULL,
+	"updatedAt" INTEGER NOT NULL,
+	UNIQUE ("userId", "regionId")
+);
+
+CREATE TABLE weather_alerts (
+	id          TEXT PRIMARY KEY,
+	title       TEXT NOT NULL,
+	description TEXT NOT NULL,
+	severity    TEXT NOT NULL CHECK (severity IN ('LOW', 'MEDIUM', 'HIGH', 'CRITICAL')),
+	region      TEXT,
+	"startTime" INTEGER NOT NULL,
+	"endTime"   INTEGER,
+	"isActive"  INTEGER NOT NULL DEFAULT 1,
+	"createdAt" INTEGER NOT NULL,
+	"updatedAt" INTEGER NOT NULL
+);
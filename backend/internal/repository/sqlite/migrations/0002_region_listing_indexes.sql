@@ -0,0 +1,3 @@
+-- Indeks untuk paginasi keyset daftar wilayah (nama lalu kode)
+CREATE INDEX regions_province_code_level_name_code_idx ON regions ("provinceCode", level, name, code);
+CREATE INDEX regions_regency_code_level_name_code_idx ON regions ("regencyCode", level, name, code);
@@ -0,0 +1,2 @@
+-- Alasan run sinkronisasi berhenti lebih awal, NULL bila selesai
+ALTER TABLE weather_sync_runs ADD COLUMN "runError" TEXT;
@@ -0,0 +1,2 @@
+-- Indeks untuk versi data cuaca (MAX("updatedAt")) pada validator HTTP
+CREATE INDEX weather_data_updated_at_idx ON weather_data ("updatedAt");
@@ -0,0 +1,194 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository"
+)
+
+type regionRepository struct {
+	db *sql.DB
+}
+
+const regionColumns = `id, code, name, level,
+	"provinceCode", "provinceName", "regencyCode", "regencyName",
+	"districtCode", "districtName", "villageCode", "villageName",
+	latitude, longitude, timezone,
+	"hasWeatherData", "weatherProbedAt", "weatherProbeError", "isActive",
+	"createdAt", "updatedAt"`
+
+// qualified prefixes each of the columns with a table alias, for joins
+func qualified(alias, columns string) string {
+	fields := strings.Split(columns, ",")
+	for i, field := range fields {
+		fields[i] = alias + "." + strings.TrimSpace(field)
+	}
+	return strings.Join(fields, ", ")
+}
+
+// scanRegion scans regionColumns, optionally followed by more columns
+func scanRegion(row interface{ Scan(...any) error }, more ...any) (db.RegionModel, error) {
+	var region db.RegionModel
+	r := &region.InnerRegion
+	dest := []any{
+		&r.ID, &r.Code, &r.Name, &r.Level,
+		&r.ProvinceCode, &r.ProvinceName, &r.RegencyCode, &r.RegencyName,
+		&r.DistrictCode, &r.DistrictName, &r.VillageCode, &r.VillageName,
+		&r.Latitude, &r.Longitude, &r.Timezone,
+		&r.HasWeatherData, nullTimestamp{&r.WeatherProbedAt}, &r.WeatherProbeError, &r.IsActive,
+		timestamp{&r.CreatedAt}, timestamp{&r.UpdatedAt},
+	}
+	err := row.Scan(append(dest, more...)...)
+	return region, err
+}
+
+func queryRegions(ctx context.Context, database *sql.DB, query string, args ...any) ([]db.RegionModel, error) {
+	rows, err := database.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var regions []db.RegionModel
+	for rows.Next() {
+		region, err := scanRegion(rows)
+		if err != nil {
+			return nil, err
+		}
+		regions = append(regions, region)
+	}
+	return regions, rows.Err()
+}
+
+func (r *regionRepository) FindByCode(ctx context.Context, code string) (*db.RegionModel, error) {
+	region, err := scanRegion(r.db.QueryRowContext(ctx,
+		`SELECT `+regionColumns+` FROM regions WHERE code = ? AND "isActive"`, code))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &region, nil
+}
+
+func (r *regionRepository) FindByCodes(ctx context.Context, codes []string) ([]db.RegionModel, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(codes))
+	for i, code := range codes {
+		args[i] = code
+	}
+	return queryRegions(ctx, r.db,
+		`SELECT `+regionColumns+` FROM regions WHERE code IN (`+placeholders(len(codes))+`) AND "isActive"`, args...)
+}
+
+// Search matches names case-sensitively with instr, like Prisma's Contains
+// on Postgres; LIKE would ignore case
+func (r *regionRepository) Search(ctx context.Context, query string, level int, limit int) ([]db.RegionModel, error) {
+	name := strings.Title(strings.ToLower(strings.TrimSpace(query)))
+	return queryRegions(ctx, r.db, `
+SELECT `+regionColumns+`
+FROM regions
+WHERE "isActive" AND instr(name, ?1) > 0 AND (?2 <= 0 OR level = ?2)
+ORDER BY "hasWeatherData" DESC, level DESC, name
+LIMIT ?3`, name, level, limit)
+}
+
+func (r *regionRepository) FindPopular(ctx context.Context, limit int) ([]db.RegionModel, error) {
+	return queryRegions(ctx, r.db,
+		`SELECT `+regionColumns+` FROM regions WHERE "hasWeatherData" AND "isActive" LIMIT ?`, limit)
+}
+
+func (r *regionRepository) UpdateLocation(ctx context.Context, id string, latitude, longitude float64, timezone string) (*db.RegionModel, error) {
+	region, err := scanRegion(r.db.QueryRowContext(ctx, `
+UPDATE regions SET latitude = ?, longitude = ?, timezone = ?, "updatedAt" = ?
+WHERE id = ?
+RETURNING `+regionColumns, latitude, longitude, timezone, millis(time.Now()), id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &region, nil
+}
+
+// syncTargetsSQL is syncTargetsSQL of the Postgres repository
+const syncTargetsSQL = `
+SELECT r.id, r.code
+FROM regions r
+WHERE r.level = 4 AND r."isActive" AND r."hasWeatherData"
+ORDER BY
+	EXISTS (SELECT 1 FROM user_favorites f WHERE f."regionId" = r.id) DESC,
+	(SELECT MAX(w."updatedAt") FROM weather_data w WHERE w."regionId" = r.id) ASC NULLS FIRST,
+	r.code
+LIMIT ?1`
+
+func (r *regionRepository) FindSyncTargets(ctx context.Context, limit int) ([]repository.SyncTarget, error) {
+	return r.targets(ctx, syncTargetsSQL, limit)
+}
+
+// probeTargetsSQL is probeTargetsSQL of the Postgres repository
+const probeTargetsSQL = `
+SELECT id, code
+FROM regions
+WHERE level = 4 AND "isActive"
+	AND (
+		"weatherProbedAt" IS NULL
+		OR (NOT "hasWeatherData" AND "weatherProbedAt" < ?2)
+		OR ("hasWeatherData" AND "weatherProbedAt" < ?3)
+	)
+ORDER BY "weatherProbedAt" ASC NULLS FIRST, code
+LIMIT ?1`
+
+func (r *regionRepository) FindProbeTargets(ctx context.Context, limit int, unavailableBefore, availableBefore time.Time) ([]repository.SyncTarget, error) {
+	return r.targets(ctx, probeTargetsSQL, limit, millis(unavailableBefore), millis(availableBefore))
+}
+
+func (r *regionRepository) targets(ctx context.Context, query string, args ...any) ([]repository.SyncTarget, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []repository.SyncTarget
+	for rows.Next() {
+		var target repository.SyncTarget
+		if err := rows.Scan(&target.ID, &target.Code); err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, rows.Err()
+}
+
+func (r *regionRepository) RecordProbe(ctx context.Context, id string, available bool, reason string) error {
+	var failure *string
+	if reason != "" {
+		failure = &reason
+	}
+	now := millis(time.Now())
+	return r.exec(ctx, `
+UPDATE regions SET "hasWeatherData" = ?, "weatherProbedAt" = ?, "weatherProbeError" = ?, "updatedAt" = ?
+WHERE id = ?`, available, now, failure, now, id)
+}
+
+func (r *regionRepository) RecordProbeFailure(ctx context.Context, id string, reason string) error {
+	now := millis(time.Now())
+	return r.exec(ctx, `
+UPDATE regions SET "weatherProbedAt" = ?, "weatherProbeError" = ?, "updatedAt" = ?
+WHERE id = ?`, now, reason, now, id)
+}
+
+// exec runs an update of one record, returning errNotFound when there is none
+func (r *regionRepository) exec(ctx context.Context, query string, args ...any) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errNotFound
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"eamagineweather-backend/db"
+)
+
+type regionAliasRepository struct {
+	db *sql.DB
+}
+
+const aliasColumns = `id, "oldCode", "newCode", reason, "createdAt"`
+
+func scanAlias(row interface{ Scan(...any) error }) (*db.RegionAliasModel, error) {
+	var alias db.RegionAliasModel
+	a := &alias.InnerRegionAlias
+	if err := row.Scan(&a.ID, &a.OldCode, &a.NewCode, &a.Reason, timestamp{&a.CreatedAt}); err != nil {
+		return nil, notFound(err)
+	}
+	return &alias, nil
+}
+
+func (r *regionAliasRepository) FindAll(ctx context.Context) ([]db.RegionAliasModel, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+aliasColumns+` FROM region_aliases`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []db.RegionAliasModel
+	for rows.Next() {
+		alias, err := scanAlias(rows)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, *alias)
+	}
+	return aliases, rows.Err()
+}
+
+func (r *regionAliasRepository) Upsert(ctx context.Context, oldCode, newCode, reason string) (*db.RegionAliasModel, error) {
+	return scanAlias(r.db.QueryRowContext(ctx, `
+INSERT INTO region_aliases (id, "oldCode", "newCode", reason, "createdAt") VALUES (?, ?, ?, ?, ?)
+ON CONFLICT ("oldCode") DO UPDATE SET "newCode" = excluded."newCode", reason = excluded.reason
+RETURNING `+aliasColumns, newID(), oldCode, newCode, reason, millis(time.Now())))
+}
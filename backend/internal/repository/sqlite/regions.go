@@ -0,0 +1,228 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/regionimport"
+	"eamagineweather-backend/internal/regionstore"
+)
+
+// RegionStore returns a store reading the regions table, for the /regions
+// endpoints
+func (d *DB) RegionStore() regionstore.Store {
+	return &regionStore{db: d.sql}
+}
+
+type regionStore struct {
+	db *sql.DB
+}
+
+func (s *regionStore) Provinces(ctx context.Context, page regionstore.Page) (regionstore.List, error) {
+	return s.listByName(ctx, page, `level = 1 AND "isActive"`)
+}
+
+func (s *regionStore) Regencies(ctx context.Context, provinceCode string, page regionstore.Page) (regionstore.List, error) {
+	return s.listByName(ctx, page, `level = 2 AND "provinceCode" = ? AND "isActive"`, provinceCode)
+}
+
+func (s *regionStore) Villages(ctx context.Context, regencyCode string, page regionstore.Page) (regionstore.List, error) {
+	return s.listByName(ctx, page,
+		`level = 4 AND "regencyCode" = ? AND "hasWeatherData" AND "isActive"`, regencyCode)
+}
+
+func (s *regionStore) Search(ctx context.Context, query string, level int, page regionstore.Page) (regionstore.List, error) {
+	after, ok, err := regionstore.ParseCursor(page.Cursor)
+	if err != nil {
+		return regionstore.List{}, err
+	}
+	name := strings.Title(strings.ToLower(strings.TrimSpace(query)))
+	l := listing{
+		where: `"isActive" AND instr(name, ?) > 0 AND (? <= 0 OR level = ?)`,
+		args:  []any{name, level, level},
+		order: `"hasWeatherData" DESC, level DESC, name, code`,
+	}
+	if ok {
+		l.after = `("hasWeatherData" < ? OR ("hasWeatherData" = ? AND (level < ? OR (level = ? AND (name, code) > (?, ?)))))`
+		l.afterArgs = []any{after.HasWeatherData, after.HasWeatherData, after.Level, after.Level, after.Name, after.Code}
+	}
+	return s.list(ctx, page, l)
+}
+
+func (s *regionStore) Version(ctx context.Context) (regionstore.Version, error) {
+	var count int
+	var modified sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*), MAX("updatedAt") FROM regions`).Scan(&count, &modified); err != nil {
+		return regionstore.Version{}, err
+	}
+	if !modified.Valid {
+		return regionstore.Version{Tag: "empty"}, nil
+	}
+	return regionstore.Version{
+		Tag:      fmt.Sprintf("%d-%d", count, modified.Int64),
+		Modified: time.UnixMilli(modified.Int64),
+	}, nil
+}
+
+// listing is a query over the regions table: the rows matching where,
+// paged by after in order
+type listing struct {
+	where     string
+	args      []any
+	after     string
+	afterArgs []any
+	order     string
+}
+
+// listByName pages through the regions matching where by name
+func (s *regionStore) listByName(ctx context.Context, page regionstore.Page, where string, args ...any) (regionstore.List, error) {
+	after, ok, err := regionstore.ParseCursor(page.Cursor)
+	if err != nil {
+		return regionstore.List{}, err
+	}
+	l := listing{where: where, args: args, order: "name, code"}
+	if ok {
+		l.after = "(name, code) > (?, ?)"
+		l.afterArgs = []any{after.Name, after.Code}
+	}
+	return s.list(ctx, page, l)
+}
+
+func (s *regionStore) list(ctx context.Context, page regionstore.Page, l listing) (regionstore.List, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM regions WHERE `+l.where, l.args...).Scan(&total); err != nil {
+		return regionstore.List{}, err
+	}
+
+	where, args := l.where, l.args
+	if l.after != "" {
+		where += " AND " + l.after
+		args = append(append([]any{}, args...), l.afterArgs...)
+	}
+	limit := page.FetchLimit()
+	if limit == 0 {
+		limit = -1 // No limit in SQLite
+	}
+	models, err := queryRegions(ctx, s.db, `SELECT `+regionColumns+` FROM regions WHERE `+where+`
+ORDER BY `+l.order+`
+LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return regionstore.List{}, err
+	}
+	return regionstore.NewList(regionstore.FromModels(models), total, page), nil
+}
+
+// ImportRegions writes regions read with regionimport.Load in one
+// transaction, counting them into the report like the Prisma import does.
+// With DeactivateMissing, active regions missing from the list are
+// deactivated; moved codes are not detected, so no aliases are created.
+func (d *DB) ImportRegions(ctx context.Context, regions []regionimport.Region, opts regionimport.Options, report *regionimport.Report) error {
+	tx, err := d.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stored := make(map[string]db.RegionModel)
+	rows, err := tx.QueryContext(ctx, `SELECT `+regionColumns+` FROM regions`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		region, err := scanRegion(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		stored[region.Code] = region
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := millis(time.Now())
+	listed := make(map[string]bool, len(regions))
+	for _, region := range regions {
+		listed[region.Code] = true
+		existing, ok := stored[region.Code]
+		switch {
+		case !ok:
+			_, err = tx.ExecContext(ctx, `
+INSERT INTO regions (
+	id, code, name, level,
+	"provinceCode", "provinceName", "regencyCode", "regencyName",
+	"districtCode", "districtName", "villageCode", "villageName",
+	"hasWeatherData", "createdAt", "updatedAt"
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				newID(), region.Code, region.Name, region.Level,
+				region.ProvinceCode, region.ProvinceName, region.RegencyCode, region.RegencyName,
+				region.DistrictCode, region.DistrictName, region.VillageCode, region.VillageName,
+				region.Level == 4, now, now)
+			report.Inserted++
+		case !matches(existing, region):
+			_, err = tx.ExecContext(ctx, `
+UPDATE regions SET
+	name = ?, level = ?,
+	"provinceCode" = ?, "provinceName" = ?, "regencyCode" = ?, "regencyName" = ?,
+	"districtCode" = ?, "districtName" = ?, "villageCode" = ?, "villageName" = ?,
+	"isActive" = 1, "updatedAt" = ?
+WHERE code = ?`,
+				region.Name, region.Level,
+				region.ProvinceCode, region.ProvinceName, region.RegencyCode, region.RegencyName,
+				region.DistrictCode, region.DistrictName, region.VillageCode, region.VillageName,
+				now, region.Code)
+			report.Updated++
+		default:
+			report.Unchanged++
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.DeactivateMissing {
+		var missing []any
+		for code, region := range stored {
+			if region.IsActive && !listed[code] {
+				missing = append(missing, code)
+			}
+		}
+		for start := 0; start < len(missing); start += 500 {
+			batch := missing[start:min(start+500, len(missing))]
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE regions SET "isActive" = 0, "updatedAt" = ? WHERE code IN (`+placeholders(len(batch))+`)`,
+				append([]any{now}, batch...)...); err != nil {
+				return err
+			}
+		}
+		report.Deactivated = len(missing)
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+	return tx.Commit()
+}
+
+// matches reports whether a stored region is active and already has the
+// imported name and hierarchy
+func matches(stored db.RegionModel, region regionimport.Region) bool {
+	s := stored.InnerRegion
+	return s.IsActive && s.Name == region.Name && s.Level == region.Level &&
+		same(s.ProvinceCode, region.ProvinceCode) && same(s.ProvinceName, region.ProvinceName) &&
+		same(s.RegencyCode, region.RegencyCode) && same(s.RegencyName, region.RegencyName) &&
+		same(s.DistrictCode, region.DistrictCode) && same(s.DistrictName, region.DistrictName) &&
+		same(s.VillageCode, region.VillageCode) && same(s.VillageName, region.VillageName)
+}
+
+func same(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,224 @@
+// Package sqlite implements the repository interfaces on SQLite, through the
+// pure-Go modernc.org/sqlite driver, for single-node and development
+// deployments that should not need Postgres or Prisma code generation. The
+// schema is created by the embedded migrations when the database is opened.
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository"
+
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// DB is an open SQLite database
+type DB struct {
+	sql *sql.DB
+}
+
+// IsURL reports whether a DATABASE_URL names a SQLite database:
+// sqlite:path, sqlite:///absolute/path or file:path
+func IsURL(databaseURL string) bool {
+	return strings.HasPrefix(databaseURL, "sqlite:") || strings.HasPrefix(databaseURL, "file:")
+}
+
+// Open opens the database a sqlite: or file: URL names, creating the file
+// if needed, and applies pending migrations
+func Open(databaseURL string) (*DB, error) {
+	dsn, err := dataSourceName(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	database, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer at a time; a single connection serializes
+	// writes instead of failing them with SQLITE_BUSY, and keeps :memory:
+	// databases from being one per connection
+	database.SetMaxOpenConns(1)
+
+	d := &DB{sql: database}
+	if err := d.migrate(context.Background()); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("migrate %s: %w", databaseURL, err)
+	}
+	return d, nil
+}
+
+// dataSourceName turns a DATABASE_URL into a driver DSN with foreign keys
+// enforced and WAL journaling
+func dataSourceName(databaseURL string) (string, error) {
+	path, query, _ := strings.Cut(databaseURL, "?")
+	switch {
+	case strings.HasPrefix(path, "sqlite://"):
+		path = strings.TrimPrefix(path, "sqlite://")
+	case strings.HasPrefix(path, "sqlite:"):
+		path = strings.TrimPrefix(path, "sqlite:")
+	default:
+		path = strings.TrimPrefix(path, "file:")
+	}
+	if path == "" {
+		return "", fmt.Errorf("DATABASE_URL %q has no SQLite path", databaseURL)
+	}
+
+	params := []string{"_pragma=foreign_keys(1)", "_pragma=busy_timeout(5000)"}
+	if path != ":memory:" {
+		params = append(params, "_pragma=journal_mode(WAL)")
+	}
+	if query != "" {
+		params = append(params, query)
+	}
+	return "file:" + path + "?" + strings.Join(params, "&"), nil
+}
+
+// migrate applies the embedded migrations not applied yet, in file name
+// order, each in its own transaction
+func (d *DB) migrate(ctx context.Context) error {
+	if _, err := d.sql.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version      INTEGER PRIMARY KEY,
+	"appliedAt"  INTEGER NOT NULL
+)`); err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		base := strings.TrimPrefix(name, "migrations/")
+		version, err := strconv.Atoi(strings.SplitN(base, "_", 2)[0])
+		if err != nil {
+			return fmt.Errorf("migration %s has no version prefix", base)
+		}
+
+		var applied int
+		if err := d.sql.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+
+		script, err := migrations.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		err = d.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return fmt.Errorf("%s: %w", base, err)
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, "appliedAt") VALUES (?, ?)`, version, millis(time.Now()))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database
+func (d *DB) Close() error {
+	return d.sql.Close()
+}
+
+func (d *DB) Regions() repository.RegionRepository            { return &regionRepository{d.sql} }
+func (d *DB) Weather() repository.WeatherRepository           { return &weatherRepository{d.sql} }
+func (d *DB) Users() repository.UserRepository                { return &userRepository{d.sql} }
+func (d *DB) SyncRuns() repository.SyncRunRepository          { return &syncRunRepository{d.sql} }
+func (d *DB) Alerts() repository.AlertRepository              { return &alertRepository{d.sql} }
+func (d *DB) RegionAliases() repository.RegionAliasRepository { return &regionAliasRepository{d.sql} }
+
+// inTx runs fn in a transaction, committing when it returns nil
+func (d *DB) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := d.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// newID returns a random record id, in place of Prisma's cuid()
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "c" + hex.EncodeToString(b)
+}
+
+// Times are stored as Unix milliseconds, so they sort and compare as
+// integers
+
+func millis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+// timestamp scans a millisecond column into a time.Time
+type timestamp struct {
+	dst *time.Time
+}
+
+func (t timestamp) Scan(src any) error {
+	ms, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("timestamp: unexpected %T", src)
+	}
+	*t.dst = time.UnixMilli(ms).UTC()
+	return nil
+}
+
+// nullTimestamp scans a nullable millisecond column into a *time.Time
+type nullTimestamp struct {
+	dst **time.Time
+}
+
+func (t nullTimestamp) Scan(src any) error {
+	if src == nil {
+		*t.dst = nil
+		return nil
+	}
+	var value time.Time
+	if err := (timestamp{&value}).Scan(src); err != nil {
+		return err
+	}
+	*t.dst = &value
+	return nil
+}
+
+var errNotFound = db.ErrNotFound
+
+// notFound maps sql.ErrNoRows to the error Prisma returns for a missing
+// record, which services check with db.IsErrNotFound
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return errNotFound
+	}
+	return err
+}
+
+// placeholders returns n comma separated ? placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository/repositorytest"
+)
+
+func TestRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Backend {
+		d, err := Open("sqlite::memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { d.Close() })
+		return repositorytest.Backend{
+			Regions:       d.Regions(),
+			Weather:       d.Weather(),
+			Users:         d.Users(),
+			SyncRuns:      d.SyncRuns(),
+			Alerts:        d.Alerts(),
+			RegionAliases: d.RegionAliases(),
+			AddRegions:    d.addRegions,
+			AddFavorites:  d.addFavorites,
+			AddAlerts:     d.addAlerts,
+		}
+	})
+}
+
+func (d *DB) addRegions(ctx context.Context, regions ...db.RegionModel) error {
+	now := millis(time.Now())
+	for _, r := range regions {
+		_, err := d.sql.ExecContext(ctx, `
+			INSERT INTO regions (id, code, name, level, "provinceCode", "provinceName",
+				"regencyCode", "regencyName", "districtCode", "districtName",
+				"villageCode", "villageName", "hasWeatherData", "isActive", "createdAt", "updatedAt")
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.ID, r.Code, r.InnerRegion.Name, r.Level, r.InnerRegion.ProvinceCode, r.InnerRegion.ProvinceName,
+			r.InnerRegion.RegencyCode, r.InnerRegion.RegencyName, r.InnerRegion.DistrictCode, r.InnerRegion.DistrictName,
+			r.InnerRegion.VillageCode, r.InnerRegion.VillageName, r.HasWeatherData, r.IsActive, now, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DB) addFavorites(ctx context.Context, favorites ...db.UserFavoriteModel) error {
+	now := millis(time.Now())
+	for _, f := range favorites {
+		_, err := d.sql.ExecContext(ctx, `
+			INSERT INTO user_favorites (id, "userId", "regionId", "order", "createdAt", "updatedAt")
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			f.ID, f.UserID, f.RegionID, f.Order, now, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DB) addAlerts(ctx context.Context, alerts ...db.WeatherAlertModel) error {
+	for _, a := range alerts {
+		var end *int64
+		if a.InnerWeatherAlert.EndTime != nil {
+			end = new(int64)
+			*end = millis(*a.InnerWeatherAlert.EndTime)
+		}
+		_, err := d.sql.ExecContext(ctx, `
+			INSERT INTO weather_alerts (id, title, description, severity, region,
+				"startTime", "endTime", "isActive", "createdAt", "updatedAt")
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			a.ID, a.Title, a.Description, string(a.Severity), a.InnerWeatherAlert.Region,
+			millis(a.StartTime), end, a.IsActive, millis(a.CreatedAt), millis(a.UpdatedAt))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"eamagineweather-backend/db"
+)
+
+type syncRunRepository struct {
+	db *sql.DB
+}
+
+const syncRunColumns = `id, "startedAt", "finishedAt", "regionCount", "pointCount", "failedCount", "runError"`
+
+func scanSyncRun(row *sql.Row) (*db.WeatherSyncRunModel, error) {
+	var run db.WeatherSyncRunModel
+	s := &run.InnerWeatherSyncRun
+	if err := row.Scan(
+		&s.ID, timestamp{&s.StartedAt}, nullTimestamp{&s.FinishedAt}, &s.RegionCount, &s.PointCount, &s.FailedCount, &s.RunError,
+	); err != nil {
+		return nil, notFound(err)
+	}
+	return &run, nil
+}
+
+func (r *syncRunRepository) Start(ctx context.Context) (*db.WeatherSyncRunModel, error) {
+	return scanSyncRun(r.db.QueryRowContext(ctx, `
+INSERT INTO weather_sync_runs (id, "startedAt") VALUES (?, ?)
+RETURNING `+syncRunColumns, newID(), millis(time.Now())))
+}
+
+func (r *syncRunRepository) Finish(ctx context.Context, id string, regions, points, failed int, reason string) (*db.WeatherSyncRunModel, error) {
+	var runError *string
+	if reason != "" {
+		runError = &reason
+	}
+	return scanSyncRun(r.db.QueryRowContext(ctx, `
+UPDATE weather_sync_runs SET "finishedAt" = ?, "regionCount" = ?, "pointCount" = ?, "failedCount" = ?, "runError" = ?
+WHERE id = ?
+RETURNING `+syncRunColumns, millis(time.Now()), regions, points, failed, runError, id))
+}
+
+func (r *syncRunRepository) FindLatestFinished(ctx context.Context) (*db.WeatherSyncRunModel, error) {
+	return scanSyncRun(r.db.QueryRowContext(ctx, `
+SELECT `+syncRunColumns+` FROM weather_sync_runs
+WHERE "finishedAt" <= ?
+ORDER BY "finishedAt" DESC
+LIMIT 1`, millis(time.Now())))
+}
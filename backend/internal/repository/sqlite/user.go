@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"eamagineweather-backend/db"
+)
+
+type userRepository struct {
+	db *sql.DB
+}
+
+const userColumns = `id, email, name, avatar, units, "windUnit", language, "createdAt", "updatedAt"`
+
+func scanUser(row interface{ Scan(...any) error }) (*db.UserModel, error) {
+	var user db.UserModel
+	u := &user.InnerUser
+	if err := row.Scan(
+		&u.ID, &u.Email, &u.Name, &u.Avatar, &u.Units, &u.WindUnit, &u.Language,
+		timestamp{&u.CreatedAt}, timestamp{&u.UpdatedAt},
+	); err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*db.UserModel, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = ?`, email))
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id string) (*db.UserModel, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+}
+
+func (r *userRepository) UpdatePreferences(ctx context.Context, id, units, windUnit, language string) (*db.UserModel, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `
+UPDATE users SET units = ?, "windUnit" = ?, language = ?, "updatedAt" = ?
+WHERE id = ?
+RETURNING `+userColumns, units, windUnit, language, millis(time.Now()), id))
+}
+
+func (r *userRepository) Create(ctx context.Context, email string, name string) (*db.UserModel, error) {
+	now := millis(time.Now())
+	return scanUser(r.db.QueryRowContext(ctx, `
+INSERT INTO users (id, email, name, "createdAt", "updatedAt")
+VALUES (?, ?, ?, ?, ?)
+RETURNING `+userColumns, newID(), email, name, now, now))
+}
+
+func (r *userRepository) Update(ctx context.Context, id string, name string) (*db.UserModel, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `
+UPDATE users SET name = ?, "updatedAt" = ?
+WHERE id = ?
+RETURNING `+userColumns, name, millis(time.Now()), id))
+}
+
+func (r *userRepository) FindFavorites(ctx context.Context, userID string) ([]db.UserFavoriteModel, error) {
+	rows, err := r.db.QueryContext(ctx, `
+SELECT `+qualified("r", regionColumns)+`, f.id, f."userId", f."regionId", f."order", f."createdAt", f."updatedAt"
+FROM user_favorites f
+JOIN regions r ON r.id = f."regionId"
+WHERE f."userId" = ?
+ORDER BY f."order"`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var favorites []db.UserFavoriteModel
+	for rows.Next() {
+		var favorite db.UserFavoriteModel
+		f := &favorite.InnerUserFavorite
+		region, err := scanRegion(rows,
+			&f.ID, &f.UserID, &f.RegionID, &f.Order, timestamp{&f.CreatedAt}, timestamp{&f.UpdatedAt})
+		if err != nil {
+			return nil, err
+		}
+		favorite.RelationsUserFavorite.Region = &region
+		favorites = append(favorites, favorite)
+	}
+	return favorites, rows.Err()
+}
@@ -0,0 +1,414 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository"
+)
+
+type weatherRepository struct {
+	db *sql.DB
+}
+
+const pointColumns = `id, "regionId", "utcDatetime", "localDatetime",
+	temperature, humidity, "weatherDesc", "weatherDescEn",
+	"windSpeed", "windDirection", "cloudCover", visibility, precipitation,
+	"analysisDate", "createdAt", "updatedAt"`
+
+func (r *weatherRepository) queryPoints(ctx context.Context, query string, args ...any) ([]db.WeatherDataModel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []db.WeatherDataModel
+	for rows.Next() {
+		var point db.WeatherDataModel
+		p := &point.InnerWeatherData
+		if err := rows.Scan(
+			&p.ID, &p.RegionID, timestamp{&p.UtcDatetime}, timestamp{&p.LocalDatetime},
+			&p.Temperature, &p.Humidity, &p.WeatherDesc, &p.WeatherDescEn,
+			&p.WindSpeed, &p.WindDirection, &p.CloudCover, &p.Visibility, &p.Precipitation,
+			timestamp{&p.AnalysisDate}, timestamp{&p.CreatedAt}, timestamp{&p.UpdatedAt},
+		); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+func (r *weatherRepository) FindByRegionCode(ctx context.Context, regionCode string) ([]db.WeatherDataModel, error) {
+	var regionID string
+	if err := r.db.QueryRowContext(ctx, `SELECT id FROM regions WHERE code = ?`, regionCode).Scan(&regionID); err != nil {
+		return nil, notFound(err)
+	}
+	return r.queryPoints(ctx, `
+SELECT `+pointColumns+` FROM weather_data
+WHERE "regionId" = ?
+ORDER BY "utcDatetime" DESC
+LIMIT 24`, regionID)
+}
+
+func (r *weatherRepository) Create(ctx context.Context, regionID string, temp float64, humidity int, desc string) (*db.WeatherDataModel, error) {
+	now := time.Now().UTC()
+	points, err := r.queryPoints(ctx, `
+INSERT INTO weather_data (
+	id, "regionId", "utcDatetime", "localDatetime",
+	temperature, humidity, "weatherDesc", "weatherDescEn",
+	"windSpeed", "windDirection", "cloudCover", visibility,
+	"analysisDate", "createdAt", "updatedAt"
+) VALUES (?1, ?2, ?3, ?3, ?4, ?5, ?6, '', 0, '', 0, '', ?3, ?3, ?3)
+RETURNING `+pointColumns, newID(), regionID, millis(now), temp, humidity, desc)
+	if err != nil {
+		return nil, err
+	}
+	return &points[0], nil
+}
+
+// compactSelectSQL aggregates raw points before the cutoff ?1 per region and
+// local day, the SELECT of the Postgres compactSQL. SQLite has no mode(), so
+// the dominant descriptions come from subqueries.
+const compactSelectSQL = `
+WITH raw AS (
+	SELECT *, ("localDatetime" / 86400000) * 86400000 AS day
+	FROM weather_data
+	WHERE "localDatetime" < ?1
+)
+SELECT
+	"regionId", day,
+	MIN(temperature), MAX(temperature), AVG(temperature),
+	AVG(humidity), MAX("windSpeed"), AVG("cloudCover"),
+	(SELECT m."weatherDesc" FROM raw m WHERE m."regionId" = r."regionId" AND m.day = r.day
+		GROUP BY 1 ORDER BY COUNT(*) DESC, 1 LIMIT 1),
+	(SELECT m."weatherDescEn" FROM raw m WHERE m."regionId" = r."regionId" AND m.day = r.day
+		GROUP BY 1 ORDER BY COUNT(*) DESC, 1 LIMIT 1),
+	COUNT(*)
+FROM raw r
+GROUP BY "regionId", day`
+
+// upsertSummarySQL stores one daily summary unless a summary with at least
+// as many samples is stored already, as in the Postgres compactSQL
+const upsertSummarySQL = `
+INSERT INTO weather_daily_summaries (
+	id, "regionId", date,
+	"minTemperature", "maxTemperature", "avgTemperature",
+	"avgHumidity", "maxWindSpeed", "avgCloudCover",
+	"weatherDesc", "weatherDescEn", "sampleCount",
+	"createdAt", "updatedAt"
+) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?13)
+ON CONFLICT ("regionId", date) DO UPDATE SET
+	"minTemperature" = excluded."minTemperature",
+	"maxTemperature" = excluded."maxTemperature",
+	"avgTemperature" = excluded."avgTemperature",
+	"avgHumidity"    = excluded."avgHumidity",
+	"maxWindSpeed"   = excluded."maxWindSpeed",
+	"avgCloudCover"  = excluded."avgCloudCover",
+	"weatherDesc"    = excluded."weatherDesc",
+	"weatherDescEn"  = excluded."weatherDescEn",
+	"sampleCount"    = excluded."sampleCount",
+	"updatedAt"      = excluded."updatedAt"
+WHERE weather_daily_summaries."sampleCount" < excluded."sampleCount"`
+
+func (r *weatherRepository) CompactBefore(ctx context.Context, cutoff time.Time) (rows int, days int, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	summaries, err := querySummaries(ctx, tx, compactSelectSQL, true, millis(cutoff))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	now := millis(time.Now())
+	for _, s := range summaries {
+		result, err := tx.ExecContext(ctx, upsertSummarySQL,
+			s.regionID+":"+s.Period.Format("20060102"), s.regionID, millis(s.Period),
+			s.MinTemperature, s.MaxTemperature, s.AvgTemperature,
+			s.AvgHumidity, s.MaxWindSpeed, s.AvgCloudCover,
+			s.WeatherDesc, s.WeatherDescEn, s.SampleCount, now)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			rows += s.SampleCount
+			days++
+		}
+	}
+	return rows, days, tx.Commit()
+}
+
+// summary is a history row with the region it belongs to
+type summary struct {
+	regionID string
+	repository.WeatherHistoryRow
+}
+
+// querySummaries scans rows of period and aggregates, preceded by the region
+// id when withRegion is set
+func querySummaries(ctx context.Context, tx interface {
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}, query string, withRegion bool, args ...any) ([]summary, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []summary
+	for rows.Next() {
+		var s summary
+		dest := []any{
+			timestamp{&s.Period},
+			&s.MinTemperature, &s.MaxTemperature, &s.AvgTemperature,
+			&s.AvgHumidity, &s.MaxWindSpeed, &s.AvgCloudCover,
+			&s.WeatherDesc, &s.WeatherDescEn, &s.SampleCount,
+		}
+		if withRegion {
+			dest = append([]any{&s.regionID}, dest...)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// deleteBatchSQL is deleteBatchSQL of the Postgres repository
+const deleteBatchSQL = `
+DELETE FROM weather_data
+WHERE id IN (
+	SELECT id FROM weather_data
+	WHERE "localDatetime" < ?
+	LIMIT ?
+)`
+
+func (r *weatherRepository) DeleteBefore(ctx context.Context, cutoff time.Time, batchSize int) (int, error) {
+	total := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		result, err := r.db.ExecContext(ctx, deleteBatchSQL, millis(cutoff), batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += int(n)
+		if int(n) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+func (r *weatherRepository) FindHistoryPoints(ctx context.Context, regionID string, from, to time.Time, limit int) ([]db.WeatherDataModel, error) {
+	return r.queryPoints(ctx, `
+SELECT `+pointColumns+` FROM weather_data
+WHERE "regionId" = ? AND "localDatetime" >= ? AND "localDatetime" < ?
+ORDER BY "localDatetime"
+LIMIT ?`, regionID, millis(from), millis(to), limit)
+}
+
+// historyDaysCTE is historyDaysCTE of the Postgres repository: one row per
+// local day for region ?1, summaries from day ?2 up to day ?3 and raw points
+// from ?4 up to ?5 for days without a summary
+const historyDaysCTE = `
+WITH raw AS (
+	SELECT w.*, (w."localDatetime" / 86400000) * 86400000 AS day
+	FROM weather_data w
+	WHERE w."regionId" = ?1 AND w."localDatetime" >= ?4 AND w."localDatetime" < ?5
+		AND NOT EXISTS (
+			SELECT 1 FROM weather_daily_summaries s
+			WHERE s."regionId" = w."regionId" AND s.date = (w."localDatetime" / 86400000) * 86400000
+		)
+),
+days AS (
+	SELECT
+		date AS period,
+		"minTemperature" AS min_t, "maxTemperature" AS max_t, "avgTemperature" AS avg_t,
+		"avgHumidity" AS avg_h, "maxWindSpeed" AS max_ws, "avgCloudCover" AS avg_cc,
+		"weatherDesc" AS desc_id, "weatherDescEn" AS desc_en, "sampleCount" AS samples
+	FROM weather_daily_summaries
+	WHERE "regionId" = ?1 AND date >= ?2 AND date < ?3
+	UNION ALL
+	SELECT
+		day,
+		MIN(temperature), MAX(temperature), AVG(temperature),
+		AVG(humidity), MAX("windSpeed"), AVG("cloudCover"),
+		(SELECT m."weatherDesc" FROM raw m WHERE m.day = r.day GROUP BY 1 ORDER BY COUNT(*) DESC, 1 LIMIT 1),
+		(SELECT m."weatherDescEn" FROM raw m WHERE m.day = r.day GROUP BY 1 ORDER BY COUNT(*) DESC, 1 LIMIT 1),
+		COUNT(*)
+	FROM raw r
+	GROUP BY day
+)`
+
+const historyDailySQL = historyDaysCTE + `
+SELECT period, min_t, max_t, avg_t, avg_h, max_ws, avg_cc, desc_id, desc_en, samples
+FROM days
+ORDER BY period
+LIMIT ?6`
+
+const historyMonthlySQL = historyDaysCTE + `,
+months AS (
+	SELECT *, CAST(strftime('%s', period / 1000, 'unixepoch', 'start of month') AS INTEGER) * 1000 AS month
+	FROM days
+)
+SELECT
+	month,
+	MIN(min_t), MAX(max_t),
+	SUM(avg_t * samples) / SUM(samples),
+	SUM(avg_h * samples) / SUM(samples),
+	MAX(max_ws),
+	SUM(avg_cc * samples) / SUM(samples),
+	(SELECT m.desc_id FROM months m WHERE m.month = o.month GROUP BY 1 ORDER BY COUNT(*) DESC, 1 LIMIT 1),
+	(SELECT m.desc_en FROM months m WHERE m.month = o.month GROUP BY 1 ORDER BY COUNT(*) DESC, 1 LIMIT 1),
+	SUM(samples)
+FROM months o
+GROUP BY month
+ORDER BY month
+LIMIT ?6`
+
+func (r *weatherRepository) AggregateHistory(ctx context.Context, regionID string, monthly bool, from, to time.Time, limit int) ([]repository.WeatherHistoryRow, error) {
+	query := historyDailySQL
+	if monthly {
+		query = historyMonthlySQL
+	}
+
+	summaries, err := querySummaries(ctx, r.db, query, false,
+		regionID, millis(truncateDay(from)), millis(truncateDay(to)), millis(from), millis(to), limit)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]repository.WeatherHistoryRow, len(summaries))
+	for i, s := range summaries {
+		rows[i] = s.WeatherHistoryRow
+	}
+	return rows, nil
+}
+
+// upsertPointSQL stores one point like upsertPointsSQL of the Postgres
+// repository, unless the stored point comes from a newer analysis
+const upsertPointSQL = `
+INSERT INTO weather_data (
+	id, "regionId", "utcDatetime", "localDatetime",
+	temperature, humidity, "weatherDesc", "weatherDescEn",
+	"windSpeed", "windDirection", "cloudCover", visibility, precipitation,
+	"analysisDate", "createdAt", "updatedAt"
+) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14, ?15, ?15)
+ON CONFLICT ("regionId", "utcDatetime") DO UPDATE SET
+	"localDatetime" = excluded."localDatetime",
+	temperature     = excluded.temperature,
+	humidity        = excluded.humidity,
+	"weatherDesc"   = excluded."weatherDesc",
+	"weatherDescEn" = excluded."weatherDescEn",
+	"windSpeed"     = excluded."windSpeed",
+	"windDirection" = excluded."windDirection",
+	"cloudCover"    = excluded."cloudCover",
+	visibility      = excluded.visibility,
+	precipitation   = excluded.precipitation,
+	"analysisDate"  = excluded."analysisDate",
+	"updatedAt"     = excluded."updatedAt"
+WHERE weather_data."analysisDate" <= excluded."analysisDate"`
+
+func (r *weatherRepository) UpsertPoints(ctx context.Context, regionID string, points []repository.WeatherPoint) (int, error) {
+	if len(points) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, upsertPointSQL)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	written := 0
+	now := millis(time.Now())
+	for _, p := range points {
+		utc := p.UTCDatetime.UTC()
+		result, err := stmt.ExecContext(ctx,
+			regionID+":"+utc.Format("200601021504"), regionID, millis(utc), millis(p.LocalDatetime),
+			p.Temperature, p.Humidity, p.WeatherDesc, p.WeatherDescEn,
+			p.WindSpeed, p.WindDirection, p.CloudCover, p.Visibility, p.Precipitation,
+			millis(p.AnalysisDate), now)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := result.RowsAffected()
+		written += int(n)
+	}
+	return written, tx.Commit()
+}
+
+// villagePointsSQL is villagePointsSQL of the Postgres repository, with a
+// window function in place of DISTINCT ON
+const villagePointsSQL = `
+SELECT "regionId", code, latitude, longitude, "utcDatetime", temperature, humidity,
+	"windSpeed", precipitation, "weatherDesc", "weatherDescEn"
+FROM (
+	SELECT
+		w."regionId", r.code, r.latitude, r.longitude,
+		w."utcDatetime", w.temperature, w.humidity, w."windSpeed", w.precipitation,
+		w."weatherDesc", w."weatherDescEn",
+		ROW_NUMBER() OVER (PARTITION BY r.code ORDER BY w."utcDatetime" DESC) AS latest
+	FROM weather_data w
+	JOIN regions r ON r.id = w."regionId"
+	WHERE r.level = 4 AND r."isActive" AND substr(r.code, 1, length(?1) + 1) = ?1 || '.'
+		AND w."utcDatetime" <= ?2 AND w."utcDatetime" > ?2 - 10800000
+)
+WHERE latest = 1
+ORDER BY code`
+
+func (r *weatherRepository) FindVillagePoints(ctx context.Context, parentCode string, at time.Time) ([]repository.VillagePoint, error) {
+	rows, err := r.db.QueryContext(ctx, villagePointsSQL, parentCode, millis(at))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []repository.VillagePoint
+	for rows.Next() {
+		var p repository.VillagePoint
+		if err := rows.Scan(
+			&p.RegionID, &p.Code, &p.Latitude, &p.Longitude, timestamp{&p.UTCDatetime},
+			&p.Temperature, &p.Humidity, &p.WindSpeed, &p.Precipitation, &p.WeatherDesc, &p.WeatherDescEn,
+		); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// truncateDay returns the calendar date of a wall clock time labelled as UTC
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (r *weatherRepository) LatestUpdate(ctx context.Context) (time.Time, error) {
+	var updated sql.NullInt64
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX("updatedAt") FROM weather_data`).Scan(&updated); err != nil {
+		return time.Time{}, err
+	}
+	if !updated.Valid {
+		return time.Time{}, nil
+	}
+	return time.UnixMilli(updated.Int64), nil
+}
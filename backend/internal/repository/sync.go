@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"eamagineweather-backend/db"
+)
+
+type prismaSyncRunRepository struct {
+	db *db.PrismaClient
+}
+
+func NewSyncRunRepository(database *db.PrismaClient) SyncRunRepository {
+	return &prismaSyncRunRepository{db: database}
+}
+
+// Start records the beginning of a sync run
+func (r *prismaSyncRunRepository) Start(ctx context.Context) (*db.WeatherSyncRunModel, error) {
+	return r.db.WeatherSyncRun.CreateOne().Exec(ctx)
+}
+
+// Finish records the outcome of a sync run; reason explains a run that
+// stopped early and is empty for one that completed
+func (r *prismaSyncRunRepository) Finish(ctx context.Context, id string, regions, points, failed int, reason string) (*db.WeatherSyncRunModel, error) {
+	var runError *string
+	if reason != "" {
+		runError = &reason
+	}
+	return r.db.WeatherSyncRun.FindUnique(
+		db.WeatherSyncRun.ID.Equals(id),
+	).Update(
+		db.WeatherSyncRun.FinishedAt.Set(time.Now()),
+		db.WeatherSyncRun.RegionCount.Set(regions),
+		db.WeatherSyncRun.PointCount.Set(points),
+		db.WeatherSyncRun.FailedCount.Set(failed),
+		db.WeatherSyncRun.RunError.SetOptional(runError),
+	).Exec(ctx)
+}
+
+// FindLatestFinished returns the most recently finished run
+func (r *prismaSyncRunRepository) FindLatestFinished(ctx context.Context) (*db.WeatherSyncRunModel, error) {
+	return r.db.WeatherSyncRun.FindFirst(
+		db.WeatherSyncRun.FinishedAt.Lte(time.Now()),
+	).OrderBy(
+		db.WeatherSyncRun.FinishedAt.Order(db.SortOrderDesc),
+	).Exec(ctx)
+}
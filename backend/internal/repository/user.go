@@ -5,26 +5,56 @@ import (
 	"eamagineweather-backend/db"
 )
 
-type UserRepository struct {
+type prismaUserRepository struct {
 	db *db.PrismaClient
 }
 
-func NewUserRepository(database *db.PrismaClient) *UserRepository {
-	return &UserRepository{db: database}
+func NewUserRepository(database *db.PrismaClient) UserRepository {
+	return &prismaUserRepository{db: database}
 }
 
-func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*db.UserModel, error) {
+func (r *prismaUserRepository) FindByEmail(ctx context.Context, email string) (*db.UserModel, error) {
 	return r.db.User.FindFirst(
 		db.User.Email.Equals(email),
 	).Exec(ctx)
 }
 
-func (r *UserRepository) Create(ctx context.Context, email string, name string) (*db.UserModel, error) {
+func (r *prismaUserRepository) FindByID(ctx context.Context, id string) (*db.UserModel, error) {
+	return r.db.User.FindUnique(
+		db.User.ID.Equals(id),
+	).Exec(ctx)
+}
+
+// UpdatePreferences stores the display units and language of a user. An empty
+// windUnit means the default of the unit system.
+func (r *prismaUserRepository) UpdatePreferences(ctx context.Context, id, units, windUnit, language string) (*db.UserModel, error) {
+	return r.db.User.FindUnique(
+		db.User.ID.Equals(id),
+	).Update(
+		db.User.Units.Set(units),
+		db.User.WindUnit.Set(windUnit),
+		db.User.Language.Set(language),
+	).Exec(ctx)
+}
+
+func (r *prismaUserRepository) Create(ctx context.Context, email string, name string) (*db.UserModel, error) {
 	// Simplified create for now
 	return nil, nil
 }
 
-func (r *UserRepository) Update(ctx context.Context, id string, name string) (*db.UserModel, error) {
+func (r *prismaUserRepository) Update(ctx context.Context, id string, name string) (*db.UserModel, error) {
 	// Simplified update for now
 	return nil, nil
-}
\ No newline at end of file
+}
+
+// FindFavorites returns a user's favorites in display order, with their
+// regions fetched in the same query
+func (r *prismaUserRepository) FindFavorites(ctx context.Context, userID string) ([]db.UserFavoriteModel, error) {
+	return r.db.UserFavorite.FindMany(
+		db.UserFavorite.UserID.Equals(userID),
+	).With(
+		db.UserFavorite.Region.Fetch(),
+	).OrderBy(
+		db.UserFavorite.Order.Order(db.SortOrderAsc),
+	).Exec(ctx)
+}
@@ -2,18 +2,21 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"time"
+
 	"eamagineweather-backend/db"
 )
 
-type WeatherRepository struct {
+type prismaWeatherRepository struct {
 	db *db.PrismaClient
 }
 
-func NewWeatherRepository(database *db.PrismaClient) *WeatherRepository {
-	return &WeatherRepository{db: database}
+func NewWeatherRepository(database *db.PrismaClient) WeatherRepository {
+	return &prismaWeatherRepository{db: database}
 }
 
-func (r *WeatherRepository) FindByRegionCode(ctx context.Context, regionCode string) ([]db.WeatherDataModel, error) {
+func (r *prismaWeatherRepository) FindByRegionCode(ctx context.Context, regionCode string) ([]db.WeatherDataModel, error) {
 	region, err := r.db.Region.FindFirst(
 		db.Region.Code.Equals(regionCode),
 	).Exec(ctx)
@@ -29,7 +32,314 @@ func (r *WeatherRepository) FindByRegionCode(ctx context.Context, regionCode str
 	).Take(24).Exec(ctx) // Last 24 hours of data
 }
 
-func (r *WeatherRepository) Create(ctx context.Context, regionID string, temp float64, humidity int, desc string) (*db.WeatherDataModel, error) {
+func (r *prismaWeatherRepository) Create(ctx context.Context, regionID string, temp float64, humidity int, desc string) (*db.WeatherDataModel, error) {
 	// Simplified create for now
 	return nil, nil
-}
\ No newline at end of file
+}
+
+// compactSQL rolls raw points before the cutoff date into one row per region
+// and local calendar day. Days are only rewritten when they gained samples, so
+// a day whose raw rows are partially deleted never overwrites its summary.
+const compactSQL = `
+WITH upserted AS (
+	INSERT INTO weather_daily_summaries (
+		id, "regionId", date,
+		"minTemperature", "maxTemperature", "avgTemperature",
+		"avgHumidity", "maxWindSpeed", "avgCloudCover",
+		"weatherDesc", "weatherDescEn", "sampleCount",
+		"createdAt", "updatedAt"
+	)
+	SELECT
+		"regionId" || ':' || to_char("localDatetime"::date, 'YYYYMMDD'),
+		"regionId",
+		"localDatetime"::date,
+		MIN(temperature), MAX(temperature), AVG(temperature),
+		AVG(humidity), MAX("windSpeed"), AVG("cloudCover"),
+		mode() WITHIN GROUP (ORDER BY "weatherDesc"),
+		mode() WITHIN GROUP (ORDER BY "weatherDescEn"),
+		COUNT(*),
+		NOW(), NOW()
+	FROM weather_data
+	WHERE "localDatetime" < $1
+	GROUP BY "regionId", "localDatetime"::date
+	ON CONFLICT ("regionId", date) DO UPDATE SET
+		"minTemperature" = EXCLUDED."minTemperature",
+		"maxTemperature" = EXCLUDED."maxTemperature",
+		"avgTemperature" = EXCLUDED."avgTemperature",
+		"avgHumidity"    = EXCLUDED."avgHumidity",
+		"maxWindSpeed"   = EXCLUDED."maxWindSpeed",
+		"avgCloudCover"  = EXCLUDED."avgCloudCover",
+		"weatherDesc"    = EXCLUDED."weatherDesc",
+		"weatherDescEn"  = EXCLUDED."weatherDescEn",
+		"sampleCount"    = EXCLUDED."sampleCount",
+		"updatedAt"      = NOW()
+	WHERE weather_daily_summaries."sampleCount" < EXCLUDED."sampleCount"
+	RETURNING "sampleCount"
+)
+SELECT COALESCE(SUM("sampleCount"), 0)::int AS rows, COUNT(*)::int AS days FROM upserted`
+
+// deleteBatchSQL removes at most $2 raw points older than $1. Selecting ids
+// first keeps each statement's lock footprint bounded.
+const deleteBatchSQL = `
+DELETE FROM weather_data
+WHERE id IN (
+	SELECT id FROM weather_data
+	WHERE "localDatetime" < $1
+	LIMIT $2
+)`
+
+// CompactBefore writes daily summaries for all raw points whose local date is
+// before the cutoff. It returns the number of raw rows and days compacted.
+func (r *prismaWeatherRepository) CompactBefore(ctx context.Context, cutoff time.Time) (rows int, days int, err error) {
+	var result []struct {
+		Rows int `json:"rows"`
+		Days int `json:"days"`
+	}
+	if err := r.db.Prisma.QueryRaw(compactSQL, cutoff).Exec(ctx, &result); err != nil {
+		return 0, 0, err
+	}
+	if len(result) == 0 {
+		return 0, 0, nil
+	}
+	return result[0].Rows, result[0].Days, nil
+}
+
+// DeleteBefore deletes raw points whose local time is before the cutoff, at
+// most batchSize rows per statement, and returns the total number deleted.
+func (r *prismaWeatherRepository) DeleteBefore(ctx context.Context, cutoff time.Time, batchSize int) (int, error) {
+	total := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		result, err := r.db.Prisma.ExecuteRaw(deleteBatchSQL, cutoff, batchSize).Exec(ctx)
+		if err != nil {
+			return total, err
+		}
+
+		total += result.Count
+		if result.Count < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// WeatherHistoryRow is one aggregated bucket returned by AggregateHistory.
+type WeatherHistoryRow struct {
+	Period         time.Time `json:"period"`
+	MinTemperature float64   `json:"min_t"`
+	MaxTemperature float64   `json:"max_t"`
+	AvgTemperature float64   `json:"avg_t"`
+	AvgHumidity    float64   `json:"avg_h"`
+	MaxWindSpeed   float64   `json:"max_ws"`
+	AvgCloudCover  float64   `json:"avg_cc"`
+	WeatherDesc    string    `json:"desc_id"`
+	WeatherDescEn  string    `json:"desc_en"`
+	SampleCount    int       `json:"samples"`
+}
+
+// historyDaysCTE yields one row per local day for $1 between $2 and $3,
+// taking compacted summaries where they exist and aggregating raw points
+// for days that have not been compacted yet.
+const historyDaysCTE = `
+WITH days AS (
+	SELECT
+		date::timestamp AS period,
+		"minTemperature" AS min_t, "maxTemperature" AS max_t, "avgTemperature" AS avg_t,
+		"avgHumidity" AS avg_h, "maxWindSpeed" AS max_ws, "avgCloudCover" AS avg_cc,
+		"weatherDesc" AS desc_id, "weatherDescEn" AS desc_en, "sampleCount" AS samples
+	FROM weather_daily_summaries
+	WHERE "regionId" = $1 AND date >= $2::date AND date < $3::date
+	UNION ALL
+	SELECT
+		w."localDatetime"::date::timestamp,
+		MIN(w.temperature), MAX(w.temperature), AVG(w.temperature),
+		AVG(w.humidity)::float8, MAX(w."windSpeed"), AVG(w."cloudCover")::float8,
+		mode() WITHIN GROUP (ORDER BY w."weatherDesc"),
+		mode() WITHIN GROUP (ORDER BY w."weatherDescEn"),
+		COUNT(*)::int
+	FROM weather_data w
+	WHERE w."regionId" = $1 AND w."localDatetime" >= $2 AND w."localDatetime" < $3
+		AND NOT EXISTS (
+			SELECT 1 FROM weather_daily_summaries s
+			WHERE s."regionId" = w."regionId" AND s.date = w."localDatetime"::date
+		)
+	GROUP BY 1
+)`
+
+const historyDailySQL = historyDaysCTE + `
+SELECT period, min_t, max_t, avg_t, avg_h, max_ws, avg_cc, desc_id, desc_en, samples::int AS samples
+FROM days
+ORDER BY period
+LIMIT $4`
+
+const historyMonthlySQL = historyDaysCTE + `
+SELECT
+	date_trunc('month', period) AS period,
+	MIN(min_t) AS min_t, MAX(max_t) AS max_t,
+	(SUM(avg_t * samples) / SUM(samples))::float8 AS avg_t,
+	(SUM(avg_h * samples) / SUM(samples))::float8 AS avg_h,
+	MAX(max_ws) AS max_ws,
+	(SUM(avg_cc * samples) / SUM(samples))::float8 AS avg_cc,
+	mode() WITHIN GROUP (ORDER BY desc_id) AS desc_id,
+	mode() WITHIN GROUP (ORDER BY desc_en) AS desc_en,
+	SUM(samples)::int AS samples
+FROM days
+GROUP BY 1
+ORDER BY 1
+LIMIT $4`
+
+// FindHistoryPoints returns raw points for a region whose local time falls in
+// [from, to), oldest first.
+func (r *prismaWeatherRepository) FindHistoryPoints(ctx context.Context, regionID string, from, to time.Time, limit int) ([]db.WeatherDataModel, error) {
+	return r.db.WeatherData.FindMany(
+		db.WeatherData.RegionID.Equals(regionID),
+		db.WeatherData.LocalDatetime.Gte(from),
+		db.WeatherData.LocalDatetime.Lt(to),
+	).OrderBy(
+		db.WeatherData.LocalDatetime.Order(db.SortOrderAsc),
+	).Take(limit).Exec(ctx)
+}
+
+// AggregateHistory returns daily or monthly buckets for a region whose local
+// start falls in [from, to), oldest first.
+func (r *prismaWeatherRepository) AggregateHistory(ctx context.Context, regionID string, monthly bool, from, to time.Time, limit int) ([]WeatherHistoryRow, error) {
+	query := historyDailySQL
+	if monthly {
+		query = historyMonthlySQL
+	}
+
+	var rows []WeatherHistoryRow
+	if err := r.db.Prisma.QueryRaw(query, regionID, from, to, limit).Exec(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// WeatherPoint is one forecast point to store with UpsertPoints
+type WeatherPoint struct {
+	UTCDatetime   time.Time `json:"utc"`
+	LocalDatetime time.Time `json:"local"` // Wall clock time labelled as UTC
+	Temperature   float64   `json:"t"`
+	Humidity      int       `json:"hu"`
+	WeatherDesc   string    `json:"desc_id"`
+	WeatherDescEn string    `json:"desc_en"`
+	WindSpeed     float64   `json:"ws"`
+	WindDirection string    `json:"wd"`
+	CloudCover    int       `json:"tcc"`
+	Visibility    string    `json:"vs"`
+	Precipitation float64   `json:"tp"`
+	AnalysisDate  time.Time `json:"analysis"`
+}
+
+// upsertPointsSQL stores all points of one region in a single statement.
+// Ids are derived from region and time like daily summary ids, so a point
+// keeps its id when a later forecast revises it.
+const upsertPointsSQL = `
+INSERT INTO weather_data (
+	id, "regionId", "utcDatetime", "localDatetime",
+	temperature, humidity, "weatherDesc", "weatherDescEn",
+	"windSpeed", "windDirection", "cloudCover", visibility, precipitation,
+	"analysisDate", "createdAt", "updatedAt"
+)
+SELECT
+	$1 || ':' || to_char(p.utc, 'YYYYMMDDHH24MI'), $1, p.utc, p.local,
+	p.t, p.hu, p.desc_id, p.desc_en,
+	p.ws, p.wd, p.tcc, p.vs, p.tp,
+	p.analysis, NOW(), NOW()
+FROM json_to_recordset($2::json) AS p(
+	utc timestamp, local timestamp, t float8, hu int, desc_id text, desc_en text,
+	ws float8, wd text, tcc int, vs text, tp float8, analysis timestamp
+)
+ON CONFLICT ("regionId", "utcDatetime") DO UPDATE SET
+	"localDatetime" = EXCLUDED."localDatetime",
+	temperature     = EXCLUDED.temperature,
+	humidity        = EXCLUDED.humidity,
+	"weatherDesc"   = EXCLUDED."weatherDesc",
+	"weatherDescEn" = EXCLUDED."weatherDescEn",
+	"windSpeed"     = EXCLUDED."windSpeed",
+	"windDirection" = EXCLUDED."windDirection",
+	"cloudCover"    = EXCLUDED."cloudCover",
+	visibility      = EXCLUDED.visibility,
+	precipitation   = EXCLUDED.precipitation,
+	"analysisDate"  = EXCLUDED."analysisDate",
+	"updatedAt"     = NOW()
+WHERE weather_data."analysisDate" <= EXCLUDED."analysisDate"`
+
+// UpsertPoints stores forecast points for a region, replacing stored points
+// at the same time unless they come from a newer analysis. It returns the
+// number of rows written.
+func (r *prismaWeatherRepository) UpsertPoints(ctx context.Context, regionID string, points []WeatherPoint) (int, error) {
+	if len(points) == 0 {
+		return 0, nil
+	}
+	for i := range points {
+		points[i].UTCDatetime = points[i].UTCDatetime.UTC()
+	}
+
+	payload, err := json.Marshal(points)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.Prisma.ExecuteRaw(upsertPointsSQL, regionID, string(payload)).Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// VillagePoint is the latest stored point of one village, see
+// FindVillagePoints
+type VillagePoint struct {
+	RegionID      string    `json:"regionId"`
+	Code          string    `json:"code"`
+	Latitude      *float64  `json:"latitude"`
+	Longitude     *float64  `json:"longitude"`
+	UTCDatetime   time.Time `json:"utcDatetime"`
+	Temperature   float64   `json:"temperature"`
+	Humidity      int       `json:"humidity"`
+	WindSpeed     float64   `json:"windSpeed"`
+	Precipitation float64   `json:"precipitation"`
+	WeatherDesc   string    `json:"weatherDesc"`
+	WeatherDescEn string    `json:"weatherDescEn"`
+}
+
+// villagePointsSQL picks, for every active village under the code prefix $1,
+// the latest point in the forecast slot containing $2
+const villagePointsSQL = `
+SELECT DISTINCT ON (r.code)
+	w."regionId", r.code, r.latitude, r.longitude,
+	w."utcDatetime", w.temperature, w.humidity, w."windSpeed", w.precipitation,
+	w."weatherDesc", w."weatherDescEn"
+FROM weather_data w
+JOIN regions r ON r.id = w."regionId"
+WHERE r.level = 4 AND r."isActive" AND r.code LIKE $1 || '.%'
+	AND w."utcDatetime" <= $2 AND w."utcDatetime" > $2 - interval '3 hours'
+ORDER BY r.code, w."utcDatetime" DESC`
+
+// FindVillagePoints returns the current point of every synced village below
+// the province, regency or district code, ordered by village code
+func (r *prismaWeatherRepository) FindVillagePoints(ctx context.Context, parentCode string, at time.Time) ([]VillagePoint, error) {
+	var points []VillagePoint
+	if err := r.db.Prisma.QueryRaw(villagePointsSQL, parentCode, at.UTC()).Exec(ctx, &points); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// LatestUpdate returns when weather data was last written, zero when there
+// is none
+func (r *prismaWeatherRepository) LatestUpdate(ctx context.Context) (time.Time, error) {
+	point, err := r.db.WeatherData.FindFirst().OrderBy(
+		db.WeatherData.UpdatedAt.Order(db.SortOrderDesc),
+	).Exec(ctx)
+	if db.IsErrNotFound(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return point.UpdatedAt, nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/repository"
+)
+
+var ErrAlertNotFound = errors.New("alert not found")
+
+type AlertService struct {
+	alertRepo  repository.AlertRepository
+	regionRepo repository.RegionRepository
+}
+
+func NewAlertService(alertRepo repository.AlertRepository, regionRepo repository.RegionRepository) *AlertService {
+	return &AlertService{alertRepo: alertRepo, regionRepo: regionRepo}
+}
+
+// GetActiveAlerts returns the active alerts that concern a region: those for
+// the region itself, for a region containing or within it, and those
+// without a region. An empty code returns every active alert.
+func (s *AlertService) GetActiveAlerts(ctx context.Context, regionCode string) ([]models.WeatherAlert, error) {
+	var codes []string
+	if regionCode != "" {
+		codes = []string{regionCode}
+	}
+	alerts, err := s.alertRepo.FindActive(ctx, codes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alerts: %w", err)
+	}
+
+	result := make([]models.WeatherAlert, len(alerts))
+	for i, alert := range alerts {
+		result[i] = alertFromModel(alert)
+	}
+	s.fillRegionNames(ctx, result)
+	return result, nil
+}
+
+// GetActiveAlertsBatch returns the active alerts concerning each of the
+// given regions, as GetActiveAlerts would, from a single query
+func (s *AlertService) GetActiveAlertsBatch(ctx context.Context, regionCodes []string) (map[string][]models.WeatherAlert, error) {
+	alerts, err := s.alertRepo.FindActive(ctx, regionCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alerts: %w", err)
+	}
+
+	all := make([]models.WeatherAlert, len(alerts))
+	for i, alert := range alerts {
+		all[i] = alertFromModel(alert)
+	}
+	s.fillRegionNames(ctx, all)
+
+	result := make(map[string][]models.WeatherAlert, len(regionCodes))
+	for _, code := range regionCodes {
+		for _, alert := range all {
+			if alertConcerns(alert, code) {
+				result[code] = append(result[code], alert)
+			}
+		}
+	}
+	return result, nil
+}
+
+// alertConcerns reports whether an alert is for the whole country, for the
+// region, or for a region containing or within it
+func alertConcerns(alert models.WeatherAlert, regionCode string) bool {
+	return alert.Region == "" ||
+		alert.Region == regionCode ||
+		strings.HasPrefix(regionCode, alert.Region+".") ||
+		strings.HasPrefix(alert.Region, regionCode+".")
+}
+
+// GetAlert returns one alert by id
+func (s *AlertService) GetAlert(ctx context.Context, id string) (*models.WeatherAlert, error) {
+	alert, err := s.alertRepo.FindByID(ctx, id)
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, ErrAlertNotFound
+		}
+		return nil, err
+	}
+
+	result := []models.WeatherAlert{alertFromModel(*alert)}
+	s.fillRegionNames(ctx, result)
+	return &result[0], nil
+}
+
+// fillRegionNames names the region of each alert. Codes missing from the
+// database keep an empty name.
+func (s *AlertService) fillRegionNames(ctx context.Context, alerts []models.WeatherAlert) {
+	var codes []string
+	for _, alert := range alerts {
+		if alert.Region != "" {
+			codes = append(codes, alert.Region)
+		}
+	}
+	if len(codes) == 0 {
+		return
+	}
+
+	regions, err := s.regionRepo.FindByCodes(ctx, codes)
+	if err != nil {
+		return
+	}
+	names := make(map[string]string, len(regions))
+	for _, region := range regions {
+		names[region.Code] = region.Name
+	}
+	for i := range alerts {
+		alerts[i].RegionName = names[alerts[i].Region]
+	}
+}
+
+func alertFromModel(alert db.WeatherAlertModel) models.WeatherAlert {
+	result := models.WeatherAlert{
+		ID:          alert.ID,
+		Title:       alert.Title,
+		Description: alert.Description,
+		Severity:    string(alert.Severity),
+		StartTime:   alert.StartTime,
+		IsActive:    alert.IsActive,
+		CreatedAt:   alert.CreatedAt,
+		UpdatedAt:   alert.UpdatedAt,
+	}
+	result.Region, _ = alert.Region()
+	if endTime, ok := alert.EndTime(); ok {
+		result.EndTime = &endTime
+	}
+	return result
+}
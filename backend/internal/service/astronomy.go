@@ -0,0 +1,101 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"eamagineweather-backend/internal/astro"
+	"eamagineweather-backend/internal/condition"
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/regiontz"
+)
+
+// astronomyFor returns sun and moon events for the region's local day that
+// contains t, or nil while the region has no coordinates
+func astronomyFor(region models.RegionInfo, t time.Time) *models.Astronomy {
+	if region.Latitude == nil || region.Longitude == nil {
+		return nil
+	}
+
+	loc := regiontz.Location(region.Code, region.Timezone)
+	sun := astro.Sun(t, *region.Latitude, *region.Longitude, loc)
+	moon := astro.Moon(sun.SolarNoon)
+
+	return &models.Astronomy{
+		Date:             t.In(loc).Format("2006-01-02"),
+		Timezone:         loc.String(),
+		Sunrise:          sun.Sunrise,
+		Sunset:           sun.Sunset,
+		SolarNoon:        sun.SolarNoon,
+		CivilDawn:        sun.CivilDawn,
+		CivilDusk:        sun.CivilDusk,
+		DayLengthMinutes: int(sun.DayLength.Round(time.Minute).Minutes()),
+		MoonPhase:        math.Round(moon.Phase*100) / 100,
+		MoonIllumination: math.Round(moon.Illumination*100) / 100,
+		MoonPhaseName:    moon.Name,
+		MoonPhaseNameEn:  moon.NameEn,
+	}
+}
+
+// dailyForecasts groups forecast points by the region's local calendar day.
+// RainChance is the share of a day's points that forecast rain.
+func dailyForecasts(region models.RegionInfo, forecast []models.ForecastWeather) []models.DailyForecast {
+	loc := regiontz.Location(region.Code, region.Timezone)
+
+	var days []models.DailyForecast
+	var points, wet []int
+	for _, point := range forecast {
+		local := point.DateTime.In(loc)
+		date := local.Format("2006-01-02")
+
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, models.DailyForecast{
+				Date:           date,
+				MinTemperature: point.Temperature,
+				MaxTemperature: point.Temperature,
+				ConditionCode:  point.ConditionCode,
+				Astronomy:      astronomyFor(region, local),
+			})
+			points = append(points, 0)
+			wet = append(wet, 0)
+		}
+
+		i := len(days) - 1
+		day := &days[i]
+		if point.Temperature < day.MinTemperature {
+			day.MinTemperature = point.Temperature
+		}
+		if point.Temperature > day.MaxTemperature {
+			day.MaxTemperature = point.Temperature
+		}
+		day.ConditionCode = int(condition.Worst(condition.Code(day.ConditionCode), condition.Code(point.ConditionCode)))
+
+		points[i]++
+		if point.Precipitation > 0 || condition.IsWet(condition.Code(point.ConditionCode)) {
+			wet[i]++
+		}
+		day.RainChance = int(math.Round(float64(wet[i]) * 100 / float64(points[i])))
+	}
+	return days
+}
+
+// isNight reports whether t is between sunset and sunrise in the region.
+// Without coordinates 06:00–18:00 local time counts as day.
+func isNight(region models.RegionInfo, t time.Time) bool {
+	loc := regiontz.Location(region.Code, region.Timezone)
+	if region.Latitude == nil || region.Longitude == nil {
+		hour := t.In(loc).Hour()
+		return hour < 6 || hour >= 18
+	}
+
+	sun := astro.Sun(t, *region.Latitude, *region.Longitude, loc)
+	if sun.Sunrise.IsZero() {
+		return false
+	}
+	return t.Before(sun.Sunrise) || !t.Before(sun.Sunset)
+}
+
+// setConditionIcon picks the day or night icon for a point in the region
+func setConditionIcon(region models.RegionInfo, weather *models.WeatherCondition, t time.Time) {
+	weather.Icon = condition.Icon(condition.Code(weather.ConditionCode), isNight(region, t))
+}
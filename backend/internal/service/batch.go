@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/models"
+)
+
+// batchConcurrency is how many BMKG fetches a batch runs at once. The
+// shared rate limiter still caps the overall request rate.
+const batchConcurrency = 8
+
+// GetCurrentWeatherBatch returns current weather for many regions. Cached
+// regions are answered from one Redis round trip, the rest are fetched
+// concurrently. A failing region gets an error entry instead of failing the
+// whole batch.
+func (s *WeatherService) GetCurrentWeatherBatch(ctx context.Context, regionCodes []string) map[string]models.BatchWeatherResult {
+	results := make(map[string]models.BatchWeatherResult, len(regionCodes))
+	var mu sync.Mutex
+	set := func(code string, weather *models.WeatherResponse, err error) {
+		result := models.BatchWeatherResult{Weather: weather}
+		if err != nil {
+			result = models.BatchWeatherResult{Error: err.Error()}
+		}
+		mu.Lock()
+		results[code] = result
+		mu.Unlock()
+	}
+
+	var villages []string
+	for _, code := range regionCodes {
+		if regionLevel(code) == 4 {
+			villages = append(villages, code)
+		}
+	}
+	cached := s.bmkgService.GetCachedWeatherData(ctx, villages)
+
+	var misses []string
+	for _, code := range regionCodes {
+		data, ok := cached[code]
+		if !ok {
+			misses = append(misses, code)
+			continue
+		}
+		current, err := currentFromBMKG(code, data)
+		if err != nil {
+			set(code, nil, err)
+			continue
+		}
+		set(code, s.currentResponse(ctx, code, current), nil)
+	}
+
+	codes := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < batchConcurrency && i < len(misses); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for code := range codes {
+				weather, err := s.GetCurrentWeather(ctx, code)
+				set(code, weather, err)
+			}
+		}()
+	}
+	for _, code := range misses {
+		codes <- code
+	}
+	close(codes)
+	wg.Wait()
+
+	return results
+}
+
+// GetForecastBatch returns BMKG forecasts for villages the caller has
+// already loaded, so no region lookups are made. Cached villages are
+// answered from one Redis round trip and the rest are fetched concurrently.
+// Regions that are not villages or fail to fetch are left out.
+func (s *WeatherService) GetForecastBatch(ctx context.Context, regions []*db.RegionModel) map[string][]models.ForecastWeather {
+	results := make(map[string][]models.ForecastWeather, len(regions))
+	var mu sync.Mutex
+	s.fetchVillages(ctx, regions, func(region *db.RegionModel, data *models.BMKGWeatherResponse) {
+		info := regionInfo(region)
+		forecast := forecastFromBMKG(data)
+		for i := range forecast {
+			setConditionIcon(info, &forecast[i].WeatherCondition, forecast[i].DateTime)
+		}
+		mu.Lock()
+		results[region.Code] = forecast
+		mu.Unlock()
+	})
+	return results
+}
+
+// VillageWeather holds the current-weather and forecast responses of one
+// village, shaped as GetCurrentWeather and GetWeatherForecast return them
+type VillageWeather struct {
+	Current  *models.WeatherResponse
+	Forecast *models.WeatherResponse
+}
+
+// GetVillageWeatherBatch returns current weather and forecasts for villages
+// the caller has already loaded, like GetForecastBatch
+func (s *WeatherService) GetVillageWeatherBatch(ctx context.Context, regions []*db.RegionModel) map[string]VillageWeather {
+	results := make(map[string]VillageWeather, len(regions))
+	var mu sync.Mutex
+	s.fetchVillages(ctx, regions, func(region *db.RegionModel, data *models.BMKGWeatherResponse) {
+		info := regionInfo(region)
+		current, err := currentFromBMKG(region.Code, data)
+		if err != nil {
+			log.Printf("No current weather for %s: %v", region.Code, err)
+			return
+		}
+		setConditionIcon(info, &current.WeatherCondition, current.DateTime)
+
+		forecast := forecastFromBMKG(data)
+		for i := range forecast {
+			setConditionIcon(info, &forecast[i].WeatherCondition, forecast[i].DateTime)
+		}
+
+		weather := VillageWeather{
+			Current: &models.WeatherResponse{
+				Current:   current,
+				Astronomy: astronomyFor(info, current.DateTime),
+				Region:    info,
+			},
+			Forecast: &models.WeatherResponse{
+				Forecast: forecast,
+				Daily:    dailyForecasts(info, forecast),
+				Region:   info,
+			},
+		}
+		mu.Lock()
+		results[region.Code] = weather
+		mu.Unlock()
+	})
+	return results
+}
+
+// fetchVillages calls fn with the BMKG response of each village among
+// regions. Cached villages are read in one Redis round trip and the rest
+// are fetched concurrently, so fn must be safe for concurrent use.
+func (s *WeatherService) fetchVillages(ctx context.Context, regions []*db.RegionModel, fn func(*db.RegionModel, *models.BMKGWeatherResponse)) {
+	var villages []*db.RegionModel
+	var codes []string
+	for _, region := range regions {
+		if region.Level == 4 {
+			villages = append(villages, region)
+			codes = append(codes, region.Code)
+		}
+	}
+	cached := s.bmkgService.GetCachedWeatherData(ctx, codes)
+
+	var misses []*db.RegionModel
+	for _, region := range villages {
+		if data, ok := cached[region.Code]; ok {
+			fn(region, data)
+		} else {
+			misses = append(misses, region)
+		}
+	}
+
+	queue := make(chan *db.RegionModel)
+	var wg sync.WaitGroup
+	for i := 0; i < batchConcurrency && i < len(misses); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for region := range queue {
+				data, err := s.bmkgService.GetWeatherData(ctx, region.Code)
+				if err != nil {
+					log.Printf("Failed to fetch forecast for %s: %v", region.Code, err)
+					continue
+				}
+				fn(region, data)
+			}
+		}()
+	}
+	for _, region := range misses {
+		queue <- region
+	}
+	close(queue)
+	wg.Wait()
+}
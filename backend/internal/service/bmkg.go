@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
+	"eamagineweather-backend/internal/condition"
 	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/weathercalc"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -16,20 +19,73 @@ type BMKGService struct {
 	baseURL     string
 	httpClient  *http.Client
 	redisClient *redis.Client
+	limiter     *rateLimiter
 }
 
-func NewBMKGService(baseURL string, redisClient *redis.Client) *BMKGService {
+// NewBMKGService creates a BMKG client that makes at most rateLimit
+// requests per minute; zero or less disables the limit
+func NewBMKGService(baseURL string, rateLimit int, redisClient *redis.Client) *BMKGService {
 	return &BMKGService{
 		baseURL:     baseURL,
 		httpClient:  &http.Client{Timeout: 30 * time.Second},
 		redisClient: redisClient,
+		limiter:     newRateLimiter(rateLimit),
 	}
 }
 
+// BMKGStatusError is returned when BMKG answers with a status other than 200
+type BMKGStatusError struct {
+	StatusCode int
+}
+
+func (e *BMKGStatusError) Error() string {
+	return fmt.Sprintf("BMKG API returned status %d", e.StatusCode)
+}
+
+// Is lets a 404 match ErrRegionNotFound: BMKG does not know the code
+func (e *BMKGStatusError) Is(target error) bool {
+	return target == ErrRegionNotFound && e.StatusCode == http.StatusNotFound
+}
+
+func weatherCacheKey(regionCode string) string {
+	return fmt.Sprintf("weather:%s", regionCode)
+}
+
+// GetCachedWeatherData returns the cached BMKG responses for the given
+// regions in one round trip. Regions without a usable cache entry are left
+// out of the result.
+func (s *BMKGService) GetCachedWeatherData(ctx context.Context, regionCodes []string) map[string]*models.BMKGWeatherResponse {
+	cached := make(map[string]*models.BMKGWeatherResponse)
+	if s.redisClient == nil || len(regionCodes) == 0 {
+		return cached
+	}
+
+	keys := make([]string, len(regionCodes))
+	for i, code := range regionCodes {
+		keys[i] = weatherCacheKey(code)
+	}
+
+	values, err := s.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return cached
+	}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var response models.BMKGWeatherResponse
+		if err := json.Unmarshal([]byte(data), &response); err == nil {
+			cached[regionCodes[i]] = &response
+		}
+	}
+	return cached
+}
+
 func (s *BMKGService) GetWeatherData(ctx context.Context, regionCode string) (*models.BMKGWeatherResponse, error) {
 	// Check cache first
 	if s.redisClient != nil {
-		cacheKey := fmt.Sprintf("weather:%s", regionCode)
+		cacheKey := weatherCacheKey(regionCode)
 		cached, err := s.redisClient.Get(ctx, cacheKey).Result()
 		if err == nil {
 			var response models.BMKGWeatherResponse
@@ -40,6 +96,10 @@ func (s *BMKGService) GetWeatherData(ctx context.Context, regionCode string) (*m
 	}
 
 	// Fetch from BMKG API
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
 	url := fmt.Sprintf("%s/prakiraan-cuaca?adm4=%s", s.baseURL, regionCode)
 	
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -57,7 +117,7 @@ func (s *BMKGService) GetWeatherData(ctx context.Context, regionCode string) (*m
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("BMKG API returned status %d", resp.StatusCode)
+		return nil, &BMKGStatusError{StatusCode: resp.StatusCode}
 	}
 
 	var weatherResponse models.BMKGWeatherResponse
@@ -67,7 +127,7 @@ func (s *BMKGService) GetWeatherData(ctx context.Context, regionCode string) (*m
 
 	// Cache the response for 30 minutes
 	if s.redisClient != nil {
-		cacheKey := fmt.Sprintf("weather:%s", regionCode)
+		cacheKey := weatherCacheKey(regionCode)
 		if data, err := json.Marshal(weatherResponse); err == nil {
 			s.redisClient.Set(ctx, cacheKey, data, 30*time.Minute)
 		}
@@ -76,12 +136,32 @@ func (s *BMKGService) GetWeatherData(ctx context.Context, regionCode string) (*m
 	return &weatherResponse, nil
 }
 
+// GetLocation returns the coordinates and time zone BMKG reports for a
+// region, or nil when the response carries no location
+func (s *BMKGService) GetLocation(ctx context.Context, regionCode string) (*models.BMKGLocation, error) {
+	weatherData, err := s.GetWeatherData(ctx, regionCode)
+	if err != nil {
+		return nil, err
+	}
+
+	location := weatherData.Lokasi
+	if location == nil || (location.Latitude == 0 && location.Longitude == 0) {
+		return nil, nil
+	}
+	return location, nil
+}
+
 func (s *BMKGService) GetCurrentWeather(ctx context.Context, regionCode string) (*models.CurrentWeather, error) {
 	weatherData, err := s.GetWeatherData(ctx, regionCode)
 	if err != nil {
 		return nil, err
 	}
 
+	return currentFromBMKG(regionCode, weatherData)
+}
+
+// currentFromBMKG converts the first point of a BMKG response
+func currentFromBMKG(regionCode string, weatherData *models.BMKGWeatherResponse) (*models.CurrentWeather, error) {
 	if len(weatherData.Data) == 0 {
 		return nil, fmt.Errorf("no weather data available for region %s", regionCode)
 	}
@@ -100,16 +180,19 @@ func (s *BMKGService) GetCurrentWeather(ctx context.Context, regionCode string)
 	}
 
 	return &models.CurrentWeather{
-		Temperature:   current.Temperature,
-		Humidity:      current.Humidity,
-		WeatherDesc:   current.WeatherDesc,
-		WeatherDescEn: current.WeatherDescEn,
-		WindSpeed:     current.WindSpeed,
-		WindDirection: current.WindDirection,
-		CloudCover:    current.CloudCover,
-		Visibility:    current.Visibility,
-		DateTime:      utcTime,
-		LocalDateTime: localTime,
+		Temperature:      current.Temperature,
+		Humidity:         current.Humidity,
+		WeatherDesc:      current.WeatherDesc,
+		WeatherDescEn:    current.WeatherDescEn,
+		WindSpeed:        current.WindSpeed,
+		WindDirection:    current.WindDirection,
+		CloudCover:       current.CloudCover,
+		Visibility:       current.Visibility,
+		Precipitation:    current.Precipitation,
+		DateTime:         utcTime,
+		LocalDateTime:    localTime,
+		WeatherCondition: weatherCondition(current.WeatherDesc, current.WeatherDescEn),
+		DerivedIndices:   derivedIndices(current.Temperature, current.Humidity, current.WindSpeed),
 	}, nil
 }
 
@@ -119,6 +202,12 @@ func (s *BMKGService) GetWeatherForecast(ctx context.Context, regionCode string)
 		return nil, err
 	}
 
+	return forecastFromBMKG(weatherData), nil
+}
+
+// forecastFromBMKG converts every point of a BMKG response, skipping points
+// with invalid timestamps
+func forecastFromBMKG(weatherData *models.BMKGWeatherResponse) []models.ForecastWeather {
 	forecasts := make([]models.ForecastWeather, 0, len(weatherData.Data))
 	
 	for _, data := range weatherData.Data {
@@ -133,20 +222,57 @@ func (s *BMKGService) GetWeatherForecast(ctx context.Context, regionCode string)
 		}
 
 		forecast := models.ForecastWeather{
-			Temperature:   data.Temperature,
-			Humidity:      data.Humidity,
-			WeatherDesc:   data.WeatherDesc,
-			WeatherDescEn: data.WeatherDescEn,
-			WindSpeed:     data.WindSpeed,
-			WindDirection: data.WindDirection,
-			CloudCover:    data.CloudCover,
-			Visibility:    data.Visibility,
-			DateTime:      utcTime,
-			LocalDateTime: localTime,
+			Temperature:      data.Temperature,
+			Humidity:         data.Humidity,
+			WeatherDesc:      data.WeatherDesc,
+			WeatherDescEn:    data.WeatherDescEn,
+			WindSpeed:        data.WindSpeed,
+			WindDirection:    data.WindDirection,
+			CloudCover:       data.CloudCover,
+			Visibility:       data.Visibility,
+			Precipitation:    data.Precipitation,
+			DateTime:         utcTime,
+			LocalDateTime:    localTime,
+			WeatherCondition: weatherCondition(data.WeatherDesc, data.WeatherDescEn),
+			DerivedIndices:   derivedIndices(data.Temperature, data.Humidity, data.WindSpeed),
 		}
 		
 		forecasts = append(forecasts, forecast)
 	}
 
-	return forecasts, nil
-}
\ No newline at end of file
+	return forecasts
+}
+
+// weatherCondition classifies a BMKG description. The icon is the day
+// variant until the caller knows the region and can tell day from night.
+func weatherCondition(desc, descEn string) models.WeatherCondition {
+	info := condition.Lookup(condition.Classify(desc, descEn))
+	return models.WeatherCondition{
+		ConditionCode: int(info.Code),
+		Condition:     info.Key,
+		Severity:      info.Severity,
+		WMOCode:       info.WMO,
+		Icon:          info.IconDay,
+	}
+}
+
+// derivedIndices computes feels-like and related indices, rounded to one decimal
+func derivedIndices(temperature float64, humidity int, windSpeed float64) models.DerivedIndices {
+	rh := float64(humidity)
+	beaufort := weathercalc.Beaufort(windSpeed)
+
+	return models.DerivedIndices{
+		FeelsLike:      round1(weathercalc.ApparentTemperature(temperature, rh, windSpeed)),
+		HeatIndex:      round1(weathercalc.HeatIndex(temperature, rh)),
+		DewPoint:       round1(weathercalc.DewPoint(temperature, rh)),
+		Humidex:        round1(weathercalc.Humidex(temperature, rh)),
+		WindChill:      round1(weathercalc.WindChill(temperature, windSpeed)),
+		BeaufortForce:  beaufort.Force,
+		BeaufortDesc:   beaufort.Description,
+		BeaufortDescEn: beaufort.DescriptionEn,
+	}
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/regiontz"
+)
+
+// CompareRegions aligns the forecasts of the given regions on the UTC
+// timestamps all of them share. The first code is the baseline. Days in the
+// summary follow the baseline's time zone, so a day means the same 24 hours
+// for every region even when they sit in WIB, WITA and WIT.
+func (s *WeatherService) CompareRegions(ctx context.Context, regionCodes []string) (*models.WeatherComparison, error) {
+	forecasts := make([]*models.WeatherResponse, len(regionCodes))
+	errs := make([]error, len(regionCodes))
+
+	var wg sync.WaitGroup
+	for i, code := range regionCodes {
+		wg.Add(1)
+		go func(i int, code string) {
+			defer wg.Done()
+			forecasts[i], errs[i] = s.GetWeatherForecast(ctx, code)
+		}(i, code)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("region %s: %w", regionCodes[i], err)
+		}
+	}
+
+	baseline := forecasts[0].Region
+	comparison := &models.WeatherComparison{
+		Baseline: baseline.Code,
+		Timezone: regiontz.Location(baseline.Code, baseline.Timezone).String(),
+		Regions:  make([]models.RegionInfo, len(forecasts)),
+	}
+
+	// Index every region's points by UTC time
+	byTime := make([]map[time.Time]models.ForecastWeather, len(forecasts))
+	for i, forecast := range forecasts {
+		comparison.Regions[i] = forecast.Region
+		byTime[i] = make(map[time.Time]models.ForecastWeather, len(forecast.Forecast))
+		for _, point := range forecast.Forecast {
+			byTime[i][point.DateTime.UTC()] = point
+		}
+	}
+
+	locations := make([]*time.Location, len(forecasts))
+	for i, region := range comparison.Regions {
+		locations[i] = regiontz.Location(region.Code, region.Timezone)
+	}
+
+	// Keep the baseline's order and only timestamps every region has
+	for _, base := range forecasts[0].Forecast {
+		at := base.DateTime.UTC()
+		point := models.ComparisonPoint{DateTime: at}
+		for i := range forecasts {
+			weather, ok := byTime[i][at]
+			if !ok {
+				point.Regions = nil
+				break
+			}
+			point.Regions = append(point.Regions, models.ComparisonRegionPoint{
+				Code:          comparison.Regions[i].Code,
+				LocalDateTime: at.In(locations[i]),
+				Temperature:   weather.Temperature,
+				Humidity:      weather.Humidity,
+				WindSpeed:     weather.WindSpeed,
+				Precipitation: weather.Precipitation,
+				ConditionCode: weather.ConditionCode,
+			})
+		}
+		if point.Regions != nil {
+			comparison.Timeline = append(comparison.Timeline, point)
+		}
+	}
+
+	fillComparison(comparison, locations[0])
+	return comparison, nil
+}
+
+// fillComparison computes deltas against the baseline and the daily
+// summary from the timeline values. It runs again after unit conversion so
+// both are expressed in the converted units.
+func fillComparison(comparison *models.WeatherComparison, loc *time.Location) {
+	comparison.Daily = nil
+
+	type totals struct {
+		maxTemperature float64
+		precipitation  float64
+		maxWindSpeed   float64
+	}
+	var day []totals
+	date := ""
+
+	flush := func() {
+		if day == nil {
+			return
+		}
+		summary := models.ComparisonDay{Date: date}
+		for i, t := range day {
+			code := comparison.Regions[i].Code
+			if i == 0 || t.maxTemperature > summary.MaxTemperature {
+				summary.Warmest, summary.MaxTemperature = code, t.maxTemperature
+			}
+			if i == 0 || t.precipitation > summary.MaxPrecipitation {
+				summary.Wettest, summary.MaxPrecipitation = code, round2(t.precipitation)
+			}
+			if i == 0 || t.maxWindSpeed > summary.MaxWindSpeed {
+				summary.Windiest, summary.MaxWindSpeed = code, t.maxWindSpeed
+			}
+		}
+		comparison.Daily = append(comparison.Daily, summary)
+	}
+
+	for p := range comparison.Timeline {
+		point := &comparison.Timeline[p]
+		base := point.Regions[0]
+
+		pointDate := point.DateTime.In(loc).Format("2006-01-02")
+		if pointDate != date {
+			flush()
+			date = pointDate
+			day = make([]totals, len(point.Regions))
+			for i, region := range point.Regions {
+				day[i] = totals{maxTemperature: region.Temperature, maxWindSpeed: region.WindSpeed}
+			}
+		}
+
+		for i := range point.Regions {
+			region := &point.Regions[i]
+			region.TemperatureDelta = round1(region.Temperature - base.Temperature)
+			region.HumidityDelta = region.Humidity - base.Humidity
+			region.WindSpeedDelta = round1(region.WindSpeed - base.WindSpeed)
+			region.PrecipitationDelta = round2(region.Precipitation - base.Precipitation)
+
+			t := &day[i]
+			if region.Temperature > t.maxTemperature {
+				t.maxTemperature = region.Temperature
+			}
+			if region.WindSpeed > t.maxWindSpeed {
+				t.maxWindSpeed = region.WindSpeed
+			}
+			t.precipitation += region.Precipitation
+		}
+	}
+	flush()
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
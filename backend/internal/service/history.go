@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/condition"
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/regiontz"
+)
+
+// History granularities accepted by GetHistory
+const (
+	GranularityThreeHourly = "3h"
+	GranularityDaily       = "daily"
+	GranularityMonthly     = "monthly"
+)
+
+var (
+	ErrRegionNotFound = errors.New("region not found")
+	ErrInvalidCursor  = errors.New("invalid cursor")
+)
+
+// HistoryQuery describes a page of stored weather history. From and To are
+// local wall-clock times of the region; To is exclusive.
+type HistoryQuery struct {
+	Granularity string
+	From        time.Time
+	To          time.Time
+	Cursor      string
+	Limit       int
+}
+
+// GetHistory returns stored weather for a region. Pages are keyed on the
+// local start time of the last returned bucket, so results stay stable while
+// new sync runs append data.
+func (s *WeatherService) GetHistory(ctx context.Context, regionCode string, query HistoryQuery) (*models.WeatherHistoryResponse, error) {
+	region, err := s.regionRepo.FindByCode(ctx, regionCode)
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, ErrRegionNotFound
+		}
+		return nil, err
+	}
+
+	// Buckets start at the region's local midnight. From is already the
+	// region's wall clock, so its date is the local date.
+	from := query.From
+	switch query.Granularity {
+	case GranularityDaily:
+		from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	case GranularityMonthly:
+		from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	if query.Cursor != "" {
+		last, err := decodeHistoryCursor(query.Cursor, region.Code, query.Granularity)
+		if err != nil {
+			return nil, err
+		}
+		from = nextHistoryPeriod(query.Granularity, last)
+	}
+
+	response := &models.WeatherHistoryResponse{
+		Region:      regionInfo(region),
+		Granularity: query.Granularity,
+		From:        query.From,
+		To:          query.To,
+	}
+
+	// Fetch one extra row to learn whether another page exists
+	switch query.Granularity {
+	case GranularityThreeHourly:
+		points, err := s.weatherRepo.FindHistoryPoints(ctx, region.ID, from, query.To, query.Limit+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load weather history: %w", err)
+		}
+		if len(points) > query.Limit {
+			points = points[:query.Limit]
+			response.NextCursor = encodeHistoryCursor(region.Code, query.Granularity, points[len(points)-1].LocalDatetime)
+		}
+		response.Points = make([]models.ForecastWeather, len(points))
+		for i, point := range points {
+			response.Points[i] = forecastFromModel(point)
+			setConditionIcon(response.Region, &response.Points[i].WeatherCondition, point.UtcDatetime)
+		}
+
+	case GranularityDaily, GranularityMonthly:
+		rows, err := s.weatherRepo.AggregateHistory(ctx, region.ID, query.Granularity == GranularityMonthly, from, query.To, query.Limit+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to aggregate weather history: %w", err)
+		}
+		if len(rows) > query.Limit {
+			rows = rows[:query.Limit]
+			response.NextCursor = encodeHistoryCursor(region.Code, query.Granularity, rows[len(rows)-1].Period)
+		}
+		response.Stats = make([]models.WeatherHistoryStats, len(rows))
+		for i, row := range rows {
+			response.Stats[i] = models.WeatherHistoryStats{
+				Period:         row.Period,
+				MinTemperature: row.MinTemperature,
+				MaxTemperature: row.MaxTemperature,
+				AvgTemperature: row.AvgTemperature,
+				AvgHumidity:    row.AvgHumidity,
+				MaxWindSpeed:   row.MaxWindSpeed,
+				AvgCloudCover:  row.AvgCloudCover,
+				WeatherDesc:    row.WeatherDesc,
+				WeatherDescEn:  row.WeatherDescEn,
+				ConditionCode:  int(condition.Classify(row.WeatherDesc, row.WeatherDescEn)),
+				SampleCount:    row.SampleCount,
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported granularity %q", query.Granularity)
+	}
+
+	return response, nil
+}
+
+// encodeHistoryCursor keys a page on the last returned bucket. The region and
+// granularity are part of the cursor, so it cannot continue another listing.
+func encodeHistoryCursor(regionCode, granularity string, period time.Time) string {
+	raw := regionCode + "|" + granularity + "|" + period.UTC().Format(time.RFC3339)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeHistoryCursor(cursor, regionCode, granularity string) (time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 || parts[0] != regionCode || parts[1] != granularity {
+		return time.Time{}, ErrInvalidCursor
+	}
+	period, err := time.Parse(time.RFC3339, parts[2])
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return period, nil
+}
+
+// nextHistoryPeriod returns the earliest bucket start after last
+func nextHistoryPeriod(granularity string, last time.Time) time.Time {
+	switch granularity {
+	case GranularityDaily:
+		return last.AddDate(0, 0, 1)
+	case GranularityMonthly:
+		return last.AddDate(0, 1, 0)
+	default:
+		return last.Add(time.Second)
+	}
+}
+
+func regionInfo(region *db.RegionModel) models.RegionInfo {
+	info := models.RegionInfo{
+		Code: region.Code,
+		Name: region.Name,
+	}
+	info.Province, _ = region.ProvinceName()
+	info.Regency, _ = region.RegencyName()
+	info.District, _ = region.DistrictName()
+	info.Village, _ = region.VillageName()
+	if lat, ok := region.Latitude(); ok {
+		info.Latitude = &lat
+	}
+	if lon, ok := region.Longitude(); ok {
+		info.Longitude = &lon
+	}
+	timezone, _ := region.Timezone()
+	info.Timezone = regiontz.Location(region.Code, timezone).String()
+	return info
+}
+
+func forecastFromModel(data db.WeatherDataModel) models.ForecastWeather {
+	return models.ForecastWeather{
+		Temperature:      data.Temperature,
+		Humidity:         data.Humidity,
+		WeatherDesc:      data.WeatherDesc,
+		WeatherDescEn:    data.WeatherDescEn,
+		WindSpeed:        data.WindSpeed,
+		WindDirection:    data.WindDirection,
+		CloudCover:       data.CloudCover,
+		Visibility:       data.Visibility,
+		Precipitation:    data.Precipitation,
+		DateTime:         data.UtcDatetime,
+		LocalDateTime:    data.LocalDatetime,
+		WeatherCondition: weatherCondition(data.WeatherDesc, data.WeatherDescEn),
+		DerivedIndices:   derivedIndices(data.Temperature, data.Humidity, data.WindSpeed),
+	}
+}
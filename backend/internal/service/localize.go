@@ -0,0 +1,129 @@
+package service
+
+import (
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/regiontz"
+	"eamagineweather-backend/internal/units"
+)
+
+// LocalizeWeather converts a weather response in place from BMKG units into
+// the caller's preferred units and fills the localized description. It is
+// the only place weather values are converted. The description is
+// additive: weather_desc and weather_desc_en stay in the response for
+// existing clients.
+func LocalizeWeather(response *models.WeatherResponse, prefs units.Preferences) {
+	if response.Current != nil {
+		c := response.Current
+		c.Description = prefs.Pick(c.WeatherDesc, c.WeatherDescEn)
+		c.Temperature = prefs.Temperature(c.Temperature)
+		c.WindSpeed = prefs.WindSpeed(c.WindSpeed)
+		c.Visibility = prefs.Visibility(c.Visibility)
+		c.Precipitation = prefs.Precipitation(c.Precipitation)
+		localizeIndices(&c.DerivedIndices, prefs)
+	}
+
+	for i := range response.Forecast {
+		f := &response.Forecast[i]
+		f.Description = prefs.Pick(f.WeatherDesc, f.WeatherDescEn)
+		f.Temperature = prefs.Temperature(f.Temperature)
+		f.WindSpeed = prefs.WindSpeed(f.WindSpeed)
+		f.Visibility = prefs.Visibility(f.Visibility)
+		f.Precipitation = prefs.Precipitation(f.Precipitation)
+		localizeIndices(&f.DerivedIndices, prefs)
+	}
+
+	if response.Regional != nil {
+		r := response.Regional
+		r.MinTemperature = prefs.Temperature(r.MinTemperature)
+		r.MedianTemperature = prefs.Temperature(r.MedianTemperature)
+		r.MaxTemperature = prefs.Temperature(r.MaxTemperature)
+		r.MaxWindSpeed = prefs.WindSpeed(r.MaxWindSpeed)
+	}
+
+	for i := range response.Daily {
+		d := &response.Daily[i]
+		d.MinTemperature = prefs.Temperature(d.MinTemperature)
+		d.MaxTemperature = prefs.Temperature(d.MaxTemperature)
+	}
+
+	response.Units = unitsInfo(prefs)
+}
+
+// LocalizeHistory converts a history page in place, like LocalizeWeather
+func LocalizeHistory(response *models.WeatherHistoryResponse, prefs units.Preferences) {
+	for i := range response.Points {
+		p := &response.Points[i]
+		p.Description = prefs.Pick(p.WeatherDesc, p.WeatherDescEn)
+		p.Temperature = prefs.Temperature(p.Temperature)
+		p.WindSpeed = prefs.WindSpeed(p.WindSpeed)
+		p.Visibility = prefs.Visibility(p.Visibility)
+		p.Precipitation = prefs.Precipitation(p.Precipitation)
+		localizeIndices(&p.DerivedIndices, prefs)
+	}
+
+	for i := range response.Stats {
+		s := &response.Stats[i]
+		s.MinTemperature = prefs.Temperature(s.MinTemperature)
+		s.MaxTemperature = prefs.Temperature(s.MaxTemperature)
+		s.AvgTemperature = prefs.Temperature(s.AvgTemperature)
+		s.MaxWindSpeed = prefs.WindSpeed(s.MaxWindSpeed)
+	}
+
+	response.Units = unitsInfo(prefs)
+}
+
+// LocalizeComparison converts a comparison in place. Deltas and the daily
+// summary are recomputed from the converted values, since a temperature
+// difference does not convert like a temperature.
+func LocalizeComparison(comparison *models.WeatherComparison, prefs units.Preferences) {
+	for p := range comparison.Timeline {
+		for i := range comparison.Timeline[p].Regions {
+			r := &comparison.Timeline[p].Regions[i]
+			r.Temperature = prefs.Temperature(r.Temperature)
+			r.WindSpeed = prefs.WindSpeed(r.WindSpeed)
+			r.Precipitation = prefs.Precipitation(r.Precipitation)
+		}
+	}
+	fillComparison(comparison, regiontz.Location(comparison.Baseline, comparison.Timezone))
+
+	comparison.Units = unitsInfo(prefs)
+}
+
+// LocalizeMapLayer converts the values of a temperature or wind layer in
+// place and names their unit
+func LocalizeMapLayer(layer *models.MapLayer, prefs units.Preferences) {
+	switch layer.Field {
+	case MapFieldTemperature:
+		for i, v := range layer.Values {
+			layer.Values[i] = prefs.Temperature(v)
+		}
+		layer.Unit = prefs.TemperatureUnit()
+	case MapFieldWindSpeed:
+		for i, v := range layer.Values {
+			layer.Values[i] = prefs.WindSpeed(v)
+		}
+		layer.Unit = prefs.WindSpeedUnit()
+	case MapFieldHumidity:
+		layer.Unit = "%"
+	}
+}
+
+// localizeIndices converts temperature-like indices. Humidex is
+// dimensionless and the Beaufort force does not depend on units.
+func localizeIndices(indices *models.DerivedIndices, prefs units.Preferences) {
+	indices.FeelsLike = prefs.Temperature(indices.FeelsLike)
+	indices.HeatIndex = prefs.Temperature(indices.HeatIndex)
+	indices.DewPoint = prefs.Temperature(indices.DewPoint)
+	indices.WindChill = prefs.Temperature(indices.WindChill)
+}
+
+func unitsInfo(prefs units.Preferences) *models.UnitsInfo {
+	return &models.UnitsInfo{
+		System:        string(prefs.System),
+		Temperature:   prefs.TemperatureUnit(),
+		WindSpeed:     prefs.WindSpeedUnit(),
+		Visibility:    prefs.VisibilityUnit(),
+		Precipitation: prefs.PrecipitationUnit(),
+		Lang:          string(prefs.Lang),
+	}
+}
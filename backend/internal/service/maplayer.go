@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/condition"
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/repository"
+)
+
+// ErrNotAggregate is returned for a village code, which has no villages
+// under it to map
+var ErrNotAggregate = errors.New("map layers cover provinces, regencies and districts")
+
+// Map layer fields
+const (
+	MapFieldTemperature = "temperature"
+	MapFieldCondition   = "condition" // condition_code
+	MapFieldRain        = "rain"      // 1 if raining, else 0
+	MapFieldHumidity    = "humidity"
+	MapFieldWindSpeed   = "wind_speed"
+)
+
+var mapFieldValues = map[string]func(point repository.VillagePoint) float64{
+	MapFieldTemperature: func(p repository.VillagePoint) float64 { return p.Temperature },
+	MapFieldCondition: func(p repository.VillagePoint) float64 {
+		return float64(condition.Classify(p.WeatherDesc, p.WeatherDescEn))
+	},
+	MapFieldRain: func(p repository.VillagePoint) float64 {
+		if p.Precipitation > 0 || condition.IsWet(condition.Classify(p.WeatherDesc, p.WeatherDescEn)) {
+			return 1
+		}
+		return 0
+	},
+	MapFieldHumidity:  func(p repository.VillagePoint) float64 { return float64(p.Humidity) },
+	MapFieldWindSpeed: func(p repository.VillagePoint) float64 { return p.WindSpeed },
+}
+
+// IsMapField reports whether field can be requested from GetMapLayer
+func IsMapField(field string) bool {
+	_, ok := mapFieldValues[field]
+	return ok
+}
+
+// GetMapLayer returns one field of the latest synced point for every
+// village under a province, regency or district code
+func (s *WeatherService) GetMapLayer(ctx context.Context, regionCode, field string) (*models.MapLayer, error) {
+	if regionLevel(regionCode) >= 4 {
+		return nil, ErrNotAggregate
+	}
+	if _, err := s.regionRepo.FindByCode(ctx, regionCode); err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, ErrRegionNotFound
+		}
+		return nil, err
+	}
+	value := mapFieldValues[field]
+
+	points, err := s.weatherRepo.FindVillagePoints(ctx, regionCode, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	layer := &models.MapLayer{
+		Region:    regionCode,
+		Field:     field,
+		SyncRunID: s.currentSyncRun(),
+		Codes:     make([]string, len(points)),
+		Lats:      make([]*float64, len(points)),
+		Lons:      make([]*float64, len(points)),
+		Values:    make([]float64, len(points)),
+	}
+	for i, point := range points {
+		layer.Codes[i] = point.Code
+		layer.Lats[i] = roundCoordinate(point.Latitude)
+		layer.Lons[i] = roundCoordinate(point.Longitude)
+		layer.Values[i] = value(point)
+		if point.UTCDatetime.After(layer.ValidAt) {
+			layer.ValidAt = point.UTCDatetime
+		}
+	}
+	return layer, nil
+}
+
+// roundCoordinate keeps four decimals, about 11 m, which is plenty for a
+// village marker and shortens the payload
+func roundCoordinate(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	rounded := math.Round(*v*1e4) / 1e4
+	return &rounded
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"eamagineweather-backend/internal/repository"
+)
+
+// ProbePolicy controls the BMKG availability probe. BMKG does not forecast
+// every village, so hasWeatherData is set from what BMKG actually answers.
+type ProbePolicy struct {
+	Interval         time.Duration // Time between runs; zero or less disables probing
+	BatchSize        int           // Villages probed per run
+	RetryUnavailable time.Duration // Probe villages without a forecast again after this
+	RecheckAvailable time.Duration // Probe villages with a forecast again after this
+}
+
+// ProbeReport summarises one probe run
+type ProbeReport struct {
+	Probed      int
+	Available   int
+	Unavailable int
+	Failed      int // BMKG could not be asked; the flag was left alone
+}
+
+type ProbeService struct {
+	regionRepo  repository.RegionRepository
+	bmkgService *BMKGService
+	policy      ProbePolicy
+}
+
+func NewProbeService(regionRepo repository.RegionRepository, bmkgService *BMKGService, policy ProbePolicy) *ProbeService {
+	if policy.BatchSize < 1 {
+		policy.BatchSize = 200
+	}
+	return &ProbeService{
+		regionRepo:  regionRepo,
+		bmkgService: bmkgService,
+		policy:      policy,
+	}
+}
+
+// StartPeriodicProbe probes a batch of villages every Interval until ctx is
+// done. Probes go through the BMKG rate limiter like every other request,
+// so keep BatchSize well below what the limit allows per Interval.
+func (s *ProbeService) StartPeriodicProbe(ctx context.Context) {
+	if s.policy.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Run(ctx)
+			if err != nil {
+				log.Printf("BMKG availability probe failed: %v", err)
+				continue
+			}
+			if report.Probed > 0 {
+				log.Printf("BMKG availability probe: %d villages, %d available, %d unavailable, %d failed",
+					report.Probed, report.Available, report.Unavailable, report.Failed)
+			}
+		}
+	}
+}
+
+// Run probes the villages that are due, never-probed ones first
+func (s *ProbeService) Run(ctx context.Context) (*ProbeReport, error) {
+	now := time.Now()
+	targets, err := s.regionRepo.FindProbeTargets(ctx, s.policy.BatchSize,
+		now.Add(-s.policy.RetryUnavailable), now.Add(-s.policy.RecheckAvailable))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load probe targets: %w", err)
+	}
+
+	report := &ProbeReport{}
+	for _, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		available, reason, definite := s.probe(ctx, target.Code)
+		if definite {
+			err = s.regionRepo.RecordProbe(ctx, target.ID, available, reason)
+		} else {
+			err = s.regionRepo.RecordProbeFailure(ctx, target.ID, reason)
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to record probe of %s: %w", target.Code, err)
+		}
+
+		report.Probed++
+		switch {
+		case !definite:
+			report.Failed++
+		case available:
+			report.Available++
+		default:
+			report.Unavailable++
+		}
+	}
+	return report, nil
+}
+
+// probe asks BMKG for a village's forecast. definite is false when the
+// answer says nothing about the village, e.g. a timeout or a 5xx.
+func (s *ProbeService) probe(ctx context.Context, regionCode string) (available bool, reason string, definite bool) {
+	data, err := s.bmkgService.GetWeatherData(ctx, regionCode)
+	if err != nil {
+		var statusErr *BMKGStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 &&
+			statusErr.StatusCode != http.StatusTooManyRequests {
+			return false, err.Error(), true
+		}
+		return false, err.Error(), false
+	}
+	if _, err := currentFromBMKG(regionCode, data); err != nil {
+		return false, err.Error(), true
+	}
+	return true, "", true
+}
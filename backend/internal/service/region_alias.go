@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"eamagineweather-backend/internal/repository"
+)
+
+const (
+	// aliasRefresh is how long aliases are served from memory before the
+	// table is read again
+	aliasRefresh = 5 * time.Minute
+	// maxAliasHops bounds alias chains, e.g. a code renumbered twice
+	maxAliasHops = 8
+)
+
+// RegionAliasService resolves region codes retired by pemekaran or
+// renumbering to the codes that replaced them
+type RegionAliasService struct {
+	aliasRepo repository.RegionAliasRepository
+
+	mu       sync.RWMutex
+	aliases  map[string]string
+	loadedAt time.Time
+}
+
+func NewRegionAliasService(aliasRepo repository.RegionAliasRepository) *RegionAliasService {
+	return &RegionAliasService{aliasRepo: aliasRepo}
+}
+
+// Resolve returns the current code for a retired one, following chains of
+// aliases. It reports false for codes that have no alias.
+func (s *RegionAliasService) Resolve(ctx context.Context, code string) (string, bool) {
+	aliases := s.load(ctx)
+	resolved, ok := aliases[code]
+	if !ok {
+		return "", false
+	}
+	for hops := 1; hops < maxAliasHops; hops++ {
+		next, ok := aliases[resolved]
+		if !ok {
+			break
+		}
+		resolved = next
+	}
+	return resolved, resolved != code
+}
+
+// load returns the aliases, reading them again once they are stale. If the
+// read fails the stale aliases are kept.
+func (s *RegionAliasService) load(ctx context.Context) map[string]string {
+	s.mu.RLock()
+	aliases, loadedAt := s.aliases, s.loadedAt
+	s.mu.RUnlock()
+	if time.Since(loadedAt) < aliasRefresh {
+		return aliases
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.loadedAt) < aliasRefresh {
+		return s.aliases
+	}
+	s.loadedAt = time.Now()
+
+	rows, err := s.aliasRepo.FindAll(ctx)
+	if err != nil {
+		log.Printf("Failed to load region aliases: %v", err)
+		return s.aliases
+	}
+	s.aliases = make(map[string]string, len(rows))
+	for _, row := range rows {
+		s.aliases[row.OldCode] = row.NewCode
+	}
+	return s.aliases
+}
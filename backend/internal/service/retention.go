@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"eamagineweather-backend/internal/repository"
+)
+
+// RetentionPolicy controls how long weather_data rows are kept.
+type RetentionPolicy struct {
+	RawDays       int           // Raw points older than this are compacted into daily summaries
+	HardLimitDays int           // Raw points older than this are deleted
+	BatchSize     int           // Maximum rows removed per DELETE statement
+	Interval      time.Duration // Time between runs
+}
+
+// RetentionReport summarises a single retention run.
+type RetentionReport struct {
+	CompactedRows int       `json:"compactedRows"`
+	CompactedDays int       `json:"compactedDays"`
+	DeletedRows   int       `json:"deletedRows"`
+	StartedAt     time.Time `json:"startedAt"`
+	FinishedAt    time.Time `json:"finishedAt"`
+}
+
+type RetentionService struct {
+	weatherRepo repository.WeatherRepository
+	policy      RetentionPolicy
+	now         func() time.Time
+}
+
+func NewRetentionService(weatherRepo repository.WeatherRepository, policy RetentionPolicy) *RetentionService {
+	if policy.RawDays < 1 {
+		policy.RawDays = 1
+	}
+	if policy.HardLimitDays < policy.RawDays {
+		policy.HardLimitDays = policy.RawDays
+	}
+	if policy.BatchSize < 1 {
+		policy.BatchSize = 5000
+	}
+	if policy.Interval <= 0 {
+		policy.Interval = 24 * time.Hour
+	}
+
+	return &RetentionService{
+		weatherRepo: weatherRepo,
+		policy:      policy,
+		now:         time.Now,
+	}
+}
+
+func (s *RetentionService) StartPeriodicRetention(ctx context.Context) {
+	ticker := time.NewTicker(s.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Run(ctx)
+			if err != nil {
+				log.Printf("Weather retention run failed: %v", err)
+				continue
+			}
+			log.Printf("Weather retention: compacted %d rows into %d daily summaries, deleted %d rows",
+				report.CompactedRows, report.CompactedDays, report.DeletedRows)
+		}
+	}
+}
+
+// Run compacts raw points past the raw retention window, then deletes raw
+// points past the hard limit. Compaction always runs first so nothing is
+// deleted before it has been summarised.
+func (s *RetentionService) Run(ctx context.Context) (*RetentionReport, error) {
+	report := &RetentionReport{StartedAt: s.now()}
+
+	// weather_data.localDatetime holds local wall time, so cutoffs are
+	// whole local days rather than instants
+	today := report.StartedAt.UTC().Truncate(24 * time.Hour)
+	rawCutoff := today.AddDate(0, 0, -s.policy.RawDays)
+	hardCutoff := today.AddDate(0, 0, -s.policy.HardLimitDays)
+
+	rows, days, err := s.weatherRepo.CompactBefore(ctx, rawCutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compact weather data: %w", err)
+	}
+	report.CompactedRows = rows
+	report.CompactedDays = days
+
+	deleted, err := s.weatherRepo.DeleteBefore(ctx, hardCutoff, s.policy.BatchSize)
+	report.DeletedRows = deleted
+	if err != nil {
+		return report, fmt.Errorf("failed to delete expired weather data: %w", err)
+	}
+
+	report.FinishedAt = s.now()
+	return report, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/condition"
+	"eamagineweather-backend/internal/models"
+)
+
+// ErrNoRollupData is returned for a province, regency or district none of
+// whose villages have been synced for the current forecast slot
+var ErrNoRollupData = errors.New("no synced village weather for region")
+
+// regionLevel derives the administrative level from a Kemendagri code:
+// 32 is a province, 32.73 a regency, 32.73.01 a district, 32.73.01.1001 a village
+func regionLevel(code string) int {
+	return strings.Count(code, ".") + 1
+}
+
+// GetRegionalWeather summarises the current weather of a province, regency
+// or district from its synced villages. Summaries are cached until the next
+// sync run finishes.
+func (s *WeatherService) GetRegionalWeather(ctx context.Context, regionCode string) (*models.WeatherResponse, error) {
+	region, err := s.regionRepo.FindByCode(ctx, regionCode)
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, ErrRegionNotFound
+		}
+		return nil, err
+	}
+
+	runID := s.currentSyncRun()
+	cacheKey := fmt.Sprintf("rollup:%s:%s", runID, regionCode)
+	if s.redis != nil && runID != "" {
+		if cached, err := s.redis.Get(ctx, cacheKey).Result(); err == nil {
+			var response models.WeatherResponse
+			if err := json.Unmarshal([]byte(cached), &response); err == nil {
+				return &response, nil
+			}
+		}
+	}
+
+	now := time.Now()
+	points, err := s.weatherRepo.FindVillagePoints(ctx, regionCode, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load village weather: %w", err)
+	}
+	if len(points) == 0 {
+		return nil, ErrNoRollupData
+	}
+
+	regional := &models.RegionalWeather{
+		Level:        region.Level,
+		VillageCount: len(points),
+		SyncRunID:    runID,
+	}
+
+	temperatures := make([]float64, len(points))
+	conditions := make(map[condition.Code]int)
+	wet := 0
+	for i, point := range points {
+		temperatures[i] = point.Temperature
+		if point.WindSpeed > regional.MaxWindSpeed {
+			regional.MaxWindSpeed = point.WindSpeed
+		}
+		if point.UTCDatetime.After(regional.ValidAt) {
+			regional.ValidAt = point.UTCDatetime
+		}
+
+		code := condition.Classify(point.WeatherDesc, point.WeatherDescEn)
+		conditions[code]++
+		if condition.IsWet(code) || point.Precipitation > 0 {
+			wet++
+		}
+	}
+
+	sort.Float64s(temperatures)
+	regional.MinTemperature = temperatures[0]
+	regional.MaxTemperature = temperatures[len(temperatures)-1]
+	regional.MedianTemperature = round1(median(temperatures))
+	regional.RainShare = round2(float64(wet) / float64(len(points)))
+
+	// Most villages wins; ties go to the more severe condition
+	dominant := condition.Unknown
+	for code, count := range conditions {
+		if count > conditions[dominant] || (count == conditions[dominant] && condition.Worst(code, dominant) == code) {
+			dominant = code
+		}
+	}
+	regional.ConditionCode = int(dominant)
+	regional.Condition = condition.Lookup(dominant).Key
+
+	response := &models.WeatherResponse{
+		Regional: regional,
+		Region:   regionInfo(region),
+	}
+
+	if s.redis != nil && runID != "" {
+		if data, err := json.Marshal(response); err == nil {
+			s.redis.Set(ctx, cacheKey, data, 2*s.syncPolicy.Interval)
+		}
+	}
+	return response, nil
+}
+
+// median of sorted values
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"eamagineweather-backend/db"
+	"eamagineweather-backend/internal/repository"
+)
+
+// ErrNotVillage is returned when a sync targets a region BMKG has no
+// forecast for
+var ErrNotVillage = errors.New("only villages can be synced")
+
+// SyncPolicy configures the periodic BMKG sync
+type SyncPolicy struct {
+	Interval   time.Duration // Time between runs
+	MaxRegions int           // Most villages fetched per run
+}
+
+// SyncReport summarises one sync run
+type SyncReport struct {
+	RunID   string
+	Regions int
+	Points  int
+	Failed  int
+}
+
+// StartPeriodicSync stores BMKG forecasts for tracked villages every
+// Interval until ctx is done
+func (s *WeatherService) StartPeriodicSync(ctx context.Context) {
+	if run, err := s.syncRepo.FindLatestFinished(ctx); err == nil {
+		finishedAt, _ := run.FinishedAt()
+		s.setSyncRun(run.ID, finishedAt)
+	} else if !db.IsErrNotFound(err) {
+		log.Printf("Failed to load latest weather sync run: %v", err)
+	}
+
+	ticker := time.NewTicker(s.syncPolicy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.Println("Starting periodic weather data sync...")
+			report, err := s.SyncAll(ctx)
+			if err != nil {
+				log.Printf("Weather sync failed: %v", err)
+				continue
+			}
+			log.Printf("Weather sync %s: stored %d points for %d villages, %d failed",
+				report.RunID, report.Points, report.Regions, report.Failed)
+		}
+	}
+}
+
+// SyncAll runs one sync over every tracked village. A village that fails is
+// counted and skipped. Once the run is recorded, regional roll-ups are
+// computed afresh for it. The run is finished on every return path; one that
+// stops early is finished with the reason.
+func (s *WeatherService) SyncAll(ctx context.Context) (report *SyncReport, err error) {
+	run, err := s.syncRepo.Start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start sync run: %w", err)
+	}
+
+	report = &SyncReport{RunID: run.ID}
+	defer func() {
+		if err == nil {
+			return
+		}
+		// Finish on a fresh context, as ctx may be why the run stopped
+		if _, finishErr := s.syncRepo.Finish(context.WithoutCancel(ctx), run.ID,
+			report.Regions, report.Points, report.Failed, err.Error()); finishErr != nil {
+			log.Printf("Failed to finish weather sync run %s: %v", run.ID, finishErr)
+			return
+		}
+		s.resetDataVersion()
+	}()
+
+	targets, err := s.regionRepo.FindSyncTargets(ctx, s.syncPolicy.MaxRegions)
+	if err != nil {
+		return report, fmt.Errorf("failed to load sync targets: %w", err)
+	}
+
+	var mu sync.Mutex
+
+	queue := make(chan repository.SyncTarget)
+	var wg sync.WaitGroup
+	for i := 0; i < batchConcurrency && i < len(targets); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range queue {
+				points, err := s.syncRegion(ctx, target.ID, target.Code)
+				mu.Lock()
+				if err != nil {
+					log.Printf("Failed to sync weather for %s: %v", target.Code, err)
+					report.Failed++
+				} else {
+					report.Regions++
+					report.Points += points
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, target := range targets {
+		queue <- target
+	}
+	close(queue)
+	wg.Wait()
+
+	// Should this fail, the deferred Finish tries again with the reason
+	finished, err := s.syncRepo.Finish(ctx, run.ID, report.Regions, report.Points, report.Failed, "")
+	if err != nil {
+		return report, fmt.Errorf("failed to finish sync run: %w", err)
+	}
+	finishedAt, _ := finished.FinishedAt()
+	s.setSyncRun(run.ID, finishedAt)
+	return report, nil
+}
+
+// SyncWeatherData stores the current BMKG forecast for one village and
+// returns the number of points written
+func (s *WeatherService) SyncWeatherData(ctx context.Context, regionCode string) (int, error) {
+	region, err := s.regionRepo.FindByCode(ctx, regionCode)
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			return 0, ErrRegionNotFound
+		}
+		return 0, err
+	}
+	if region.Level != 4 {
+		return 0, ErrNotVillage
+	}
+	points, err := s.syncRegion(ctx, region.ID, region.Code)
+	if err != nil {
+		return 0, err
+	}
+
+	s.resetDataVersion()
+	return points, nil
+}
+
+func (s *WeatherService) syncRegion(ctx context.Context, regionID, regionCode string) (int, error) {
+	weatherData, err := s.bmkgService.GetWeatherData(ctx, regionCode)
+	if err != nil {
+		return 0, err
+	}
+
+	points := make([]repository.WeatherPoint, 0, len(weatherData.Data))
+	for _, data := range weatherData.Data {
+		utcTime, err := time.Parse("2006-01-02 15:04:05", data.UTCDatetime)
+		if err != nil {
+			continue // Skip invalid datetime
+		}
+		localTime, err := time.Parse("2006-01-02 15:04:05", data.LocalDatetime)
+		if err != nil {
+			continue // Skip invalid datetime
+		}
+		analysisDate, err := time.Parse("2006-01-02T15:04:05", data.AnalysisDate)
+		if err != nil {
+			analysisDate = utcTime
+		}
+
+		points = append(points, repository.WeatherPoint{
+			UTCDatetime:   utcTime,
+			LocalDatetime: localTime,
+			Temperature:   data.Temperature,
+			Humidity:      data.Humidity,
+			WeatherDesc:   data.WeatherDesc,
+			WeatherDescEn: data.WeatherDescEn,
+			WindSpeed:     data.WindSpeed,
+			WindDirection: data.WindDirection,
+			CloudCover:    data.CloudCover,
+			Visibility:    data.Visibility,
+			Precipitation: data.Precipitation,
+			AnalysisDate:  analysisDate,
+		})
+	}
+
+	return s.weatherRepo.UpsertPoints(ctx, regionID, points)
+}
+
+func (s *WeatherService) setSyncRun(id string, finishedAt time.Time) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	s.syncRunID = id
+	s.syncFinishedAt = finishedAt
+	s.resetDataVersion()
+	for sub := range s.syncSubs {
+		select {
+		case sub <- id:
+		default: // Still holding an earlier run; it will reload anyway
+		}
+	}
+}
+
+// SubscribeSyncRuns returns a channel receiving the id of each sync run
+// that finishes from now on, and a function that unsubscribes. A slow
+// subscriber misses runs rather than holding up the sync.
+func (s *WeatherService) SubscribeSyncRuns() (<-chan string, func()) {
+	sub := make(chan string, 1)
+	s.syncMu.Lock()
+	if s.syncSubs == nil {
+		s.syncSubs = make(map[chan string]struct{})
+	}
+	s.syncSubs[sub] = struct{}{}
+	s.syncMu.Unlock()
+
+	return sub, func() {
+		s.syncMu.Lock()
+		delete(s.syncSubs, sub)
+		s.syncMu.Unlock()
+	}
+}
+
+// currentSyncRun returns the id of the latest finished sync run, or "" if
+// none has finished since the database was created
+func (s *WeatherService) currentSyncRun() string {
+	s.syncMu.RLock()
+	defer s.syncMu.RUnlock()
+	return s.syncRunID
+}
+
+// DataVersion identifies the stored weather data, for HTTP validators. Tag
+// changes whenever the data does and Modified is when it last did, zero
+// before any data is stored.
+type DataVersion struct {
+	Tag      string
+	Modified time.Time
+}
+
+// dataVersionTTL is how long a loaded data version is reused. Writes by this
+// instance reset it at once; writes by another instance sharing the database
+// show up within this long.
+const dataVersionTTL = time.Minute
+
+// DataVersion returns the version of the stored weather data, derived from
+// the database alone: the latest finished sync run and the latest write to
+// weather_data, which manual syncs advance too. Every instance sharing the
+// database agrees on it, and it survives restarts.
+func (s *WeatherService) DataVersion(ctx context.Context) (DataVersion, error) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	if !s.versionLoaded.IsZero() && time.Since(s.versionLoaded) < dataVersionTTL {
+		return s.version, nil
+	}
+
+	var runID string
+	var modified time.Time
+	run, err := s.syncRepo.FindLatestFinished(ctx)
+	if err == nil {
+		runID = run.ID
+		modified, _ = run.FinishedAt()
+	} else if !db.IsErrNotFound(err) {
+		return DataVersion{}, err
+	}
+	updated, err := s.weatherRepo.LatestUpdate(ctx)
+	if err != nil {
+		return DataVersion{}, err
+	}
+	if updated.After(modified) {
+		modified = updated
+	}
+
+	s.version = DataVersion{
+		Tag:      fmt.Sprintf("%s.%d", runID, updated.UnixMilli()),
+		Modified: modified,
+	}
+	s.versionLoaded = time.Now()
+	return s.version, nil
+}
+
+// resetDataVersion makes the next DataVersion reload from the database
+func (s *WeatherService) resetDataVersion() {
+	s.versionMu.Lock()
+	s.versionLoaded = time.Time{}
+	s.versionMu.Unlock()
+}
+
+// NextSyncAt estimates when the next sync run finishes, so responses built
+// from synced data can be cached until then. Before the first run it
+// assumes one interval from now.
+func (s *WeatherService) NextSyncAt() time.Time {
+	s.syncMu.RLock()
+	finishedAt := s.syncFinishedAt
+	s.syncMu.RUnlock()
+
+	now := time.Now()
+	if s.syncPolicy.Interval <= 0 {
+		return now
+	}
+	if finishedAt.IsZero() {
+		return now.Add(s.syncPolicy.Interval)
+	}
+	next := finishedAt.Add(s.syncPolicy.Interval)
+	for !next.After(now) {
+		next = next.Add(s.syncPolicy.Interval)
+	}
+	return next
+}
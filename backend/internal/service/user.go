@@ -2,14 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
+	"eamagineweather-backend/db"
 	"eamagineweather-backend/internal/repository"
+	"eamagineweather-backend/internal/units"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
 type UserService struct {
-	userRepo *repository.UserRepository
+	userRepo repository.UserRepository
 }
 
-func NewUserService(userRepo *repository.UserRepository) *UserService {
+func NewUserService(userRepo repository.UserRepository) *UserService {
 	return &UserService{userRepo: userRepo}
 }
 
@@ -36,4 +41,41 @@ func (s *UserService) AddFavorite(ctx context.Context, userID string, regionID s
 func (s *UserService) RemoveFavorite(ctx context.Context, userID string, regionID string) error {
 	// TODO: Implement remove favorite logic
 	return nil
-}
\ No newline at end of file
+}
+
+// GetPreferences returns the saved display units and language of a user.
+// Stored values that no longer parse fall back to the defaults.
+func (s *UserService) GetPreferences(ctx context.Context, userID string) (units.Preferences, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if db.IsErrNotFound(err) {
+			return units.Default, ErrUserNotFound
+		}
+		return units.Default, err
+	}
+
+	prefs := units.Default
+	if system, ok := units.ParseSystem(user.Units); ok {
+		prefs.System = system
+		prefs.Wind = units.DefaultWindUnit(system)
+	}
+	if value, ok := user.WindUnit(); ok {
+		if wind, ok := units.ParseWindUnit(value); ok {
+			prefs.Wind = wind
+		}
+	}
+	if lang, ok := units.ParseLang(user.Language); ok {
+		prefs.Lang = lang
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences saves the display units and language of a user. A zero
+// Wind follows the unit system.
+func (s *UserService) UpdatePreferences(ctx context.Context, userID string, prefs units.Preferences) error {
+	_, err := s.userRepo.UpdatePreferences(ctx, userID, string(prefs.System), string(prefs.Wind), string(prefs.Lang))
+	if db.IsErrNotFound(err) {
+		return ErrUserNotFound
+	}
+	return err
+}
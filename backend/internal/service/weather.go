@@ -2,74 +2,135 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log"
+	"sync"
 	"time"
 
+	"eamagineweather-backend/internal/models"
+	"eamagineweather-backend/internal/regiontz"
 	"eamagineweather-backend/internal/repository"
 	"github.com/redis/go-redis/v9"
 )
 
 type WeatherService struct {
-	weatherRepo *repository.WeatherRepository
-	regionRepo  *repository.RegionRepository
+	weatherRepo repository.WeatherRepository
+	regionRepo  repository.RegionRepository
+	syncRepo    repository.SyncRunRepository
 	bmkgService *BMKGService
 	redis       *redis.Client
+	syncPolicy  SyncPolicy
+
+	syncMu         sync.RWMutex
+	syncRunID      string    // Latest finished sync run
+	syncFinishedAt time.Time // When syncRunID finished
+	syncSubs       map[chan string]struct{}
+
+	versionMu     sync.Mutex
+	version       DataVersion // Memoized by DataVersion
+	versionLoaded time.Time   // When version was loaded, zero to reload
 }
 
 func NewWeatherService(
-	weatherRepo *repository.WeatherRepository,
-	regionRepo *repository.RegionRepository,
+	weatherRepo repository.WeatherRepository,
+	regionRepo repository.RegionRepository,
+	syncRepo repository.SyncRunRepository,
 	bmkgService *BMKGService,
 	redisClient *redis.Client,
+	syncPolicy SyncPolicy,
 ) *WeatherService {
 	return &WeatherService{
 		weatherRepo: weatherRepo,
 		regionRepo:  regionRepo,
+		syncRepo:    syncRepo,
 		bmkgService: bmkgService,
 		redis:       redisClient,
+		syncPolicy:  syncPolicy,
 	}
 }
 
-func (s *WeatherService) StartPeriodicSync(ctx context.Context) {
-	ticker := time.NewTicker(3 * time.Hour)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			log.Println("Starting periodic weather data sync...")
-			// TODO: Implement periodic sync logic
+func (s *WeatherService) GetCurrentWeather(ctx context.Context, regionCode string) (*models.WeatherResponse, error) {
+	// BMKG only forecasts villages; larger regions are summarised from them
+	if regionLevel(regionCode) < 4 {
+		weather, err := s.GetRegionalWeather(ctx, regionCode)
+		if !errors.Is(err, ErrRegionNotFound) {
+			return weather, err
 		}
+		// Region not in our database - try direct BMKG API call
 	}
+
+	return s.FetchWeatherDirectFromBMKG(ctx, regionCode)
 }
 
-func (s *WeatherService) GetCurrentWeather(ctx context.Context, regionCode string) (interface{}, error) {
-	// Try cache first
-	cacheKey := "weather:" + regionCode
-	cached := s.redis.Get(ctx, cacheKey)
-	if cached.Err() == nil {
-		// Return cached data - TODO: implement proper caching
+// FetchWeatherDirectFromBMKG returns the current weather BMKG reports for a
+// code, whether or not the region is in our database
+func (s *WeatherService) FetchWeatherDirectFromBMKG(ctx context.Context, regionCode string) (*models.WeatherResponse, error) {
+	current, err := s.bmkgService.GetCurrentWeather(ctx, regionCode)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if region exists in database
-	region, err := s.regionRepo.FindByCode(ctx, regionCode)
-	if err != nil || region == nil {
-		// Region not in database - try direct BMKG API call
-		return s.bmkgService.GetWeatherData(ctx, regionCode)
-	}
+	return s.currentResponse(ctx, regionCode, current), nil
+}
 
-	// Region exists in database - fetch weather from BMKG API
-	return s.bmkgService.GetWeatherData(ctx, regionCode)
+// currentResponse wraps current conditions with region details
+func (s *WeatherService) currentResponse(ctx context.Context, regionCode string, current *models.CurrentWeather) *models.WeatherResponse {
+	region := s.lookupRegionInfo(ctx, regionCode)
+	setConditionIcon(region, &current.WeatherCondition, current.DateTime)
+	return &models.WeatherResponse{
+		Current:   current,
+		Astronomy: astronomyFor(region, current.DateTime),
+		Region:    region,
+	}
 }
 
-func (s *WeatherService) FetchWeatherDirectFromBMKG(ctx context.Context, regionCode string) (interface{}, error) {
-	// Call BMKG API directly for regions not in our database
-	return s.bmkgService.GetWeatherData(ctx, regionCode)
+func (s *WeatherService) GetWeatherForecast(ctx context.Context, regionCode string) (*models.WeatherResponse, error) {
+	forecast, err := s.bmkgService.GetWeatherForecast(ctx, regionCode)
+	if err != nil {
+		return nil, err
+	}
+
+	region := s.lookupRegionInfo(ctx, regionCode)
+	for i := range forecast {
+		setConditionIcon(region, &forecast[i].WeatherCondition, forecast[i].DateTime)
+	}
+	return &models.WeatherResponse{
+		Forecast: forecast,
+		Daily:    dailyForecasts(region, forecast),
+		Region:   region,
+	}, nil
 }
 
-func (s *WeatherService) SyncWeatherData(ctx context.Context, regionCode string) error {
-	// TODO: Implement sync logic
-	return nil
-}
\ No newline at end of file
+// lookupRegionInfo describes a region from the database. Codes that are not
+// in our database are still served straight from BMKG, so only the code is
+// known for them. Coordinates missing from the database are taken from the
+// BMKG response and stored for next time.
+func (s *WeatherService) lookupRegionInfo(ctx context.Context, regionCode string) models.RegionInfo {
+	info := models.RegionInfo{
+		Code:     regionCode,
+		Timezone: regiontz.Location(regionCode, "").String(),
+	}
+
+	region, err := s.regionRepo.FindByCode(ctx, regionCode)
+	if err == nil && region != nil {
+		info = regionInfo(region)
+	}
+	if info.Latitude != nil && info.Longitude != nil {
+		return info
+	}
+
+	location, err := s.bmkgService.GetLocation(ctx, regionCode)
+	if err != nil || location == nil {
+		return info
+	}
+	info.Latitude = &location.Latitude
+	info.Longitude = &location.Longitude
+	info.Timezone = regiontz.Location(regionCode, location.Timezone).String()
+
+	if region != nil {
+		if _, err := s.regionRepo.UpdateLocation(ctx, region.ID, location.Latitude, location.Longitude, location.Timezone); err != nil {
+			log.Printf("Failed to store location for region %s: %v", regionCode, err)
+		}
+	}
+	return info
+}
@@ -0,0 +1,45 @@
+// Package syndication writes Atom 1.0 and RSS 2.0 feeds from one
+// format-neutral description, so both feeds always carry the same items.
+package syndication
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Feed describes a feed independently of its format
+type Feed struct {
+	ID          string // Stable IRI identifying the feed
+	Title       string
+	Description string
+	Author      string
+	SelfURL     string // Where the feed itself is served
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item is one feed entry
+type Item struct {
+	ID         string // Stable IRI; must not change when the item is edited
+	Title      string
+	Summary    string
+	Link       string
+	LinkType   string // Media type of Link, e.g. "application/cap+xml"
+	Published  time.Time
+	Updated    time.Time
+	Categories []string
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
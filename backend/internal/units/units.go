@@ -0,0 +1,238 @@
+// Package units converts BMKG's fixed units (°C, km/h, km visibility and mm
+// precipitation) into the unit system and language a caller asked for.
+package units
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// System is a family of display units
+type System string
+
+const (
+	Metric   System = "metric"   // °C, km/h, km, mm
+	Imperial System = "imperial" // °F, mph, mi, in
+	SI       System = "si"       // K, m/s, m, mm
+)
+
+// WindUnit overrides the wind speed unit of a System
+type WindUnit string
+
+const (
+	KilometresPerHour WindUnit = "kmh"
+	MetresPerSecond   WindUnit = "ms"
+	Knots             WindUnit = "kn"
+	MilesPerHour      WindUnit = "mph"
+)
+
+// Lang is a response language
+type Lang string
+
+const (
+	Indonesian Lang = "id"
+	English    Lang = "en"
+)
+
+// Preferences selects units and language for a response
+type Preferences struct {
+	System System
+	Wind   WindUnit
+	Lang   Lang
+}
+
+// Default matches the values BMKG publishes
+var Default = Preferences{System: Metric, Wind: KilometresPerHour, Lang: Indonesian}
+
+// ParseSystem accepts metric, imperial or si
+func ParseSystem(value string) (System, bool) {
+	switch s := System(strings.ToLower(strings.TrimSpace(value))); s {
+	case Metric, Imperial, SI:
+		return s, true
+	}
+	return "", false
+}
+
+// ParseWindUnit accepts kmh, ms, kn or mph, plus common spellings
+func ParseWindUnit(value string) (WindUnit, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "kmh", "km/h", "kph":
+		return KilometresPerHour, true
+	case "ms", "m/s", "mps":
+		return MetresPerSecond, true
+	case "kn", "kt", "knots":
+		return Knots, true
+	case "mph":
+		return MilesPerHour, true
+	}
+	return "", false
+}
+
+// ParseLang accepts id or en, including region-qualified tags such as en-US
+func ParseLang(value string) (Lang, bool) {
+	tag := strings.ToLower(strings.TrimSpace(value))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	switch Lang(tag) {
+	case Indonesian, English:
+		return Lang(tag), true
+	}
+	return "", false
+}
+
+// DefaultWindUnit returns the wind unit that belongs to a System
+func DefaultWindUnit(system System) WindUnit {
+	switch system {
+	case Imperial:
+		return MilesPerHour
+	case SI:
+		return MetresPerSecond
+	default:
+		return KilometresPerHour
+	}
+}
+
+// Temperature converts °C
+func (p Preferences) Temperature(celsius float64) float64 {
+	switch p.System {
+	case Imperial:
+		return round(celsius*9/5+32, 1)
+	case SI:
+		return round(celsius+273.15, 2)
+	default:
+		return celsius
+	}
+}
+
+// TemperatureUnit returns the symbol Temperature converts to
+func (p Preferences) TemperatureUnit() string {
+	switch p.System {
+	case Imperial:
+		return "°F"
+	case SI:
+		return "K"
+	default:
+		return "°C"
+	}
+}
+
+// WindSpeed converts km/h
+func (p Preferences) WindSpeed(kmh float64) float64 {
+	switch p.wind() {
+	case MetresPerSecond:
+		return round(kmh/3.6, 1)
+	case Knots:
+		return round(kmh/1.852, 1)
+	case MilesPerHour:
+		return round(kmh/1.609344, 1)
+	default:
+		return kmh
+	}
+}
+
+// WindSpeedUnit returns the symbol WindSpeed converts to
+func (p Preferences) WindSpeedUnit() string {
+	switch p.wind() {
+	case MetresPerSecond:
+		return "m/s"
+	case Knots:
+		return "kn"
+	case MilesPerHour:
+		return "mph"
+	default:
+		return "km/h"
+	}
+}
+
+func (p Preferences) wind() WindUnit {
+	if p.Wind != "" {
+		return p.Wind
+	}
+	return DefaultWindUnit(p.System)
+}
+
+// Precipitation converts mm
+func (p Preferences) Precipitation(mm float64) float64 {
+	if p.System == Imperial {
+		return round(mm/25.4, 2)
+	}
+	return mm
+}
+
+// PrecipitationUnit returns the symbol Precipitation converts to
+func (p Preferences) PrecipitationUnit() string {
+	if p.System == Imperial {
+		return "in"
+	}
+	return "mm"
+}
+
+// VisibilityUnit returns the unit Visibility converts to
+func (p Preferences) VisibilityUnit() string {
+	switch p.System {
+	case Imperial:
+		return "mi"
+	case SI:
+		return "m"
+	default:
+		return "km"
+	}
+}
+
+var visibilityPattern = regexp.MustCompile(`^\s*([<>]=?)?\s*([0-9]+(?:[.,][0-9]+)?)\s*(km|m)?\s*$`)
+
+// Visibility converts BMKG's free-form vs_text, such as "> 10 km" or
+// "< 1 km", keeping any comparison prefix. Text it cannot parse is returned
+// unchanged.
+func (p Preferences) Visibility(text string) string {
+	match := visibilityPattern.FindStringSubmatch(strings.ToLower(text))
+	if match == nil {
+		return text
+	}
+
+	value, err := strconv.ParseFloat(strings.Replace(match[2], ",", ".", 1), 64)
+	if err != nil {
+		return text
+	}
+	km := value
+	if match[3] == "m" {
+		km = value / 1000
+	}
+
+	var converted float64
+	switch p.System {
+	case Imperial:
+		converted = round(km/1.609344, 1)
+	case SI:
+		converted = round(km*1000, 0)
+	default:
+		converted = round(km, 1)
+	}
+
+	formatted := strconv.FormatFloat(converted, 'f', -1, 64) + " " + p.VisibilityUnit()
+	if match[1] != "" {
+		return fmt.Sprintf("%s %s", match[1], formatted)
+	}
+	return formatted
+}
+
+// Pick returns the text for the preferred language, falling back to the
+// other when it is empty
+func (p Preferences) Pick(indonesian, english string) string {
+	if p.Lang == English && english != "" {
+		return english
+	}
+	if indonesian == "" {
+		return english
+	}
+	return indonesian
+}
+
+func round(v float64, places int) float64 {
+	pow := math.Pow10(places)
+	return math.Round(v*pow) / pow
+}
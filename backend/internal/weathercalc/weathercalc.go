@@ -0,0 +1,121 @@
+// Package weathercalc derives meteorological indices from the raw values BMKG
+// publishes: air temperature in °C, relative humidity in % and wind speed in
+// km/h. All functions are pure and safe for concurrent use.
+package weathercalc
+
+import "math"
+
+// Magnus coefficients (Sonntag 1990) used for vapour pressure and dew point
+const (
+	magnusA = 17.62
+	magnusB = 243.12 // °C
+)
+
+// ApparentTemperature returns the Australian Bureau of Meteorology apparent
+// temperature (Steadman 1994, shade version) in °C.
+func ApparentTemperature(tempC, humidity, windKmh float64) float64 {
+	e := humidity / 100 * 6.105 * math.Exp(17.27*tempC/(237.7+tempC))
+	ws := windKmh / 3.6
+	return tempC + 0.33*e - 0.70*ws - 4.00
+}
+
+// HeatIndex returns the NOAA heat index in °C using the Rothfusz regression
+// with the NWS low and high humidity adjustments. Below roughly 27°C the
+// simple Steadman formula applies, which stays close to the air temperature.
+func HeatIndex(tempC, humidity float64) float64 {
+	t := CelsiusToFahrenheit(tempC)
+	rh := humidity
+
+	hi := 0.5 * (t + 61.0 + (t-68.0)*1.2 + rh*0.094)
+	if (hi+t)/2 < 80 {
+		return FahrenheitToCelsius(hi)
+	}
+
+	hi = -42.379 + 2.04901523*t + 10.14333127*rh -
+		0.22475541*t*rh - 0.00683783*t*t - 0.05481717*rh*rh +
+		0.00122874*t*t*rh + 0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+
+	switch {
+	case rh < 13 && t >= 80 && t <= 112:
+		hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+	case rh > 85 && t >= 80 && t <= 87:
+		hi += (rh - 85) / 10 * (87 - t) / 5
+	}
+
+	return FahrenheitToCelsius(hi)
+}
+
+// DewPoint returns the dew point in °C using the Magnus formula. Humidity is
+// clamped to 1–100% so the logarithm stays finite.
+func DewPoint(tempC, humidity float64) float64 {
+	rh := math.Min(math.Max(humidity, 1), 100)
+	gamma := math.Log(rh/100) + magnusA*tempC/(magnusB+tempC)
+	return magnusB * gamma / (magnusA - gamma)
+}
+
+// Humidex returns the Environment Canada humidex, a dimensionless value on
+// the same scale as °C.
+func Humidex(tempC, humidity float64) float64 {
+	dewK := DewPoint(tempC, humidity) + 273.15
+	e := 6.11 * math.Exp(5417.7530*(1/273.16-1/dewK))
+	return tempC + 0.5555*(e-10)
+}
+
+// WindChill returns the North American wind chill index in °C. Outside the
+// formula's validity range (air above 10°C or wind below 4.8 km/h) it returns
+// the air temperature unchanged.
+func WindChill(tempC, windKmh float64) float64 {
+	if tempC > 10 || windKmh < 4.8 {
+		return tempC
+	}
+	v := math.Pow(windKmh, 0.16)
+	return 13.12 + 0.6215*tempC - 11.37*v + 0.3965*tempC*v
+}
+
+// BeaufortScale describes a Beaufort force with its WMO descriptions
+type BeaufortScale struct {
+	Force         int
+	Description   string // Indonesian
+	DescriptionEn string // English
+}
+
+// beaufortScale lists the exclusive upper bound in km/h of each force, per
+// the km/h bands of WMO No. 306. BMKG reports wind in km/h, so the bands are
+// not converted from m/s, whose rounding differs at the edges.
+var beaufortScale = []struct {
+	belowKmh float64
+	BeaufortScale
+}{
+	{1, BeaufortScale{0, "Tenang", "Calm"}},
+	{6, BeaufortScale{1, "Sedikit tenang", "Light air"}},
+	{12, BeaufortScale{2, "Sepoi lemah", "Light breeze"}},
+	{20, BeaufortScale{3, "Sepoi lembut", "Gentle breeze"}},
+	{29, BeaufortScale{4, "Sepoi sedang", "Moderate breeze"}},
+	{39, BeaufortScale{5, "Sepoi segar", "Fresh breeze"}},
+	{50, BeaufortScale{6, "Sepoi kuat", "Strong breeze"}},
+	{62, BeaufortScale{7, "Angin ribut lemah", "Near gale"}},
+	{75, BeaufortScale{8, "Angin ribut", "Gale"}},
+	{89, BeaufortScale{9, "Angin ribut kuat", "Strong gale"}},
+	{103, BeaufortScale{10, "Badai", "Storm"}},
+	{118, BeaufortScale{11, "Badai kuat", "Violent storm"}},
+}
+
+// Beaufort returns the Beaufort force for a wind speed in km/h
+func Beaufort(windKmh float64) BeaufortScale {
+	for _, level := range beaufortScale {
+		if windKmh < level.belowKmh {
+			return level.BeaufortScale
+		}
+	}
+	return BeaufortScale{12, "Topan", "Hurricane force"}
+}
+
+// CelsiusToFahrenheit converts °C to °F
+func CelsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
+
+// FahrenheitToCelsius converts °F to °C
+func FahrenheitToCelsius(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
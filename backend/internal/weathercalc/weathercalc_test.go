@@ -0,0 +1,238 @@
+package weathercalc
+
+import (
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+// The NWS heat index chart, in °F and % relative humidity. The chart rounds
+// the Rothfusz regression to whole degrees.
+func TestHeatIndexNOAAChart(t *testing.T) {
+	tests := []struct {
+		tempF, humidity, want float64
+	}{
+		{80, 40, 80},
+		{86, 90, 105},
+		{90, 50, 95},
+		{90, 70, 106},
+		{96, 65, 121},
+		{100, 40, 109},
+		{104, 55, 137},
+	}
+	for _, tt := range tests {
+		got := CelsiusToFahrenheit(HeatIndex(FahrenheitToCelsius(tt.tempF), tt.humidity))
+		if math.Abs(got-tt.want) > 1 {
+			t.Errorf("HeatIndex(%v°F, %v%%) = %.1f°F, want %v°F", tt.tempF, tt.humidity, got, tt.want)
+		}
+	}
+}
+
+// The Environment Canada wind chill chart, in °C and km/h, rounded to whole
+// degrees
+func TestWindChillChart(t *testing.T) {
+	tests := []struct {
+		tempC, windKmh, want float64
+	}{
+		{5, 40, -1},
+		{0, 10, -3},
+		{-10, 20, -18},
+		{-20, 30, -33},
+		{-30, 50, -49},
+	}
+	for _, tt := range tests {
+		got := WindChill(tt.tempC, tt.windKmh)
+		if math.Abs(got-tt.want) > 0.5 {
+			t.Errorf("WindChill(%v°C, %v km/h) = %.2f°C, want %v°C", tt.tempC, tt.windKmh, got, tt.want)
+		}
+	}
+}
+
+func TestWindChillOutsideRange(t *testing.T) {
+	for _, tt := range []struct{ tempC, windKmh float64 }{{15, 30}, {-5, 3}} {
+		if got := WindChill(tt.tempC, tt.windKmh); got != tt.tempC {
+			t.Errorf("WindChill(%v°C, %v km/h) = %v, want the air temperature", tt.tempC, tt.windKmh, got)
+		}
+	}
+}
+
+// Dew points from the Magnus formula with the coefficients WMO-CIMO
+// recommends over water
+func TestDewPointReference(t *testing.T) {
+	tests := []struct {
+		tempC, humidity, want float64
+	}{
+		{10, 90, 8.4},
+		{20, 50, 9.3},
+		{25, 60, 16.7},
+		{30, 80, 26.2},
+		{35, 30, 14.8},
+	}
+	for _, tt := range tests {
+		got := DewPoint(tt.tempC, tt.humidity)
+		if math.Abs(got-tt.want) > 0.1 {
+			t.Errorf("DewPoint(%v°C, %v%%) = %.2f°C, want %v°C", tt.tempC, tt.humidity, got, tt.want)
+		}
+	}
+}
+
+// The Environment Canada humidex table, indexed by air temperature and dew
+// point in °C and rounded to whole numbers. Dew points are turned into the
+// relative humidity Humidex takes with the same Magnus formula.
+func TestHumidexTable(t *testing.T) {
+	tests := []struct {
+		tempC, dewC, want float64
+	}{
+		{25, 20, 33},
+		{30, 15, 34},
+		{30, 20, 38},
+		{30, 25, 42},
+		{35, 20, 43},
+		{35, 25, 47},
+		{40, 25, 52},
+	}
+	for _, tt := range tests {
+		humidity := 100 * math.Exp(magnusA*tt.dewC/(magnusB+tt.dewC)-magnusA*tt.tempC/(magnusB+tt.tempC))
+		got := Humidex(tt.tempC, humidity)
+		if math.Abs(got-tt.want) > 0.5 {
+			t.Errorf("Humidex(%v°C, dew point %v°C) = %.2f, want %v", tt.tempC, tt.dewC, got, tt.want)
+		}
+	}
+}
+
+// The km/h bands of the WMO Beaufort scale, checked at both edges of every
+// band
+func TestBeaufortBands(t *testing.T) {
+	bands := []struct {
+		force    int
+		from, to float64 // km/h, to exclusive
+	}{
+		{0, 0, 1},
+		{1, 1, 6},
+		{2, 6, 12},
+		{3, 12, 20},
+		{4, 20, 29},
+		{5, 29, 39},
+		{6, 39, 50},
+		{7, 50, 62},
+		{8, 62, 75},
+		{9, 75, 89},
+		{10, 89, 103},
+		{11, 103, 118},
+		{12, 118, 300},
+	}
+	for _, band := range bands {
+		for _, windKmh := range []float64{band.from, band.to - 0.1} {
+			if got := Beaufort(windKmh); got.Force != band.force {
+				t.Errorf("Beaufort(%v km/h) = force %d, want %d", windKmh, got.Force, band.force)
+			}
+		}
+	}
+	if got := Beaufort(75); got.Description != "Angin ribut kuat" || got.DescriptionEn != "Strong gale" {
+		t.Errorf("Beaufort(75 km/h) = %+v", got)
+	}
+}
+
+// Apparent temperatures worked by hand from the formula the Australian
+// Bureau of Meteorology publishes, with wind in m/s
+func TestApparentTemperatureBoM(t *testing.T) {
+	tests := []struct {
+		tempC, humidity, windKmh, want float64
+	}{
+		{25, 50, 0, 26.2},
+		{28, 90, 0, 35.2},
+		{20, 60, 7.2, 19.2},
+		{30, 70, 10, 33.8},
+		{32, 80, 18, 37.0},
+		{35, 40, 36, 31.4},
+	}
+	for _, tt := range tests {
+		got := ApparentTemperature(tt.tempC, tt.humidity, tt.windKmh)
+		if math.Abs(got-tt.want) > 0.1 {
+			t.Errorf("ApparentTemperature(%v°C, %v%%, %v km/h) = %.2f°C, want %v°C", tt.tempC, tt.humidity, tt.windKmh, got, tt.want)
+		}
+	}
+
+	// Every m/s of wind takes 0.7°C off
+	wind := func(tRaw, rhRaw, wRaw uint16) bool {
+		temp, rh, kmh := between(tRaw, -10, 45), between(rhRaw, 0, 100), between(wRaw, 0, 100)
+		return math.Abs(ApparentTemperature(temp, rh, kmh)-ApparentTemperature(temp, rh, kmh+3.6)-0.7) < 1e-9
+	}
+	if err := quick.Check(wind, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// between maps a generated value onto [lo, hi], so quick explores only the
+// range a property holds in
+func between(x uint16, lo, hi float64) float64 {
+	return lo + float64(x)/math.MaxUint16*(hi-lo)
+}
+
+func TestDewPointProperties(t *testing.T) {
+	// Never above the air temperature, and equal to it at saturation
+	bounded := func(tRaw, rhRaw uint16) bool {
+		temp, rh := between(tRaw, -40, 50), between(rhRaw, 1, 100)
+		return DewPoint(temp, rh) <= temp+1e-9 && math.Abs(DewPoint(temp, 100)-temp) < 1e-9
+	}
+	if err := quick.Check(bounded, nil); err != nil {
+		t.Error("dew point above air temperature:", err)
+	}
+
+	// Rises with humidity
+	monotonic := func(tRaw, aRaw, bRaw uint16) bool {
+		temp := between(tRaw, -40, 50)
+		lo, hi := between(aRaw, 1, 100), between(bRaw, 1, 100)
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		return DewPoint(temp, lo) <= DewPoint(temp, hi)
+	}
+	if err := quick.Check(monotonic, nil); err != nil {
+		t.Error("dew point falls as humidity rises:", err)
+	}
+}
+
+func TestWindChillProperties(t *testing.T) {
+	// Never above the air temperature, and colder the stronger the wind
+	property := func(tRaw, aRaw, bRaw uint16) bool {
+		temp := between(tRaw, -50, 10)
+		slow, fast := between(aRaw, 4.8, 150), between(bRaw, 4.8, 150)
+		if slow > fast {
+			slow, fast = fast, slow
+		}
+		return WindChill(temp, slow) <= temp && WindChill(temp, fast) <= WindChill(temp, slow)
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHeatIndexProperties(t *testing.T) {
+	// Within the Rothfusz regression's range and away from the NWS
+	// adjustments, the heat index rises with humidity and temperature
+	monotonic := func(tRaw, uRaw, aRaw, bRaw uint16) bool {
+		cool, warm := between(tRaw, 30, 45), between(uRaw, 30, 45)
+		if cool > warm {
+			cool, warm = warm, cool
+		}
+		dry, humid := between(aRaw, 13, 85), between(bRaw, 13, 85)
+		if dry > humid {
+			dry, humid = humid, dry
+		}
+		return HeatIndex(cool, dry) <= HeatIndex(cool, humid) &&
+			HeatIndex(cool, dry) <= HeatIndex(warm, dry)
+	}
+	if err := quick.Check(monotonic, nil); err != nil {
+		t.Error(err)
+	}
+
+	// Humid heat feels hotter than the air
+	bounded := func(tRaw, rhRaw uint16) bool {
+		temp, rh := between(tRaw, 30, 45), between(rhRaw, 50, 100)
+		return HeatIndex(temp, rh) >= temp
+	}
+	if err := quick.Check(bounded, nil); err != nil {
+		t.Error("heat index below air temperature:", err)
+	}
+}
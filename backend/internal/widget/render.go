@@ -0,0 +1,224 @@
+package widget
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// RenderSVG writes the card as a standalone SVG document
+func RenderSVG(w io.Writer, card Card, theme Theme, scale float64) error {
+	sc := layout(card, theme)
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g" font-family="Go, Helvetica, Arial, sans-serif">`,
+		sc.width*scale, sc.height*scale, sc.width, sc.height)
+	fmt.Fprintf(b, `<rect x="0.5" y="0.5" width="%g" height="%g" rx="8" fill="%s" stroke="%s"/>`,
+		sc.width-1, sc.height-1, hexColor(sc.background), hexColor(sc.border))
+
+	for _, shape := range sc.shapes {
+		switch s := shape.(type) {
+		case circle:
+			fmt.Fprintf(b, `<circle cx="%.2f" cy="%.2f" r="%.2f" fill="%s"/>`, s.x, s.y, s.r, hexColor(s.fill))
+		case line:
+			fmt.Fprintf(b, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%.2f" stroke-linecap="round"/>`,
+				s.x1, s.y1, s.x2, s.y2, hexColor(s.stroke), s.width)
+		case polygon:
+			b.WriteString(`<polygon points="`)
+			for i, p := range s.points {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				fmt.Fprintf(b, "%.2f,%.2f", p[0], p[1])
+			}
+			fmt.Fprintf(b, `" fill="%s"/>`, hexColor(s.fill))
+		case text:
+			anchor, weight := "start", "normal"
+			if s.anchor == anchorMiddle {
+				anchor = "middle"
+			}
+			if s.bold {
+				weight = "bold"
+			}
+			fmt.Fprintf(b, `<text x="%.2f" y="%.2f" font-size="%g" font-weight="%s" text-anchor="%s" fill="%s">%s</text>`,
+				s.x, s.y, s.size, weight, anchor, hexColor(s.fill), html.EscapeString(s.s))
+		}
+	}
+
+	b.WriteString(`</svg>`)
+	return b.Flush()
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+var (
+	regularFont = mustParseFont(goregular.TTF)
+	boldFont    = mustParseFont(gobold.TTF)
+)
+
+func mustParseFont(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// RenderPNG rasterizes the card with anti-aliased shapes and Go fonts
+func RenderPNG(w io.Writer, card Card, theme Theme, scale float64) error {
+	sc := layout(card, theme)
+	width := int(math.Ceil(sc.width * scale))
+	height := int(math.Ceil(sc.height * scale))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// Rounded card with a one pixel border
+	radius := 8 * scale
+	fillShape(img, img.Bounds(), func(x, y float64) float64 {
+		return roundedRectCoverage(x, y, float64(width), float64(height), radius)
+	}, sc.border)
+	fillShape(img, img.Bounds(), func(x, y float64) float64 {
+		return roundedRectCoverage(x-1, y-1, float64(width)-2, float64(height)-2, radius-1)
+	}, sc.background)
+
+	for _, shape := range sc.shapes {
+		switch s := shape.(type) {
+		case circle:
+			cx, cy, r := s.x*scale, s.y*scale, s.r*scale
+			fillShape(img, box(cx-r, cy-r, cx+r, cy+r), func(x, y float64) float64 {
+				return clamp(r - math.Hypot(x-cx, y-cy) + 0.5)
+			}, s.fill)
+		case line:
+			x1, y1, x2, y2, half := s.x1*scale, s.y1*scale, s.x2*scale, s.y2*scale, s.width*scale/2
+			fillShape(img, box(math.Min(x1, x2)-half, math.Min(y1, y2)-half, math.Max(x1, x2)+half, math.Max(y1, y2)+half), func(x, y float64) float64 {
+				return clamp(half - segmentDistance(x, y, x1, y1, x2, y2) + 0.5)
+			}, s.stroke)
+		case polygon:
+			points := make([][2]float64, len(s.points))
+			minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+			for i, p := range s.points {
+				points[i] = [2]float64{p[0] * scale, p[1] * scale}
+				minX, minY = math.Min(minX, points[i][0]), math.Min(minY, points[i][1])
+				maxX, maxY = math.Max(maxX, points[i][0]), math.Max(maxY, points[i][1])
+			}
+			fillShape(img, box(minX, minY, maxX, maxY), func(x, y float64) float64 {
+				return polygonCoverage(x, y, points)
+			}, s.fill)
+		case text:
+			if err := drawText(img, s, scale); err != nil {
+				return err
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// box returns the pixels touched by a bounding box, with a pixel of margin
+// for anti-aliasing
+func box(minX, minY, maxX, maxY float64) image.Rectangle {
+	return image.Rect(int(minX)-1, int(minY)-1, int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1)
+}
+
+// fillShape blends fill into every pixel of area in proportion to the
+// coverage of the pixel's centre
+func fillShape(img *image.RGBA, area image.Rectangle, coverage func(x, y float64) float64, fill color.RGBA) {
+	bounds := area.Intersect(img.Bounds())
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			a := coverage(float64(px)+0.5, float64(py)+0.5)
+			if a <= 0 {
+				continue
+			}
+			blend(img, px, py, fill, a)
+		}
+	}
+}
+
+func blend(img *image.RGBA, x, y int, c color.RGBA, alpha float64) {
+	i := img.PixOffset(x, y)
+	dst := img.Pix[i : i+4 : i+4]
+	a := alpha * float64(c.A) / 255
+	dst[0] = uint8(float64(dst[0])*(1-a) + float64(c.R)*a + 0.5)
+	dst[1] = uint8(float64(dst[1])*(1-a) + float64(c.G)*a + 0.5)
+	dst[2] = uint8(float64(dst[2])*(1-a) + float64(c.B)*a + 0.5)
+	dst[3] = uint8(float64(dst[3])*(1-a) + 255*a + 0.5)
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func segmentDistance(px, py, x1, y1, x2, y2 float64) float64 {
+	dx, dy := x2-x1, y2-y1
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = math.Max(0, math.Min(1, ((px-x1)*dx+(py-y1)*dy)/length))
+	}
+	return math.Hypot(px-(x1+t*dx), py-(y1+t*dy))
+}
+
+func roundedRectCoverage(x, y, width, height, radius float64) float64 {
+	// Distance outside the rectangle shrunk by radius, then grown back
+	qx := math.Abs(x-width/2) - (width/2 - radius)
+	qy := math.Abs(y-height/2) - (height/2 - radius)
+	outside := math.Hypot(math.Max(qx, 0), math.Max(qy, 0)) + math.Min(math.Max(qx, qy), 0)
+	return clamp(radius - outside + 0.5)
+}
+
+// polygonCoverage samples a 4x4 grid within the pixel
+func polygonCoverage(x, y float64, points [][2]float64) float64 {
+	inside := 0
+	for sy := 0; sy < 4; sy++ {
+		for sx := 0; sx < 4; sx++ {
+			if pointInPolygon(x-0.375+float64(sx)*0.25, y-0.375+float64(sy)*0.25, points) {
+				inside++
+			}
+		}
+	}
+	return float64(inside) / 16
+}
+
+func pointInPolygon(x, y float64, points [][2]float64) bool {
+	inside := false
+	for i, j := 0, len(points)-1; i < len(points); j, i = i, i+1 {
+		xi, yi, xj, yj := points[i][0], points[i][1], points[j][0], points[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func drawText(img *image.RGBA, s text, scale float64) error {
+	f := regularFont
+	if s.bold {
+		f = boldFont
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: s.size * scale, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	drawer := &font.Drawer{Dst: img, Src: image.NewUniform(s.fill), Face: face}
+	x := s.x * scale
+	if s.anchor == anchorMiddle {
+		x -= float64(drawer.MeasureString(s.s)) / 64 / 2
+	}
+	drawer.Dot = fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(s.y * scale * 64)}
+	drawer.DrawString(s.s)
+	return nil
+}
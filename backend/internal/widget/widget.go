@@ -0,0 +1,255 @@
+// Package widget renders a small multi-day forecast card as SVG or PNG in
+// pure Go, for partners who embed it with a plain <img> tag.
+//
+// Both formats draw the same scene: the card is laid out once as a list of
+// shapes in a fixed coordinate space, and each renderer scales it.
+package widget
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"eamagineweather-backend/internal/condition"
+	"eamagineweather-backend/internal/units"
+)
+
+// Base dimensions of a three-day card; Size multiplies them
+const (
+	columnWidth = 100
+	baseHeight  = 130
+)
+
+// Day is one column of the card
+type Day struct {
+	Date       time.Time
+	Min, Max   float64
+	RainChance int // Percent
+	Condition  condition.Code
+}
+
+// Card is everything a widget shows
+type Card struct {
+	Title string
+	Unit  string // Temperature unit symbol, e.g. "°C"
+	Lang  units.Lang
+	Days  []Day
+}
+
+// Theme holds the colours of a card
+type Theme struct {
+	Background color.RGBA
+	Border     color.RGBA
+	Text       color.RGBA
+	Muted      color.RGBA
+	Sun        color.RGBA
+	Cloud      color.RGBA
+	Rain       color.RGBA
+	Bolt       color.RGBA
+}
+
+// Themes by name
+var Themes = map[string]Theme{
+	"light": {
+		Background: color.RGBA{0xff, 0xff, 0xff, 0xff},
+		Border:     color.RGBA{0xe2, 0xe8, 0xf0, 0xff},
+		Text:       color.RGBA{0x0f, 0x17, 0x2a, 0xff},
+		Muted:      color.RGBA{0x64, 0x74, 0x8b, 0xff},
+		Sun:        color.RGBA{0xf5, 0x9e, 0x0b, 0xff},
+		Cloud:      color.RGBA{0x94, 0xa3, 0xb8, 0xff},
+		Rain:       color.RGBA{0x3b, 0x82, 0xf6, 0xff},
+		Bolt:       color.RGBA{0xea, 0xb3, 0x08, 0xff},
+	},
+	"dark": {
+		Background: color.RGBA{0x0f, 0x17, 0x2a, 0xff},
+		Border:     color.RGBA{0x33, 0x41, 0x55, 0xff},
+		Text:       color.RGBA{0xf8, 0xfa, 0xfc, 0xff},
+		Muted:      color.RGBA{0x94, 0xa3, 0xb8, 0xff},
+		Sun:        color.RGBA{0xfb, 0xbf, 0x24, 0xff},
+		Cloud:      color.RGBA{0xcb, 0xd5, 0xe1, 0xff},
+		Rain:       color.RGBA{0x60, 0xa5, 0xfa, 0xff},
+		Bolt:       color.RGBA{0xfa, 0xcc, 0x15, 0xff},
+	},
+}
+
+// Sizes maps size names to scale factors
+var Sizes = map[string]float64{
+	"small":  1,
+	"medium": 1.5,
+	"large":  2,
+}
+
+var dayNames = map[units.Lang][7]string{
+	units.Indonesian: {"Min", "Sen", "Sel", "Rab", "Kam", "Jum", "Sab"},
+	units.English:    {"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+}
+
+// Shapes of a scene, in base coordinates
+
+type circle struct {
+	x, y, r float64
+	fill    color.RGBA
+}
+
+type line struct {
+	x1, y1, x2, y2, width float64
+	stroke                color.RGBA
+}
+
+type polygon struct {
+	points [][2]float64
+	fill   color.RGBA
+}
+
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+)
+
+type text struct {
+	x, y   float64 // Baseline
+	s      string
+	size   float64
+	bold   bool
+	anchor anchor
+	fill   color.RGBA
+}
+
+type scene struct {
+	width, height float64
+	background    color.RGBA
+	border        color.RGBA
+	shapes        []any
+}
+
+// layout places the card's content
+func layout(card Card, theme Theme) scene {
+	columns := len(card.Days)
+	if columns == 0 {
+		columns = 1
+	}
+	sc := scene{
+		width:      float64(columns * columnWidth),
+		height:     baseHeight,
+		background: theme.Background,
+		border:     theme.Border,
+	}
+	add := func(s any) { sc.shapes = append(sc.shapes, s) }
+
+	add(text{x: 10, y: 20, s: truncate(card.Title, columns), size: 13, bold: true, fill: theme.Text})
+
+	names := dayNames[card.Lang]
+	if names[0] == "" {
+		names = dayNames[units.Indonesian]
+	}
+	rain := "Hujan"
+	if card.Lang == units.English {
+		rain = "Rain"
+	}
+
+	for i, day := range card.Days {
+		cx := float64(i*columnWidth) + columnWidth/2
+
+		label := fmt.Sprintf("%s %d", names[day.Date.Weekday()], day.Date.Day())
+		add(text{x: cx, y: 42, s: label, size: 11, anchor: anchorMiddle, fill: theme.Muted})
+
+		for _, s := range icon(day.Condition, cx, 66, 15, theme) {
+			add(s)
+		}
+
+		temps := formatTemperature(day.Max, card.Unit) + " / " + formatTemperature(day.Min, card.Unit)
+		add(text{x: cx, y: 102, s: temps, size: 13, bold: true, anchor: anchorMiddle, fill: theme.Text})
+		add(text{x: cx, y: 119, s: fmt.Sprintf("%s %d%%", rain, day.RainChance), size: 11, anchor: anchorMiddle, fill: theme.Rain})
+	}
+	return sc
+}
+
+func formatTemperature(v float64, unit string) string {
+	if unit == "K" {
+		return fmt.Sprintf("%.0fK", v)
+	}
+	return fmt.Sprintf("%.0f°", v)
+}
+
+// icon draws a condition centred on (x, y) with unit size k
+func icon(code condition.Code, x, y, k float64, theme Theme) []any {
+	switch code {
+	case condition.Clear:
+		return sun(x, y, k, theme)
+	case condition.MostlyClear, condition.PartlyCloudy:
+		return append(sun(x-0.35*k, y-0.3*k, 0.75*k, theme), cloud(x+0.2*k, y+0.2*k, 0.8*k, theme.Cloud)...)
+	case condition.Haze, condition.Smoke, condition.Fog:
+		var shapes []any
+		for i, dy := range []float64{-0.5, 0, 0.5} {
+			inset := float64(i%2) * 0.25 * k
+			shapes = append(shapes, line{x - k + inset, y + dy*k, x + k - inset, y + dy*k, 2.5, theme.Cloud})
+		}
+		return shapes
+	case condition.Drizzle, condition.LightRain, condition.Showers:
+		return append(cloud(x, y-0.2*k, k, theme.Cloud), rainLines(x, y-0.2*k, k, 2, theme)...)
+	case condition.Rain:
+		return append(cloud(x, y-0.2*k, k, theme.Cloud), rainLines(x, y-0.2*k, k, 3, theme)...)
+	case condition.HeavyRain, condition.VeryHeavyRain:
+		return append(cloud(x, y-0.2*k, k, theme.Cloud), rainLines(x, y-0.2*k, k, 4, theme)...)
+	case condition.Thunderstorm, condition.SevereThunderstorm:
+		return append(cloud(x, y-0.2*k, k, theme.Cloud), bolt(x, y-0.2*k, k, theme.Bolt))
+	default:
+		return cloud(x, y, k, theme.Cloud)
+	}
+}
+
+// rays are the directions of the eight sun rays
+var rays = [8][2]float64{
+	{1, 0}, {0.7071, 0.7071}, {0, 1}, {-0.7071, 0.7071},
+	{-1, 0}, {-0.7071, -0.7071}, {0, -1}, {0.7071, -0.7071},
+}
+
+func sun(x, y, k float64, theme Theme) []any {
+	shapes := []any{circle{x, y, 0.5 * k, theme.Sun}}
+	for _, ray := range rays {
+		dx, dy := ray[0], ray[1]
+		shapes = append(shapes, line{x + dx*0.7*k, y + dy*0.7*k, x + dx*0.95*k, y + dy*0.95*k, 0.14 * k, theme.Sun})
+	}
+	return shapes
+}
+
+func cloud(x, y, k float64, fill color.RGBA) []any {
+	return []any{
+		circle{x - 0.5*k, y + 0.15*k, 0.38 * k, fill},
+		circle{x + 0.05*k, y - 0.15*k, 0.55 * k, fill},
+		circle{x + 0.55*k, y + 0.15*k, 0.38 * k, fill},
+		line{x - 0.5*k, y + 0.3*k, x + 0.55*k, y + 0.3*k, 0.46 * k, fill},
+	}
+}
+
+func rainLines(x, y, k float64, n int, theme Theme) []any {
+	var shapes []any
+	spacing := 1.2 * k / float64(n)
+	start := x - spacing*float64(n-1)/2
+	for i := 0; i < n; i++ {
+		dx := start + float64(i)*spacing
+		shapes = append(shapes, line{dx, y + 0.75*k, dx - 0.15*k, y + 1.1*k, 0.12 * k, theme.Rain})
+	}
+	return shapes
+}
+
+func bolt(x, y, k float64, fill color.RGBA) polygon {
+	points := [][2]float64{{0.1, 0.45}, {-0.25, 0.95}, {0, 0.95}, {-0.12, 1.3}, {0.3, 0.78}, {0.05, 0.78}, {0.22, 0.45}}
+	for i := range points {
+		points[i] = [2]float64{x + points[i][0]*k, y + points[i][1]*k}
+	}
+	return polygon{points, fill}
+}
+
+// truncate shortens a title to fit a card of the given number of columns
+func truncate(title string, columns int) string {
+	title = strings.TrimSpace(title)
+	max := columns*columnWidth/8 - 2
+	if runes := []rune(title); len(runes) > max {
+		return string(runes[:max-1]) + "…"
+	}
+	return title
+}
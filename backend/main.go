@@ -3,149 +3,137 @@ package main
 import (
 	"context"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"eamagineweather-backend/internal/config"
 	"eamagineweather-backend/internal/database"
+	"eamagineweather-backend/internal/graph"
+	"eamagineweather-backend/internal/grpcserver"
 	"eamagineweather-backend/internal/handler"
-	"eamagineweather-backend/internal/middleware"
-	"eamagineweather-backend/internal/repository"
+	"eamagineweather-backend/internal/mqttpub"
 	"eamagineweather-backend/internal/service"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.Load()
 
-	// Initialize database
-	db, err := database.NewConnection(cfg.DatabaseURL)
+	// Maintenance commands run instead of the server
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(cfg, os.Args[1], os.Args[2:]))
+	}
+
+	// Without a database only the embedded regions can be served
+	if cfg.DatabaseURL == "" {
+		serveRegionsOnly(cfg)
+		return
+	}
+
+	// Initialize database, Postgres or SQLite depending on DATABASE_URL
+	storage, err := database.Open(cfg.DatabaseURL)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Disconnect()
+	defer storage.Close()
 
 	// Initialize Redis
 	redisClient := database.NewRedisClient(cfg.RedisURL)
-	defer redisClient.Close()
+	if redisClient != nil {
+		defer redisClient.Close()
+	}
 
 	// Initialize repositories
-	regionRepo := repository.NewRegionRepository(db.Client)
-	weatherRepo := repository.NewWeatherRepository(db.Client)
-	userRepo := repository.NewUserRepository(db.Client)
+	regionRepo := storage.Regions
+	weatherRepo := storage.Weather
+	userRepo := storage.Users
+	syncRepo := storage.SyncRuns
+	alertRepo := storage.Alerts
+	aliasRepo := storage.RegionAliases
 
 	// Initialize services
-	bmkgService := service.NewBMKGService(cfg.BMKGAPIBaseURL, redisClient)
-	weatherService := service.NewWeatherService(weatherRepo, regionRepo, bmkgService, redisClient)
+	bmkgService := service.NewBMKGService(cfg.BMKGAPIBaseURL, cfg.BMKGAPIRateLimit, redisClient)
+	weatherService := service.NewWeatherService(weatherRepo, regionRepo, syncRepo, bmkgService, redisClient, service.SyncPolicy{
+		Interval:   cfg.WeatherSyncInterval,
+		MaxRegions: cfg.WeatherSyncMaxRegions,
+	})
 	userService := service.NewUserService(userRepo)
+	alertService := service.NewAlertService(alertRepo, regionRepo)
+	aliasService := service.NewRegionAliasService(aliasRepo)
+	probeService := service.NewProbeService(regionRepo, bmkgService, probePolicy(cfg))
+	retentionService := service.NewRetentionService(weatherRepo, service.RetentionPolicy{
+		RawDays:       cfg.WeatherRawRetentionDays,
+		HardLimitDays: cfg.WeatherHardRetentionDays,
+		BatchSize:     cfg.WeatherRetentionBatchSize,
+		Interval:      cfg.WeatherRetentionInterval,
+	})
 
 	// Initialize handlers
-	weatherHandler := handler.NewWeatherHandler(weatherService)
+	weatherHandler := handler.NewWeatherHandler(weatherService, cfg.WeatherBatchMaxRegions)
 	userHandler := handler.NewUserHandler(userService)
-	regionsHandler := handler.NewSimpleRegionsHandler(db.Client, redisClient)
-
-	// Setup Gin
-	if cfg.GinMode == "release" {
-		gin.SetMode(gin.ReleaseMode)
+	regionStore, err := newRegionStore(cfg, storage)
+	if err != nil {
+		log.Fatal("Failed to load regions:", err)
 	}
+	regionsHandler := handler.NewSimpleRegionsHandler(regionStore, redisClient)
+	feedHandler := handler.NewFeedHandler(weatherService, alertService)
 
-	router := gin.New()
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-
-	// CORS configuration
-	corsConfig := cors.DefaultConfig()
-	
-	// Check if we have allowed origins configured
-	if len(cfg.AllowedOrigins) > 0 && cfg.AllowedOrigins[0] != "" {
-		corsConfig.AllowOrigins = cfg.AllowedOrigins
-	} else {
-		// Fallback to allow all origins if no specific origins configured
-		corsConfig.AllowAllOrigins = true
-	}
-	
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Requested-With"}
-	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	router.Use(cors.New(corsConfig))
-
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "ok",
-			"timestamp": time.Now().Unix(),
-			"service":   "eamagine-weather-api",
-		})
+	graphServer, err := graph.NewServer(regionRepo, userRepo, alertService, weatherService, graph.Limits{
+		MaxDepth:      cfg.GraphQLMaxDepth,
+		MaxComplexity: cfg.GraphQLMaxComplexity,
 	})
-
-	// API routes
-	api := router.Group("/api/v1")
-	{
-		// Weather routes
-		weather := api.Group("/weather")
-		{
-			weather.GET("/current/:regionCode", weatherHandler.GetCurrentWeather)
-			weather.GET("/forecast/:regionCode", weatherHandler.GetWeatherForecast)
-			weather.GET("/search", weatherHandler.SearchRegions)
-			weather.POST("/sync/:regionCode", weatherHandler.SyncWeatherData)
-		}
-
-		// Region routes
-		regions := api.Group("/regions")
-		{
-			regions.GET("/provinces", regionsHandler.GetProvinces)
-			regions.GET("/regencies/:provinceCode", regionsHandler.GetRegenciesByProvince)
-			regions.GET("/villages/:regencyCode", regionsHandler.GetVillagesByRegency)
-			regions.GET("/search", regionsHandler.SearchRegions)
-		}
-
-		// User routes (protected)
-		users := api.Group("/users")
-		users.Use(middleware.AuthMiddleware(cfg.JWTSecret))
-		{
-			users.GET("/profile", userHandler.GetProfile)
-			users.PUT("/profile", userHandler.UpdateProfile)
-			users.GET("/favorites", userHandler.GetFavorites)
-			users.POST("/favorites", userHandler.AddFavorite)
-			users.DELETE("/favorites/:regionId", userHandler.RemoveFavorite)
-		}
+	if err != nil {
+		log.Fatal("Failed to initialize GraphQL:", err)
 	}
+	graphqlHandler := handler.NewGraphQLHandler(graphServer)
+
+	router := newRouter(cfg, nil)
+
+	handler.RegisterRoutes(router, handler.Routes{
+		JWTSecret:    cfg.JWTSecret,
+		Preferences:  userService.GetPreferences,
+		ResolveAlias: aliasService.Resolve,
+		Weather:      weatherHandler,
+		Users:        userHandler,
+		Regions:      regionsHandler,
+		Feeds:        feedHandler,
+		GraphQL:      graphqlHandler,
+	})
 
 	// Start background services
 	go weatherService.StartPeriodicSync(context.Background())
-
-	// Setup server
-	server := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: router,
+	go retentionService.StartPeriodicRetention(context.Background())
+	go probeService.StartPeriodicProbe(context.Background())
+
+	// Start gRPC server on its own port
+	grpcCtx, stopGRPC := context.WithCancel(context.Background())
+	defer stopGRPC()
+	if cfg.GRPCPort != "" {
+		grpcServer := grpcserver.New(weatherService, regionRepo)
+		go func() {
+			if err := grpcServer.Serve(grpcCtx, ":"+cfg.GRPCPort, cfg.GRPCReflection); err != nil {
+				log.Fatal("Failed to start gRPC server:", err)
+			}
+		}()
 	}
 
-	// Start server in goroutine
-	go func() {
-		log.Printf("Server starting on port %s", cfg.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Failed to start server:", err)
+	// Publish weather and alerts to MQTT after each sync run
+	mqttCtx, stopMQTT := context.WithCancel(context.Background())
+	defer stopMQTT()
+	if cfg.MQTTBrokerURL != "" {
+		broker, err := mqttpub.Dial(mqttpub.BrokerConfig{
+			URL:      cfg.MQTTBrokerURL,
+			Username: cfg.MQTTUsername,
+			Password: cfg.MQTTPassword,
+			ClientID: cfg.MQTTClientID,
+		})
+		if err != nil {
+			log.Printf("MQTT publishing disabled: %v", err)
+		} else {
+			defer broker.Close()
+			publisher := mqttpub.New(broker, cfg.MQTTTopicPrefix, cfg.WeatherSyncMaxRegions, weatherService, alertService, regionRepo)
+			go publisher.Run(mqttCtx)
 		}
-	}()
-
-	// Wait for interrupt signal to gracefully shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutting down server...")
-
-	// Graceful shutdown with 10 second timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
 	}
 
-	log.Println("Server exited")
+	serve(cfg, router, stopGRPC, stopMQTT)
 }
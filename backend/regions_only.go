@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+
+	"eamagineweather-backend/internal/config"
+	"eamagineweather-backend/internal/database"
+	"eamagineweather-backend/internal/handler"
+	"eamagineweather-backend/internal/regionstore"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRegionStore returns the store REGION_SOURCE asks for
+func newRegionStore(cfg *config.Config, storage *database.Storage) (regionstore.Store, error) {
+	if cfg.RegionSource == "embedded" {
+		return regionstore.LoadEmbedded()
+	}
+	return storage.RegionStore(), nil
+}
+
+// serveRegionsOnly runs the API without a database or Redis: only health
+// and the /regions endpoints, from the embedded dataset. Meant for local
+// frontend work and edge nodes.
+func serveRegionsOnly(cfg *config.Config) {
+	store, err := regionstore.LoadEmbedded()
+	if err != nil {
+		log.Fatal("DATABASE_URL is not set and the embedded regions are unavailable: ", err)
+	}
+	log.Println("DATABASE_URL is not set; serving regions from the embedded dataset only")
+
+	router := newRouter(cfg, gin.H{"regions": "embedded"})
+
+	handler.RegisterRoutes(router, handler.Routes{
+		Regions: handler.NewSimpleRegionsHandler(store, nil),
+	})
+
+	serve(cfg, router)
+}
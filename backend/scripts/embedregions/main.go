@@ -0,0 +1,43 @@
+// Command embedregions compresses the region CSV for embedding into the
+// server binary. Run it through go generate in internal/regionstore.
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"io"
+	"log"
+	"os"
+)
+
+func main() {
+	in := flag.String("in", "indonesian_regions.csv", "region CSV of code,name rows")
+	out := flag.String("out", "regions.csv.gz", "compressed output")
+	flag.Parse()
+
+	src, err := os.Open(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	zw, err := gzip.NewWriterLevel(dst, gzip.BestCompression)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// No name or time in the header, so regenerating an unchanged CSV gives
+	// the same bytes
+	if _, err := io.Copy(zw, src); err != nil {
+		log.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := dst.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"eamagineweather-backend/internal/config"
+	"eamagineweather-backend/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRouter applies GIN_MODE and returns the shared router
+func newRouter(cfg *config.Config, health gin.H) *gin.Engine {
+	if cfg.GinMode == "release" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	return handler.NewRouter(cfg.AllowedOrigins, health)
+}
+
+// serve runs the HTTP server until SIGINT or SIGTERM. It then calls stop,
+// e.g. to end background servers, and shuts down with a 10 second timeout.
+func serve(cfg *config.Config, router http.Handler, stop ...func()) {
+	server := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: router,
+	}
+
+	// Start server in goroutine
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down server...")
+	for _, fn := range stop {
+		fn()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Fatal("Server forced to shutdown:", err)
+	}
+
+	log.Println("Server exited")
+}
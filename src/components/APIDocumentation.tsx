@@ -97,11 +97,11 @@ const APIDocumentation: React.FC = () => {
           example: '31.01'
         },
         {
-          name: 'page',
-          type: 'number',
+          name: 'cursor',
+          type: 'string',
           required: false,
-          description: 'Nomor halaman (default: 1)',
-          example: '1'
+          description: 'Nilai nextCursor dari halaman sebelumnya; kosong untuk halaman pertama',
+          example: 'eyJ3Ijp0cnVlLCJsIjo0LCJuIjoiR2FtYmlyIiwiYyI6IjMxLjAxLjAxLjEwMDEifQ'
         },
         {
           name: 'limit',
@@ -125,9 +125,8 @@ const APIDocumentation: React.FC = () => {
     }
   ],
   "total": 150,
-  "page": 1,
   "pageSize": 20,
-  "totalPages": 8
+  "nextCursor": "eyJ3Ijp0cnVlLCJsIjo0LCJuIjoiR2FtYmlyIiwiYyI6IjMxLjAxLjAxLjEwMDEifQ"
 }`
       }
     },
@@ -156,6 +155,13 @@ const APIDocumentation: React.FC = () => {
           required: false,
           description: 'Jumlah hasil maksimal (default: 20, max: 50)',
           example: '20'
+        },
+        {
+          name: 'cursor',
+          type: 'string',
+          required: false,
+          description: 'Nilai nextCursor dari halaman sebelumnya',
+          example: 'eyJ3Ijp0cnVlLCJsIjo0LCJuIjoiR2FtYmlyIiwiYyI6IjMxLjAxLjAxLjEwMDEifQ'
         }
       ],
       response: {
@@ -174,7 +180,8 @@ const APIDocumentation: React.FC = () => {
       "hasWeatherData": true
     }
   ],
-  "total": 15
+  "total": 15,
+  "nextCursor": null
 }`
       }
     },
@@ -71,23 +71,25 @@ const DatabaseWeatherGrid: React.FC = () => {
     };
   }, []);
 
-  const extractWeatherData = useCallback((bmkgData: unknown): WeatherData => {
-    // Extract current weather from BMKG API response
-    const data = bmkgData && typeof bmkgData === 'object' && 'data' in bmkgData ? bmkgData.data : null
-    const currentData = Array.isArray(data) && data.length > 0 && data[0] && 
-      typeof data[0] === 'object' && 'cuaca' in data[0] && 
-      Array.isArray(data[0].cuaca) && data[0].cuaca.length > 0 &&
-      Array.isArray(data[0].cuaca[0]) && data[0].cuaca[0].length > 0 
-      ? data[0].cuaca[0][0] : null;
+  const extractWeatherData = useCallback((response: unknown): WeatherData => {
+    // The backend returns the normalized weather response: { current, region, ... }
+    const currentData = response && typeof response === 'object' && 'current' in response ? response.current : null;
     
     if (currentData && typeof currentData === 'object') {
-      const weather = currentData as { t?: number; hu?: number; weather_desc?: string; ws?: number; }
+      const weather = currentData as {
+        temperature?: number;
+        humidity?: number;
+        description?: string;
+        weather_desc?: string;
+        wind_speed?: number;
+        icon?: string;
+      }
       return {
-        temperature: weather.t || 25,
-        humidity: weather.hu || 70,
-        condition: weather.weather_desc || 'Cerah',
-        windSpeed: weather.ws || 10,
-        icon: (weather as { image?: string }).image || '',
+        temperature: weather.temperature ?? 25,
+        humidity: weather.humidity ?? 70,
+        condition: weather.description || weather.weather_desc || 'Cerah',
+        windSpeed: weather.wind_speed ?? 10,
+        icon: weather.icon || '',
       };
     }
     
@@ -151,18 +151,16 @@ export function useVillagesByRegency(regencyCode: string | null) {
   const [villages, setVillages] = useState<Region[]>([]);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
-  const [page, setPage] = useState(1);
-  const [, setTotalPages] = useState(0);
-  const [hasMore, setHasMore] = useState(false);
+  const [nextCursor, setNextCursor] = useState<string | null>(null);
 
-  const fetchVillages = useCallback(async (regencyCode: string, page: number, append = false) => {
+  const fetchVillages = useCallback(async (regencyCode: string, cursor?: string, append = false) => {
     if (!append) {
       setLoading(true);
     }
     setError(null);
 
     try {
-      const response = await regionsApi.getVillagesByRegency(regencyCode, page);
+      const response = await regionsApi.getVillagesByRegency(regencyCode, cursor);
       
       if (append) {
         setVillages(prev => [...prev, ...response.data]);
@@ -170,9 +168,7 @@ export function useVillagesByRegency(regencyCode: string | null) {
         setVillages(response.data);
       }
       
-      setPage(response.page);
-      setTotalPages(response.totalPages);
-      setHasMore(response.page < response.totalPages);
+      setNextCursor(response.nextCursor);
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Failed to fetch villages');
     } finally {
@@ -180,21 +176,22 @@ export function useVillagesByRegency(regencyCode: string | null) {
     }
   }, []);
 
+  const hasMore = nextCursor !== null;
+
   const loadMore = useCallback(() => {
-    if (regencyCode && hasMore) {
-      fetchVillages(regencyCode, page + 1, true);
+    if (regencyCode && nextCursor) {
+      fetchVillages(regencyCode, nextCursor, true);
     }
-  }, [regencyCode, hasMore, page, fetchVillages]);
+  }, [regencyCode, nextCursor, fetchVillages]);
 
   useEffect(() => {
     if (!regencyCode) {
       setVillages([]);
-      setPage(1);
-      setHasMore(false);
+      setNextCursor(null);
       return;
     }
 
-    fetchVillages(regencyCode, 1);
+    fetchVillages(regencyCode);
   }, [regencyCode, fetchVillages]);
 
   return {
@@ -43,7 +43,7 @@ export const weatherAPI = {
   // Get current weather for a region
   getCurrentWeather: async (regionCode: string): Promise<WeatherResponse> => {
     const response = await api.get(`/weather/current/${regionCode}`)
-    return response.data.data
+    return response.data
   },
 
   // Get weather forecast for a region
@@ -28,6 +28,15 @@ export interface RegionsListResponse {
   totalPages: number;
 }
 
+// Region listings are paged by cursor: pass nextCursor back as cursor
+// until it is null
+export interface RegionsPageResponse {
+  data: Region[];
+  total: number;
+  pageSize: number;
+  nextCursor: string | null;
+}
+
 export interface RegionsSearchParams {
   level?: number;
   provinceCode?: string;
@@ -76,14 +85,18 @@ class RegionsApiClient {
     return response.json();
   }
 
-  // Get villages with weather data by regency (paginated)
+  // Get villages with weather data by regency (paginated by cursor)
   async getVillagesByRegency(
     regencyCode: string,
-    page = 1,
+    cursor?: string,
     limit = 20
-  ): Promise<RegionsListResponse> {
+  ): Promise<RegionsPageResponse> {
+    const searchParams = new URLSearchParams();
+    if (cursor) searchParams.append('cursor', cursor);
+    searchParams.append('limit', limit.toString());
+
     const response = await fetch(
-      `${BASE_URL}/regions/villages/${regencyCode}?page=${page}&limit=${limit}`
+      `${BASE_URL}/regions/villages/${regencyCode}?${searchParams}`
     );
     if (!response.ok) {
       throw new Error(`Failed to fetch villages: ${response.statusText}`);
@@ -120,23 +133,33 @@ class RegionsApiClient {
 
   // Batch get weather data for multiple regions
   async batchGetWeatherData(regionCodes: string[]) {
-    const weatherPromises = regionCodes.map(code => 
-      this.getWeatherData(code).catch(error => {
-        console.warn(`Failed to fetch weather for ${code}:`, error);
-        return null;
-      })
-    );
-    
-    const results = await Promise.all(weatherPromises);
-    
-    // Return a Map for easy lookup
     const weatherMap = new Map();
-    regionCodes.forEach((code, index) => {
-      if (results[index]) {
-        weatherMap.set(code, results[index]);
+
+    // The backend accepts a limited number of codes per request
+    const chunkSize = 50;
+    for (let i = 0; i < regionCodes.length; i += chunkSize) {
+      const chunk = regionCodes.slice(i, i + chunkSize);
+      const response = await fetch(`${BASE_URL}/weather/current/batch`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ codes: chunk }),
+      });
+      if (!response.ok) {
+        console.warn(`Failed to fetch batch weather: ${response.statusText}`);
+        continue;
       }
-    });
-    
+
+      const { data } = await response.json();
+      for (const [code, result] of Object.entries<{ weather?: unknown; error?: string }>(data)) {
+        if (result.weather) {
+          // Same shape as getWeatherData
+          weatherMap.set(code, result.weather);
+        } else {
+          console.warn(`Failed to fetch weather for ${code}:`, result.error);
+        }
+      }
+    }
+
     return weatherMap;
   }
 }